@@ -0,0 +1,211 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"inbox-service/internal/application/ports"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidRequest = errors.New("invalid request")
+
+const (
+	ActionOutboxRequeue = "outbox.requeue"
+	ActionOutboxSkip    = "outbox.skip"
+	ActionOutboxPurge   = "outbox.purge"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 500
+)
+
+type ListOutboxQuery struct {
+	Filter ports.OutboxFilter
+	After  *ports.OutboxPosition
+	Limit  int
+}
+
+type OutboxPage struct {
+	Rows []ports.OutboxRow
+	// Next is set when there may be more rows.
+	Next *ports.OutboxPosition
+}
+
+// OutboxAdmin lets operators inspect and repair the outbox. Every mutation is
+// written to the audit log in the same transaction.
+type OutboxAdmin struct {
+	Tx    ports.TxManager
+	Store ports.OutboxAdminStore
+	Audit ports.AuditLog
+
+	now func() time.Time
+}
+
+func NewOutboxAdmin(tx ports.TxManager, store ports.OutboxAdminStore, audit ports.AuditLog) *OutboxAdmin {
+	return &OutboxAdmin{
+		Tx:    tx,
+		Store: store,
+		Audit: audit,
+		now:   func() time.Time { return time.Now().UTC() },
+	}
+}
+
+func (a *OutboxAdmin) List(ctx context.Context, q ListOutboxQuery) (OutboxPage, error) {
+	if err := validFilter(q.Filter); err != nil {
+		return OutboxPage{}, err
+	}
+	if q.After != nil {
+		if _, err := uuid.Parse(q.After.ID); err != nil {
+			return OutboxPage{}, fmt.Errorf("%w: before_id must be a UUID", ErrInvalidRequest)
+		}
+	}
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	if limit > maxListLimit {
+		limit = maxListLimit
+	}
+
+	rows, err := a.Store.ListOutbox(ctx, q.Filter, q.After, limit)
+	if err != nil {
+		return OutboxPage{}, err
+	}
+	page := OutboxPage{Rows: rows}
+	if len(rows) == limit {
+		last := rows[len(rows)-1]
+		page.Next = &ports.OutboxPosition{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+	return page, nil
+}
+
+func (a *OutboxAdmin) Get(ctx context.Context, id string) (ports.OutboxRow, []ports.OutboxAttempt, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return ports.OutboxRow{}, nil, fmt.Errorf("%w: id must be a UUID", ErrInvalidRequest)
+	}
+	return a.Store.GetOutbox(ctx, id)
+}
+
+// BlockedKeys lists the partition keys held back by a FAILED row. Nothing
+// behind such a row is published until it is requeued or skipped, which keeps
+// each key in order.
+func (a *OutboxAdmin) BlockedKeys(ctx context.Context, limit int) ([]ports.BlockedKey, error) {
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	if limit > maxListLimit {
+		limit = maxListLimit
+	}
+	return a.Store.ListBlockedKeys(ctx, limit)
+}
+
+// Requeue gives matching FAILED rows a fresh attempt budget. An empty filter
+// requeues every FAILED row.
+func (a *OutboxAdmin) Requeue(ctx context.Context, actor string, f ports.OutboxFilter) (int, error) {
+	if f.Status != "" && f.Status != ports.OutboxFailed {
+		return 0, fmt.Errorf("%w: only FAILED rows can be requeued", ErrInvalidRequest)
+	}
+	if err := validFilter(f); err != nil {
+		return 0, err
+	}
+	return a.mutate(ctx, actor, ActionOutboxRequeue, filterParams(f), func(ctx context.Context, tx ports.Tx, now time.Time) (int, error) {
+		return a.Store.RequeueOutbox(ctx, tx, f, now)
+	})
+}
+
+// Skip takes matching PENDING or FAILED rows out of the relay for good. At
+// least one filter is required, so a typo can't skip the whole table.
+func (a *OutboxAdmin) Skip(ctx context.Context, actor string, f ports.OutboxFilter) (int, error) {
+	switch f.Status {
+	case "", ports.OutboxPending, ports.OutboxFailed:
+	default:
+		return 0, fmt.Errorf("%w: only PENDING or FAILED rows can be skipped", ErrInvalidRequest)
+	}
+	if len(f.IDs) == 0 && f.TenantID == "" && f.EventType == "" && f.CreatedBefore == nil && f.CreatedAfter == nil {
+		return 0, fmt.Errorf("%w: skip needs ids or a filter", ErrInvalidRequest)
+	}
+	if err := validFilter(f); err != nil {
+		return 0, err
+	}
+	return a.mutate(ctx, actor, ActionOutboxSkip, filterParams(f), func(ctx context.Context, tx ports.Tx, now time.Time) (int, error) {
+		return a.Store.SkipOutbox(ctx, tx, f, now)
+	})
+}
+
+// PurgeSent deletes SENT rows (and their attempts) older than the given
+// number of days.
+func (a *OutboxAdmin) PurgeSent(ctx context.Context, actor string, olderThanDays int) (int, error) {
+	if olderThanDays < 1 {
+		return 0, fmt.Errorf("%w: older_than_days must be at least 1", ErrInvalidRequest)
+	}
+	params := map[string]any{"older_than_days": olderThanDays}
+	return a.mutate(ctx, actor, ActionOutboxPurge, params, func(ctx context.Context, tx ports.Tx, now time.Time) (int, error) {
+		return a.Store.PurgeSentOutbox(ctx, tx, now.AddDate(0, 0, -olderThanDays))
+	})
+}
+
+func (a *OutboxAdmin) mutate(ctx context.Context, actor, action string, params map[string]any, fn func(ctx context.Context, tx ports.Tx, now time.Time) (int, error)) (int, error) {
+	if actor == "" {
+		return 0, fmt.Errorf("%w: actor is required", ErrInvalidRequest)
+	}
+	var n int
+	err := a.Tx.WithTx(ctx, func(ctx context.Context, tx ports.Tx) error {
+		var err error
+		if n, err = fn(ctx, tx, a.now()); err != nil {
+			return err
+		}
+		return a.Audit.Record(ctx, tx, ports.AuditEntry{Actor: actor, Action: action, Params: params, Affected: n})
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+func validFilter(f ports.OutboxFilter) error {
+	switch f.Status {
+	case "", ports.OutboxPending, ports.OutboxProcessing, ports.OutboxSent, ports.OutboxFailed, ports.OutboxSkipped:
+	default:
+		return fmt.Errorf("%w: unknown status %q", ErrInvalidRequest, f.Status)
+	}
+	for _, id := range f.IDs {
+		if _, err := uuid.Parse(id); err != nil {
+			return fmt.Errorf("%w: id %q is not a UUID", ErrInvalidRequest, id)
+		}
+	}
+	if f.TenantID != "" {
+		if _, err := uuid.Parse(f.TenantID); err != nil {
+			return fmt.Errorf("%w: tenant_id must be a UUID", ErrInvalidRequest)
+		}
+	}
+	return nil
+}
+
+// filterParams is how a filter shows up in the audit log.
+func filterParams(f ports.OutboxFilter) map[string]any {
+	p := map[string]any{}
+	if len(f.IDs) > 0 {
+		p["ids"] = f.IDs
+	}
+	if f.Status != "" {
+		p["status"] = f.Status
+	}
+	if f.TenantID != "" {
+		p["tenant_id"] = f.TenantID
+	}
+	if f.EventType != "" {
+		p["event_type"] = f.EventType
+	}
+	if f.CreatedBefore != nil {
+		p["created_before"] = f.CreatedBefore.Format(time.RFC3339Nano)
+	}
+	if f.CreatedAfter != nil {
+		p["created_after"] = f.CreatedAfter.Format(time.RFC3339Nano)
+	}
+	return p
+}
@@ -0,0 +1,207 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"inbox-service/internal/application/ports"
+)
+
+// --- fakes ---
+
+type fakeTxMgr struct{}
+
+func (fakeTxMgr) WithTx(ctx context.Context, fn func(ctx context.Context, tx ports.Tx) error) error {
+	return fn(ctx, nil)
+}
+
+type fakeStore struct {
+	rows     []ports.OutboxRow
+	filter   ports.OutboxFilter
+	limit    int
+	affected int
+	purged   time.Time
+	err      error
+}
+
+func (s *fakeStore) ListOutbox(ctx context.Context, f ports.OutboxFilter, after *ports.OutboxPosition, limit int) ([]ports.OutboxRow, error) {
+	s.filter, s.limit = f, limit
+	if len(s.rows) > limit {
+		return s.rows[:limit], nil
+	}
+	return s.rows, nil
+}
+
+func (s *fakeStore) GetOutbox(ctx context.Context, id string) (ports.OutboxRow, []ports.OutboxAttempt, error) {
+	return ports.OutboxRow{}, nil, ports.ErrNotFound
+}
+
+func (s *fakeStore) ListBlockedKeys(ctx context.Context, limit int) ([]ports.BlockedKey, error) {
+	s.limit = limit
+	return []ports.BlockedKey{{PartitionKey: "t/u", Waiting: 3}}, s.err
+}
+
+func (s *fakeStore) RequeueOutbox(ctx context.Context, tx ports.Tx, f ports.OutboxFilter, now time.Time) (int, error) {
+	s.filter = f
+	return s.affected, s.err
+}
+
+func (s *fakeStore) SkipOutbox(ctx context.Context, tx ports.Tx, f ports.OutboxFilter, now time.Time) (int, error) {
+	s.filter = f
+	return s.affected, s.err
+}
+
+func (s *fakeStore) PurgeSentOutbox(ctx context.Context, tx ports.Tx, olderThan time.Time) (int, error) {
+	s.purged = olderThan
+	return s.affected, s.err
+}
+
+type fakeAudit struct {
+	entries []ports.AuditEntry
+}
+
+func (a *fakeAudit) Record(ctx context.Context, tx ports.Tx, e ports.AuditEntry) error {
+	a.entries = append(a.entries, e)
+	return nil
+}
+
+// --- tests ---
+
+func TestOutboxAdmin_ListPagesAndClampsLimit(t *testing.T) {
+	store := &fakeStore{}
+	t0 := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		store.rows = append(store.rows, ports.OutboxRow{ID: fmt.Sprintf("row-%d", i), CreatedAt: t0.Add(-time.Duration(i) * time.Minute)})
+	}
+	a := NewOutboxAdmin(fakeTxMgr{}, store, &fakeAudit{})
+
+	page, err := a.List(context.Background(), ListOutboxQuery{Limit: 2})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(page.Rows) != 2 || page.Next == nil || page.Next.ID != "row-1" {
+		t.Fatalf("expected 2 rows and a next position at row-1, got %+v", page)
+	}
+
+	page, err = a.List(context.Background(), ListOutboxQuery{Limit: 10000})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if store.limit != maxListLimit || page.Next != nil {
+		t.Fatalf("expected limit %d and no next page, got %d / %+v", maxListLimit, store.limit, page.Next)
+	}
+
+	_, err = a.List(context.Background(), ListOutboxQuery{Filter: ports.OutboxFilter{Status: "BOGUS"}})
+	if !errors.Is(err, ErrInvalidRequest) {
+		t.Fatalf("expected ErrInvalidRequest for an unknown status, got %v", err)
+	}
+
+	_, err = a.List(context.Background(), ListOutboxQuery{After: &ports.OutboxPosition{CreatedAt: t0, ID: "row-1"}})
+	if !errors.Is(err, ErrInvalidRequest) {
+		t.Fatalf("expected ErrInvalidRequest for a non-UUID position, got %v", err)
+	}
+}
+
+func TestOutboxAdmin_RequeueIsAudited(t *testing.T) {
+	store := &fakeStore{affected: 4}
+	audit := &fakeAudit{}
+	a := NewOutboxAdmin(fakeTxMgr{}, store, audit)
+
+	n, err := a.Requeue(context.Background(), "ops@example.com", ports.OutboxFilter{EventType: "InboxItemCreated"})
+	if err != nil {
+		t.Fatalf("Requeue: %v", err)
+	}
+	if n != 4 {
+		t.Fatalf("expected 4 requeued, got %d", n)
+	}
+	if len(audit.entries) != 1 {
+		t.Fatalf("expected one audit entry, got %d", len(audit.entries))
+	}
+	e := audit.entries[0]
+	if e.Actor != "ops@example.com" || e.Action != ActionOutboxRequeue || e.Affected != 4 || e.Params["event_type"] != "InboxItemCreated" {
+		t.Fatalf("unexpected audit entry %+v", e)
+	}
+
+	_, err = a.Requeue(context.Background(), "ops@example.com", ports.OutboxFilter{Status: ports.OutboxSent})
+	if !errors.Is(err, ErrInvalidRequest) {
+		t.Fatalf("expected ErrInvalidRequest when requeueing SENT rows, got %v", err)
+	}
+}
+
+func TestOutboxAdmin_FailedMutationIsNotAudited(t *testing.T) {
+	store := &fakeStore{err: errors.New("boom")}
+	audit := &fakeAudit{}
+	a := NewOutboxAdmin(fakeTxMgr{}, store, audit)
+
+	if _, err := a.Requeue(context.Background(), "ops", ports.OutboxFilter{}); err == nil {
+		t.Fatalf("expected the store error")
+	}
+	if len(audit.entries) != 0 {
+		t.Fatalf("expected no audit entry, got %+v", audit.entries)
+	}
+}
+
+func TestOutboxAdmin_SkipNeedsAFilter(t *testing.T) {
+	store := &fakeStore{affected: 1}
+	a := NewOutboxAdmin(fakeTxMgr{}, store, &fakeAudit{})
+
+	_, err := a.Skip(context.Background(), "ops", ports.OutboxFilter{})
+	if !errors.Is(err, ErrInvalidRequest) {
+		t.Fatalf("expected ErrInvalidRequest for an empty filter, got %v", err)
+	}
+	_, err = a.Skip(context.Background(), "ops", ports.OutboxFilter{IDs: []string{"not-a-uuid"}})
+	if !errors.Is(err, ErrInvalidRequest) {
+		t.Fatalf("expected ErrInvalidRequest for a bad id, got %v", err)
+	}
+
+	id := "11111111-1111-1111-1111-111111111111"
+	n, err := a.Skip(context.Background(), "ops", ports.OutboxFilter{IDs: []string{id}})
+	if err != nil || n != 1 {
+		t.Fatalf("expected 1 skipped, got %d / %v", n, err)
+	}
+	if len(store.filter.IDs) != 1 || store.filter.IDs[0] != id {
+		t.Fatalf("expected the id to reach the store, got %+v", store.filter)
+	}
+}
+
+func TestOutboxAdmin_PurgeSent(t *testing.T) {
+	store := &fakeStore{affected: 10}
+	audit := &fakeAudit{}
+	a := NewOutboxAdmin(fakeTxMgr{}, store, audit)
+	now := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	a.now = func() time.Time { return now }
+
+	if _, err := a.PurgeSent(context.Background(), "ops", 0); !errors.Is(err, ErrInvalidRequest) {
+		t.Fatalf("expected ErrInvalidRequest for 0 days, got %v", err)
+	}
+
+	n, err := a.PurgeSent(context.Background(), "ops", 7)
+	if err != nil || n != 10 {
+		t.Fatalf("expected 10 purged, got %d / %v", n, err)
+	}
+	if want := now.AddDate(0, 0, -7); !store.purged.Equal(want) {
+		t.Fatalf("expected cutoff %v, got %v", want, store.purged)
+	}
+	if len(audit.entries) != 1 || audit.entries[0].Action != ActionOutboxPurge {
+		t.Fatalf("expected a purge audit entry, got %+v", audit.entries)
+	}
+}
+
+func TestOutboxAdmin_BlockedKeysClampsLimit(t *testing.T) {
+	store := &fakeStore{}
+	a := NewOutboxAdmin(fakeTxMgr{}, store, &fakeAudit{})
+
+	keys, err := a.BlockedKeys(context.Background(), 0)
+	if err != nil {
+		t.Fatalf("BlockedKeys: %v", err)
+	}
+	if len(keys) != 1 || keys[0].PartitionKey != "t/u" || store.limit != defaultListLimit {
+		t.Fatalf("unexpected keys %+v / limit %d", keys, store.limit)
+	}
+	if _, err := a.BlockedKeys(context.Background(), 10000); err != nil || store.limit != maxListLimit {
+		t.Fatalf("expected limit %d, got %d (%v)", maxListLimit, store.limit, err)
+	}
+}
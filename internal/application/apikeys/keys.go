@@ -0,0 +1,196 @@
+package apikeys
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"inbox-service/internal/application/ports"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidRequest = errors.New("invalid request")
+	// ErrInvalidKey is returned by Authenticate for unknown, revoked or
+	// malformed keys.
+	ErrInvalidKey = errors.New("invalid api key")
+)
+
+const (
+	ActionCreate = "apikey.create"
+	ActionRevoke = "apikey.revoke"
+)
+
+// keyPrefix starts every key, so leaked ones are easy to grep for.
+const keyPrefix = "ink_"
+
+// Input creates a key. TenantIDs may be left empty and otherwise must name
+// only the caller's tenant; EventTypes may be left empty to allow all types.
+type Input struct {
+	Name       string
+	TenantIDs  []string
+	EventTypes []string
+}
+
+// Keys issues and checks the service API keys that producers use on the
+// ingest endpoints. Keys are random, so a plain SHA-256 is enough to store
+// them; issuing and revoking goes to the audit log. A tenant admin manages
+// only keys issued for their tenant alone, so keys spanning several tenants
+// are out of reach of every one of them.
+type Keys struct {
+	Tx    ports.TxManager
+	Store ports.APIKeyStore
+	Audit ports.AuditLog
+
+	now    func() time.Time
+	newKey func() (string, error)
+}
+
+func NewKeys(tx ports.TxManager, store ports.APIKeyStore, audit ports.AuditLog) *Keys {
+	return &Keys{
+		Tx:     tx,
+		Store:  store,
+		Audit:  audit,
+		now:    func() time.Time { return time.Now().UTC() },
+		newKey: randomKey,
+	}
+}
+
+// Create returns the new key for tenantID and its plaintext, which is not
+// stored and can't be shown again.
+func (k *Keys) Create(ctx context.Context, actor, tenantID string, in Input) (ports.APIKey, string, error) {
+	name := strings.TrimSpace(in.Name)
+	if name == "" {
+		return ports.APIKey{}, "", fmt.Errorf("%w: name required", ErrInvalidRequest)
+	}
+	own, err := validTenants([]string{tenantID})
+	if err != nil {
+		return ports.APIKey{}, "", err
+	}
+	if len(in.TenantIDs) == 0 {
+		in.TenantIDs = own
+	}
+	tenants, err := validTenants(in.TenantIDs)
+	if err != nil {
+		return ports.APIKey{}, "", err
+	}
+	if !slices.Equal(tenants, own) {
+		return ports.APIKey{}, "", fmt.Errorf("%w: keys can only be issued for your own tenant", ErrInvalidRequest)
+	}
+	types, err := validEventTypes(in.EventTypes)
+	if err != nil {
+		return ports.APIKey{}, "", err
+	}
+	plain, err := k.newKey()
+	if err != nil {
+		return ports.APIKey{}, "", err
+	}
+	key := ports.APIKey{
+		ID:         uuid.NewString(),
+		Name:       name,
+		Prefix:     plain[:len(keyPrefix)+8],
+		TenantIDs:  tenants,
+		EventTypes: types,
+		CreatedAt:  k.now(),
+	}
+	err = k.Tx.WithTx(ctx, func(ctx context.Context, tx ports.Tx) error {
+		if err := k.Store.CreateAPIKey(ctx, tx, key, hash(plain)); err != nil {
+			return err
+		}
+		return k.Audit.Record(ctx, tx, ports.AuditEntry{
+			Actor:    actor,
+			Action:   ActionCreate,
+			Params:   map[string]any{"id": key.ID, "name": key.Name, "tenant_ids": key.TenantIDs, "event_types": key.EventTypes},
+			Affected: 1,
+		})
+	})
+	if err != nil {
+		return ports.APIKey{}, "", err
+	}
+	return key, plain, nil
+}
+
+func (k *Keys) List(ctx context.Context, tenantID string) ([]ports.APIKey, error) {
+	if _, err := uuid.Parse(tenantID); err != nil {
+		return nil, fmt.Errorf("%w: invalid tenant id", ErrInvalidRequest)
+	}
+	return k.Store.ListAPIKeys(ctx, tenantID)
+}
+
+// Revoke disables one of tenantID's keys for good; other tenants' keys are
+// ErrNotFound.
+func (k *Keys) Revoke(ctx context.Context, actor, tenantID, id string) error {
+	if _, err := uuid.Parse(tenantID); err != nil {
+		return fmt.Errorf("%w: invalid tenant id", ErrInvalidRequest)
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return fmt.Errorf("%w: invalid id", ErrInvalidRequest)
+	}
+	return k.Tx.WithTx(ctx, func(ctx context.Context, tx ports.Tx) error {
+		if err := k.Store.RevokeAPIKey(ctx, tx, tenantID, id, k.now()); err != nil {
+			return err
+		}
+		return k.Audit.Record(ctx, tx, ports.AuditEntry{Actor: actor, Action: ActionRevoke, Params: map[string]any{"id": id, "tenant_id": tenantID}, Affected: 1})
+	})
+}
+
+// Authenticate returns the key matching plain.
+func (k *Keys) Authenticate(ctx context.Context, plain string) (ports.APIKey, error) {
+	if !strings.HasPrefix(plain, keyPrefix) {
+		return ports.APIKey{}, ErrInvalidKey
+	}
+	key, err := k.Store.FindAPIKey(ctx, hash(plain))
+	if errors.Is(err, ports.ErrNotFound) {
+		return ports.APIKey{}, ErrInvalidKey
+	}
+	return key, err
+}
+
+func hash(plain string) []byte {
+	sum := sha256.Sum256([]byte(plain))
+	return sum[:]
+}
+
+func randomKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate api key: %w", err)
+	}
+	return keyPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func validTenants(ids []string) ([]string, error) {
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%w: at least one tenant id required", ErrInvalidRequest)
+	}
+	out := make([]string, 0, len(ids))
+	for _, id := range ids {
+		u, err := uuid.Parse(strings.TrimSpace(id))
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid tenant id %q", ErrInvalidRequest, id)
+		}
+		out = append(out, u.String())
+	}
+	slices.Sort(out)
+	return slices.Compact(out), nil
+}
+
+func validEventTypes(types []string) ([]string, error) {
+	out := make([]string, 0, len(types))
+	for _, t := range types {
+		t = strings.TrimSpace(t)
+		if t == "" {
+			return nil, fmt.Errorf("%w: empty event type", ErrInvalidRequest)
+		}
+		out = append(out, t)
+	}
+	slices.Sort(out)
+	return slices.Compact(out), nil
+}
@@ -0,0 +1,152 @@
+package apikeys
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"inbox-service/internal/application/ports"
+)
+
+// --- fakes ---
+
+type runTx struct{}
+
+func (runTx) WithTx(ctx context.Context, fn func(ctx context.Context, tx ports.Tx) error) error {
+	return fn(ctx, nil)
+}
+
+type storedKey struct {
+	key  ports.APIKey
+	hash []byte
+}
+
+type memStore struct{ keys []*storedKey }
+
+func (s *memStore) CreateAPIKey(ctx context.Context, tx ports.Tx, k ports.APIKey, hash []byte) error {
+	s.keys = append(s.keys, &storedKey{key: k, hash: hash})
+	return nil
+}
+
+func (s *memStore) ListAPIKeys(ctx context.Context, tenantID string) ([]ports.APIKey, error) {
+	var out []ports.APIKey
+	for _, k := range s.keys {
+		if slices.Equal(k.key.TenantIDs, []string{tenantID}) {
+			out = append(out, k.key)
+		}
+	}
+	return out, nil
+}
+
+func (s *memStore) FindAPIKey(ctx context.Context, hash []byte) (ports.APIKey, error) {
+	for _, k := range s.keys {
+		if bytes.Equal(k.hash, hash) && k.key.RevokedAt == nil {
+			return k.key, nil
+		}
+	}
+	return ports.APIKey{}, ports.ErrNotFound
+}
+
+func (s *memStore) RevokeAPIKey(ctx context.Context, tx ports.Tx, tenantID, id string, at time.Time) error {
+	for _, k := range s.keys {
+		if k.key.ID == id && slices.Equal(k.key.TenantIDs, []string{tenantID}) && k.key.RevokedAt == nil {
+			k.key.RevokedAt = &at
+			return nil
+		}
+	}
+	return ports.ErrNotFound
+}
+
+type memAudit struct{ entries []ports.AuditEntry }
+
+func (a *memAudit) Record(ctx context.Context, tx ports.Tx, e ports.AuditEntry) error {
+	a.entries = append(a.entries, e)
+	return nil
+}
+
+// --- tests ---
+
+func TestKeys_CreateAuthenticateRevoke(t *testing.T) {
+	store, audit := &memStore{}, &memAudit{}
+	k := NewKeys(runTx{}, store, audit)
+	ctx := context.Background()
+	tenant, other := "aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa", "cccccccc-cccc-cccc-cccc-cccccccccccc"
+
+	key, plain, err := k.Create(ctx, "ops", tenant, Input{Name: "tasks", TenantIDs: []string{tenant, tenant}, EventTypes: []string{"TaskAssignedToUser"}})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if !strings.HasPrefix(plain, keyPrefix) || !strings.HasPrefix(plain, key.Prefix) || len(key.TenantIDs) != 1 {
+		t.Fatalf("unexpected key %+v / %q", key, plain)
+	}
+	if bytes.Contains(store.keys[0].hash, []byte(plain)) || len(store.keys[0].hash) != 32 {
+		t.Fatalf("expected only a SHA-256 of the key to be stored")
+	}
+
+	got, err := k.Authenticate(ctx, plain)
+	if err != nil || got.ID != key.ID {
+		t.Fatalf("Authenticate: %+v, %v", got, err)
+	}
+	for _, bad := range []string{"", "nope", plain + "x"} {
+		if _, err := k.Authenticate(ctx, bad); !errors.Is(err, ErrInvalidKey) {
+			t.Fatalf("%q: expected ErrInvalidKey, got %v", bad, err)
+		}
+	}
+
+	if err := k.Revoke(ctx, "ops", other, key.ID); !errors.Is(err, ports.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound revoking another tenant's key, got %v", err)
+	}
+	if err := k.Revoke(ctx, "ops", tenant, key.ID); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	if _, err := k.Authenticate(ctx, plain); !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("expected a revoked key to be rejected, got %v", err)
+	}
+	if err := k.Revoke(ctx, "ops", tenant, key.ID); !errors.Is(err, ports.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound revoking twice, got %v", err)
+	}
+
+	if len(audit.entries) != 2 || audit.entries[0].Action != ActionCreate || audit.entries[1].Action != ActionRevoke || audit.entries[1].Actor != "ops" {
+		t.Fatalf("unexpected audit log %+v", audit.entries)
+	}
+}
+
+func TestKeys_ScopedToTheCallersTenant(t *testing.T) {
+	k := NewKeys(runTx{}, &memStore{}, &memAudit{})
+	ctx := context.Background()
+	tenant, other := "aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa", "cccccccc-cccc-cccc-cccc-cccccccccccc"
+
+	// Without tenant_ids the key is for the caller's tenant.
+	mine, _, err := k.Create(ctx, "ops", tenant, Input{Name: "tasks"})
+	if err != nil || !slices.Equal(mine.TenantIDs, []string{tenant}) {
+		t.Fatalf("Create: %+v, %v", mine, err)
+	}
+	if _, _, err := k.Create(ctx, "ops", other, Input{Name: "billing"}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	list, err := k.List(ctx, tenant)
+	if err != nil || len(list) != 1 || list[0].ID != mine.ID {
+		t.Fatalf("expected only the tenant's own key listed: %v %+v", err, list)
+	}
+}
+
+func TestKeys_CreateValidates(t *testing.T) {
+	k := NewKeys(runTx{}, &memStore{}, &memAudit{})
+	for _, in := range []Input{
+		{TenantIDs: []string{"aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa"}},
+		{Name: "x", TenantIDs: []string{"not-a-uuid"}},
+		{Name: "x", TenantIDs: []string{"aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa"}, EventTypes: []string{" "}},
+		// Another tenant's, or one spanning several.
+		{Name: "x", TenantIDs: []string{"cccccccc-cccc-cccc-cccc-cccccccccccc"}},
+		{Name: "x", TenantIDs: []string{"aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa", "cccccccc-cccc-cccc-cccc-cccccccccccc"}},
+	} {
+		if _, _, err := k.Create(context.Background(), "ops", "aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa", in); !errors.Is(err, ErrInvalidRequest) {
+			t.Fatalf("%+v: expected ErrInvalidRequest, got %v", in, err)
+		}
+	}
+}
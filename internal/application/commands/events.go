@@ -0,0 +1,86 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"inbox-service/internal/application/ports"
+
+	"github.com/google/uuid"
+)
+
+// writeItemEvent writes a per-item outbox event with the common envelope fields
+// plus the given extras.
+func writeItemEvent(ctx context.Context, outbox ports.OutboxWriter, tx ports.Tx, eventType string, it ports.InboxItemState, extra map[string]any) error {
+	id := uuid.NewString()
+	body := map[string]any{
+		"event_id":       id,
+		"occurred_at":    it.UpdatedAt.Format(time.RFC3339Nano),
+		"tenant_id":      it.TenantID,
+		"user_id":        it.UserID,
+		"inbox_item_id":  it.ID,
+		"type":           it.Type,
+		"status":         it.Status,
+		"version":        it.Version,
+		"schema_version": 1,
+	}
+	for k, v := range extra {
+		body[k] = v
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("marshal outbox payload: %w", err)
+	}
+	return outbox.InsertOutbox(ctx, tx, ports.OutboxEvent{
+		ID:           id,
+		TenantID:     it.TenantID,
+		EventType:    eventType,
+		PartitionKey: ports.UserPartitionKey(it.TenantID, it.UserID),
+		Headers:      ports.MessageMetaFrom(ctx).Headers(),
+		Payload:      payload,
+	})
+}
+
+// appendItemStream records the item's new state for live clients.
+func appendItemStream(ctx context.Context, stream ports.StreamWriter, tx ports.Tx, kind string, it ports.InboxItemState, extra map[string]any) error {
+	body := map[string]any{
+		"inbox_item_id": it.ID,
+		"type":          it.Type,
+		"status":        it.Status,
+		"version":       it.Version,
+		"updated_at":    it.UpdatedAt.Format(time.RFC3339Nano),
+	}
+	if it.SnoozeUntil != nil {
+		body["snooze_until"] = it.SnoozeUntil.Format(time.RFC3339Nano)
+	}
+	for k, v := range extra {
+		body[k] = v
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("marshal stream event: %w", err)
+	}
+	return stream.Append(ctx, tx, ports.StreamEvent{TenantID: it.TenantID, UserID: it.UserID, Kind: kind, Data: data})
+}
+
+// countsAsUnread mirrors the unread badge definition: UNREAD and not snoozed.
+func countsAsUnread(it ports.InboxItemState) bool {
+	return it.Status == StatusUnread && it.SnoozeUntil == nil
+}
+
+// adjustUnread applies the badge change caused by an item going from before to after.
+func adjustUnread(ctx context.Context, counter ports.UnreadCounter, tx ports.Tx, before, after ports.InboxItemState) error {
+	delta := 0
+	if countsAsUnread(before) {
+		delta--
+	}
+	if countsAsUnread(after) {
+		delta++
+	}
+	if delta == 0 {
+		return nil
+	}
+	return counter.Adjust(ctx, tx, after.TenantID, after.UserID, after.Type, delta)
+}
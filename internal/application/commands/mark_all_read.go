@@ -0,0 +1,147 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"inbox-service/internal/application/ports"
+
+	"github.com/google/uuid"
+)
+
+// maxItemID sorts after every UUID, so a cursor built from a bare timestamp
+// includes all items created at that instant.
+const maxItemID = "ffffffff-ffff-ffff-ffff-ffffffffffff"
+
+type MarkAllReadCommand struct {
+	TenantID string
+	UserID   string
+	Status   string // optional; only "UNREAD" makes sense here
+	Type     string // optional
+	// Before pins the affected set (inclusive). Defaults to now.
+	Before *ports.FeedCursor
+}
+
+type MarkAllReadResult struct {
+	Updated int
+	Before  ports.FeedCursor
+}
+
+// MarkAllReadHandler marks a user's UNREAD items READ in bounded chunks, each in
+// its own transaction, and emits a single summarising outbox event.
+type MarkAllReadHandler struct {
+	Tx        ports.TxManager
+	Items     ports.InboxItemBulkStore
+	Outbox    ports.OutboxWriter
+	Counter   ports.UnreadCounter
+	Stream    ports.StreamWriter
+	ChunkSize int
+}
+
+func NewMarkAllReadHandler(tx ports.TxManager, items ports.InboxItemBulkStore, outbox ports.OutboxWriter, counter ports.UnreadCounter, stream ports.StreamWriter) *MarkAllReadHandler {
+	return &MarkAllReadHandler{Tx: tx, Items: items, Outbox: outbox, Counter: counter, Stream: stream, ChunkSize: 500}
+}
+
+func (h *MarkAllReadHandler) Handle(ctx context.Context, cmd MarkAllReadCommand) (MarkAllReadResult, error) {
+	if cmd.TenantID == "" || cmd.UserID == "" {
+		return MarkAllReadResult{}, fmt.Errorf("%w: tenant_id and user_id are required", ErrInvalidCommand)
+	}
+	if cmd.Status != "" && cmd.Status != StatusUnread {
+		return MarkAllReadResult{}, fmt.Errorf("%w: mark all read only applies to %s items", ErrInvalidCommand, StatusUnread)
+	}
+
+	before := ports.FeedCursor{SortAt: time.Now().UTC(), ID: maxItemID}
+	if cmd.Before != nil {
+		before = *cmd.Before
+		if before.ID == "" {
+			before.ID = maxItemID
+		} else if _, err := uuid.Parse(before.ID); err != nil {
+			return MarkAllReadResult{}, fmt.Errorf("%w: invalid created_before_id %q", ErrInvalidCommand, before.ID)
+		}
+	}
+	filter := ports.BulkReadFilter{Type: cmd.Type, Before: before}
+
+	chunk := h.ChunkSize
+	if chunk <= 0 {
+		chunk = 500
+	}
+
+	total := 0
+	for {
+		n := 0
+		err := h.Tx.WithTx(ctx, func(ctx context.Context, tx ports.Tx) error {
+			byType, err := h.Items.MarkReadBatch(ctx, tx, cmd.TenantID, cmd.UserID, filter, chunk)
+			if err != nil {
+				return err
+			}
+			n = 0
+			for typ, cnt := range byType {
+				if err := h.Counter.Adjust(ctx, tx, cmd.TenantID, cmd.UserID, typ, -cnt); err != nil {
+					return err
+				}
+				n += cnt
+			}
+			// The summary rides with the last chunk so it is only emitted
+			// once the whole set has been marked.
+			if n < chunk && total+n > 0 {
+				return h.writeSummary(ctx, tx, cmd, filter, total+n)
+			}
+			return nil
+		})
+		if err != nil {
+			return MarkAllReadResult{Updated: total}, err
+		}
+		total += n
+		if n < chunk {
+			break
+		}
+	}
+
+	return MarkAllReadResult{Updated: total, Before: before}, nil
+}
+
+func (h *MarkAllReadHandler) writeSummary(ctx context.Context, tx ports.Tx, cmd MarkAllReadCommand, f ports.BulkReadFilter, updated int) error {
+	id := uuid.NewString()
+	payload, err := json.Marshal(map[string]any{
+		"event_id":       id,
+		"occurred_at":    time.Now().UTC().Format(time.RFC3339Nano),
+		"tenant_id":      cmd.TenantID,
+		"user_id":        cmd.UserID,
+		"type":           f.Type,
+		"created_before": f.Before.SortAt.Format(time.RFC3339Nano),
+		"updated_count":  updated,
+		"to_status":      StatusRead,
+		"schema_version": 1,
+	})
+	if err != nil {
+		return fmt.Errorf("marshal outbox payload: %w", err)
+	}
+	if err := h.Outbox.InsertOutbox(ctx, tx, ports.OutboxEvent{
+		ID:           id,
+		TenantID:     cmd.TenantID,
+		EventType:    "InboxItemsMarkedRead",
+		PartitionKey: ports.UserPartitionKey(cmd.TenantID, cmd.UserID),
+		Headers:      ports.MessageMetaFrom(ctx).Headers(),
+		Payload:      payload,
+	}); err != nil {
+		return err
+	}
+
+	// Clients refetch the feed on this one rather than patching items one by one.
+	data, err := json.Marshal(map[string]any{
+		"type":           f.Type,
+		"created_before": f.Before.SortAt.Format(time.RFC3339Nano),
+		"updated_count":  updated,
+	})
+	if err != nil {
+		return fmt.Errorf("marshal stream event: %w", err)
+	}
+	return h.Stream.Append(ctx, tx, ports.StreamEvent{
+		TenantID: cmd.TenantID,
+		UserID:   cmd.UserID,
+		Kind:     ports.StreamItemsMarkedRead,
+		Data:     data,
+	})
+}
@@ -0,0 +1,88 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"inbox-service/internal/application/ports"
+)
+
+// fakeBulk marks `remaining` items in chunks of at most limit.
+type fakeBulk struct {
+	remaining int
+	calls     int
+	filter    ports.BulkReadFilter
+}
+
+func (f *fakeBulk) MarkReadBatch(ctx context.Context, tx ports.Tx, tenantID, userID string, flt ports.BulkReadFilter, limit int) (map[string]int, error) {
+	f.calls++
+	f.filter = flt
+	n := min(f.remaining, limit)
+	f.remaining -= n
+	if n == 0 {
+		return map[string]int{}, nil
+	}
+	return map[string]int{"TASK_ASSIGNED": n}, nil
+}
+
+func TestMarkAllRead_ChunksAndEmitsOneSummary(t *testing.T) {
+	bulk := &fakeBulk{remaining: 25}
+	out := &fakeOutbox{}
+	counter := newFakeCounter()
+	h := NewMarkAllReadHandler(fakeTxMgr{}, bulk, out, counter, &fakeStream{})
+	h.ChunkSize = 10
+
+	res, err := h.Handle(context.Background(), MarkAllReadCommand{TenantID: "t", UserID: "u", Type: "TASK_ASSIGNED"})
+	if err != nil {
+		t.Fatalf("Handle: %v", err)
+	}
+	if res.Updated != 25 {
+		t.Fatalf("expected 25 updated, got %d", res.Updated)
+	}
+	if bulk.calls != 3 {
+		t.Fatalf("expected 3 chunks, got %d", bulk.calls)
+	}
+	if bulk.filter.Before.ID != maxItemID || bulk.filter.Before.SortAt.IsZero() {
+		t.Fatalf("expected a pinned created_before cursor, got %+v", bulk.filter.Before)
+	}
+	if len(out.events) != 1 || out.events[0].EventType != "InboxItemsMarkedRead" {
+		t.Fatalf("expected exactly one summary event, got %+v", out.events)
+	}
+	if got := counter.deltas["TASK_ASSIGNED"]; got != -25 {
+		t.Fatalf("expected unread counter -25, got %d", got)
+	}
+}
+
+func TestMarkAllRead_NothingToDo(t *testing.T) {
+	out := &fakeOutbox{}
+	h := NewMarkAllReadHandler(fakeTxMgr{}, &fakeBulk{}, out, newFakeCounter(), &fakeStream{})
+
+	res, err := h.Handle(context.Background(), MarkAllReadCommand{TenantID: "t", UserID: "u"})
+	if err != nil {
+		t.Fatalf("Handle: %v", err)
+	}
+	if res.Updated != 0 || len(out.events) != 0 {
+		t.Fatalf("expected no updates and no event, got %d / %d", res.Updated, len(out.events))
+	}
+}
+
+func TestMarkAllRead_RejectsOtherStatuses(t *testing.T) {
+	h := NewMarkAllReadHandler(fakeTxMgr{}, &fakeBulk{}, &fakeOutbox{}, newFakeCounter(), &fakeStream{})
+
+	_, err := h.Handle(context.Background(), MarkAllReadCommand{TenantID: "t", UserID: "u", Status: StatusArchived})
+	if !errors.Is(err, ErrInvalidCommand) {
+		t.Fatalf("expected ErrInvalidCommand, got %v", err)
+	}
+}
+
+func TestMarkAllRead_RejectsNonUUIDBeforeID(t *testing.T) {
+	h := NewMarkAllReadHandler(fakeTxMgr{}, &fakeBulk{}, &fakeOutbox{}, newFakeCounter(), &fakeStream{})
+
+	before := &ports.FeedCursor{SortAt: time.Now().UTC(), ID: "not-a-uuid"}
+	_, err := h.Handle(context.Background(), MarkAllReadCommand{TenantID: "t", UserID: "u", Before: before})
+	if !errors.Is(err, ErrInvalidCommand) {
+		t.Fatalf("expected ErrInvalidCommand, got %v", err)
+	}
+}
@@ -0,0 +1,246 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"inbox-service/internal/application/ports"
+)
+
+// Snooze presets, resolved in the user's time zone.
+const (
+	PresetLaterToday  = "later_today"  // now + 3h
+	PresetTomorrow    = "tomorrow"     // tomorrow 09:00
+	PresetThisWeekend = "this_weekend" // next Saturday 09:00
+	PresetNextWeek    = "next_week"    // next Monday 09:00
+)
+
+const presetHour = 9
+
+// ResolvePreset turns a preset into an absolute time, computed in loc.
+func ResolvePreset(preset string, now time.Time, loc *time.Location) (time.Time, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	local := now.In(loc)
+	at9 := func(daysAhead int) time.Time {
+		d := local.AddDate(0, 0, daysAhead)
+		return time.Date(d.Year(), d.Month(), d.Day(), presetHour, 0, 0, 0, loc)
+	}
+	daysUntil := func(wd time.Weekday) int {
+		n := (int(wd) - int(local.Weekday()) + 7) % 7
+		if n == 0 {
+			n = 7
+		}
+		return n
+	}
+
+	switch preset {
+	case PresetLaterToday:
+		return now.Add(3 * time.Hour), nil
+	case PresetTomorrow:
+		return at9(1), nil
+	case PresetThisWeekend:
+		return at9(daysUntil(time.Saturday)), nil
+	case PresetNextWeek:
+		return at9(daysUntil(time.Monday)), nil
+	default:
+		return time.Time{}, fmt.Errorf("%w: unknown snooze preset %q", ErrInvalidCommand, preset)
+	}
+}
+
+type SnoozeCommand struct {
+	TenantID string
+	UserID   string
+	ItemID   string
+	// Either Until or Preset must be set. TimeZone (IANA name) is used for presets.
+	Until     *time.Time
+	Preset    string
+	TimeZone  string
+	IfVersion *int
+}
+
+type SnoozeHandler struct {
+	Tx      ports.TxManager
+	Items   ports.InboxItemStore
+	Snoozes ports.InboxItemSnoozeStore
+	Outbox  ports.OutboxWriter
+	Counter ports.UnreadCounter
+	Stream  ports.StreamWriter
+
+	now func() time.Time
+}
+
+func NewSnoozeHandler(tx ports.TxManager, items ports.InboxItemStore, snoozes ports.InboxItemSnoozeStore, outbox ports.OutboxWriter, counter ports.UnreadCounter, stream ports.StreamWriter) *SnoozeHandler {
+	return &SnoozeHandler{
+		Tx:      tx,
+		Items:   items,
+		Snoozes: snoozes,
+		Outbox:  outbox,
+		Counter: counter,
+		Stream:  stream,
+		now:     func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// Snooze hides an item from the feed until the given time. Re-snoozing moves
+// the wake-up time.
+func (h *SnoozeHandler) Snooze(ctx context.Context, cmd SnoozeCommand) (ports.InboxItemState, error) {
+	if cmd.TenantID == "" || cmd.UserID == "" || cmd.ItemID == "" {
+		return ports.InboxItemState{}, fmt.Errorf("%w: tenant_id, user_id and item id are required", ErrInvalidCommand)
+	}
+
+	now := h.now()
+	var until time.Time
+	switch {
+	case cmd.Until != nil && cmd.Preset != "":
+		return ports.InboxItemState{}, fmt.Errorf("%w: set either until or preset, not both", ErrInvalidCommand)
+	case cmd.Until != nil:
+		until = cmd.Until.UTC()
+	case cmd.Preset != "":
+		loc := time.UTC
+		if cmd.TimeZone != "" {
+			l, err := time.LoadLocation(cmd.TimeZone)
+			if err != nil {
+				return ports.InboxItemState{}, fmt.Errorf("%w: unknown time zone %q", ErrInvalidCommand, cmd.TimeZone)
+			}
+			loc = l
+		}
+		t, err := ResolvePreset(cmd.Preset, now, loc)
+		if err != nil {
+			return ports.InboxItemState{}, err
+		}
+		until = t.UTC()
+	default:
+		return ports.InboxItemState{}, fmt.Errorf("%w: until or preset is required", ErrInvalidCommand)
+	}
+	if !until.After(now) {
+		return ports.InboxItemState{}, fmt.Errorf("%w: snooze time must be in the future", ErrInvalidCommand)
+	}
+
+	return h.setSnooze(ctx, cmd.TenantID, cmd.UserID, cmd.ItemID, &until, cmd.IfVersion)
+}
+
+// Unsnooze brings a snoozed item back immediately (at its original position).
+func (h *SnoozeHandler) Unsnooze(ctx context.Context, tenantID, userID, itemID string, ifVersion *int) (ports.InboxItemState, error) {
+	if tenantID == "" || userID == "" || itemID == "" {
+		return ports.InboxItemState{}, fmt.Errorf("%w: tenant_id, user_id and item id are required", ErrInvalidCommand)
+	}
+	return h.setSnooze(ctx, tenantID, userID, itemID, nil, ifVersion)
+}
+
+func (h *SnoozeHandler) setSnooze(ctx context.Context, tenantID, userID, itemID string, until *time.Time, ifVersion *int) (ports.InboxItemState, error) {
+	var out ports.InboxItemState
+	err := h.Tx.WithTx(ctx, func(ctx context.Context, tx ports.Tx) error {
+		cur, err := h.Items.GetItem(ctx, tx, tenantID, userID, itemID)
+		if err != nil {
+			return err
+		}
+		if ifVersion != nil && *ifVersion != cur.Version {
+			return fmt.Errorf("%w: item is at version %d", ErrPreconditionFailed, cur.Version)
+		}
+		if until == nil && cur.SnoozeUntil == nil {
+			out = cur
+			return nil
+		}
+
+		next, err := h.Snoozes.SetSnooze(ctx, tx, tenantID, itemID, until, cur.Version)
+		if err != nil {
+			return err
+		}
+		if err := adjustUnread(ctx, h.Counter, tx, cur, next); err != nil {
+			return err
+		}
+
+		eventType, kind := "InboxItemSnoozed", ports.StreamItemSnoozed
+		fields := map[string]any{}
+		if until != nil {
+			fields["snooze_until"] = until.Format(time.RFC3339Nano)
+		} else {
+			eventType, kind = "InboxItemUnsnoozed", ports.StreamItemUnsnoozed
+			fields["reason"] = "cancelled"
+		}
+		if err := writeItemEvent(ctx, h.Outbox, tx, eventType, next, fields); err != nil {
+			return err
+		}
+		if err := appendItemStream(ctx, h.Stream, tx, kind, next, map[string]any{"reason": fields["reason"]}); err != nil {
+			return err
+		}
+
+		out = next
+		return nil
+	})
+	if err != nil {
+		return ports.InboxItemState{}, err
+	}
+	return out, nil
+}
+
+// SnoozeWaker resurfaces snoozed items once they are due.
+type SnoozeWaker struct {
+	Tx        ports.TxManager
+	Snoozes   ports.InboxItemSnoozeStore
+	Outbox    ports.OutboxWriter
+	Counter   ports.UnreadCounter
+	Stream    ports.StreamWriter
+	BatchSize int
+
+	now func() time.Time
+}
+
+func NewSnoozeWaker(tx ports.TxManager, snoozes ports.InboxItemSnoozeStore, outbox ports.OutboxWriter, counter ports.UnreadCounter, stream ports.StreamWriter) *SnoozeWaker {
+	return &SnoozeWaker{
+		Tx:        tx,
+		Snoozes:   snoozes,
+		Outbox:    outbox,
+		Counter:   counter,
+		Stream:    stream,
+		BatchSize: 200,
+		now:       func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// WakeDue wakes all due items in batches and returns how many it woke.
+func (w *SnoozeWaker) WakeDue(ctx context.Context) (int, error) {
+	batch := w.BatchSize
+	if batch <= 0 {
+		batch = 200
+	}
+
+	total := 0
+	for {
+		n := 0
+		err := w.Tx.WithTx(ctx, func(ctx context.Context, tx ports.Tx) error {
+			woken, err := w.Snoozes.WakeDue(ctx, tx, w.now(), batch)
+			if err != nil {
+				return err
+			}
+			for _, it := range woken {
+				before := it.Item
+				before.SnoozeUntil = &it.SnoozedUntil
+				if err := adjustUnread(ctx, w.Counter, tx, before, it.Item); err != nil {
+					return err
+				}
+				if err := writeItemEvent(ctx, w.Outbox, tx, "InboxItemUnsnoozed", it.Item, map[string]any{
+					"reason":        "due",
+					"snoozed_until": it.SnoozedUntil.Format(time.RFC3339Nano),
+				}); err != nil {
+					return err
+				}
+				if err := appendItemStream(ctx, w.Stream, tx, ports.StreamItemUnsnoozed, it.Item, map[string]any{"reason": "due"}); err != nil {
+					return err
+				}
+			}
+			n = len(woken)
+			return nil
+		})
+		if err != nil {
+			return total, err
+		}
+		total += n
+		if n < batch {
+			return total, nil
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"inbox-service/internal/application/ports"
+)
+
+type fakeSnoozes struct {
+	items *fakeItems
+	due   []ports.WokenItem
+}
+
+func (f *fakeSnoozes) SetSnooze(ctx context.Context, tx ports.Tx, tenantID, itemID string, until *time.Time, fromVersion int) (ports.InboxItemState, error) {
+	it := f.items.items[itemID]
+	if it.Version != fromVersion {
+		return ports.InboxItemState{}, ports.ErrVersionConflict
+	}
+	it.SnoozeUntil = until
+	it.Version++
+	f.items.items[itemID] = it
+	return it, nil
+}
+
+func (f *fakeSnoozes) WakeDue(ctx context.Context, tx ports.Tx, now time.Time, limit int) ([]ports.WokenItem, error) {
+	n := min(len(f.due), limit)
+	out := f.due[:n]
+	f.due = f.due[n:]
+	return out, nil
+}
+
+func TestResolvePreset(t *testing.T) {
+	ljubljana, err := time.LoadLocation("Europe/Ljubljana")
+	if err != nil {
+		t.Skipf("tzdata not available: %v", err)
+	}
+	// Wednesday 23:30 UTC is already Thursday 00:30 in Ljubljana.
+	now := time.Date(2026, 1, 21, 23, 30, 0, 0, time.UTC)
+
+	cases := []struct {
+		preset string
+		want   time.Time
+	}{
+		{PresetLaterToday, now.Add(3 * time.Hour)},
+		{PresetTomorrow, time.Date(2026, 1, 23, 9, 0, 0, 0, ljubljana)},
+		{PresetThisWeekend, time.Date(2026, 1, 24, 9, 0, 0, 0, ljubljana)},
+		{PresetNextWeek, time.Date(2026, 1, 26, 9, 0, 0, 0, ljubljana)},
+	}
+	for _, c := range cases {
+		got, err := ResolvePreset(c.preset, now, ljubljana)
+		if err != nil {
+			t.Fatalf("%s: %v", c.preset, err)
+		}
+		if !got.Equal(c.want) {
+			t.Fatalf("%s: expected %v, got %v", c.preset, c.want, got)
+		}
+	}
+
+	if _, err := ResolvePreset("someday", now, ljubljana); !errors.Is(err, ErrInvalidCommand) {
+		t.Fatalf("expected ErrInvalidCommand for unknown preset, got %v", err)
+	}
+}
+
+func TestSnooze_SetsUntilAndWritesEvent(t *testing.T) {
+	items := newFakeItems(unreadItem())
+	out := &fakeOutbox{}
+	counter := newFakeCounter()
+	h := NewSnoozeHandler(fakeTxMgr{}, items, &fakeSnoozes{items: items}, out, counter, &fakeStream{})
+
+	until := time.Now().UTC().Add(time.Hour)
+	it, err := h.Snooze(context.Background(), SnoozeCommand{TenantID: "t", UserID: "u", ItemID: "i", Until: &until})
+	if err != nil {
+		t.Fatalf("Snooze: %v", err)
+	}
+	if it.SnoozeUntil == nil || !it.SnoozeUntil.Equal(until) {
+		t.Fatalf("expected snooze_until %v, got %v", until, it.SnoozeUntil)
+	}
+	if len(out.events) != 1 || out.events[0].EventType != "InboxItemSnoozed" {
+		t.Fatalf("expected one InboxItemSnoozed event, got %+v", out.events)
+	}
+	if got := counter.deltas["TASK_ASSIGNED"]; got != -1 {
+		t.Fatalf("expected snoozing an unread item to drop the badge, got %d", got)
+	}
+
+	past := time.Now().UTC().Add(-time.Minute)
+	_, err = h.Snooze(context.Background(), SnoozeCommand{TenantID: "t", UserID: "u", ItemID: "i", Until: &past})
+	if !errors.Is(err, ErrInvalidCommand) {
+		t.Fatalf("expected ErrInvalidCommand for a past time, got %v", err)
+	}
+}
+
+func TestSnoozeWaker_EmitsUnsnoozedPerItem(t *testing.T) {
+	out := &fakeOutbox{}
+	snoozes := &fakeSnoozes{due: []ports.WokenItem{
+		{Item: ports.InboxItemState{ID: "a", TenantID: "t", UserID: "u", Type: "X", Status: StatusUnread}},
+		{Item: ports.InboxItemState{ID: "b", TenantID: "t", UserID: "u", Type: "X", Status: StatusUnread}},
+		{Item: ports.InboxItemState{ID: "c", TenantID: "t", UserID: "u", Type: "X", Status: StatusRead}},
+	}}
+	counter := newFakeCounter()
+	w := NewSnoozeWaker(fakeTxMgr{}, snoozes, out, counter, &fakeStream{})
+	w.BatchSize = 2
+
+	n, err := w.WakeDue(context.Background())
+	if err != nil {
+		t.Fatalf("WakeDue: %v", err)
+	}
+	if n != 3 {
+		t.Fatalf("expected 3 woken, got %d", n)
+	}
+	for _, e := range out.events {
+		if e.EventType != "InboxItemUnsnoozed" {
+			t.Fatalf("unexpected event type %q", e.EventType)
+		}
+	}
+	if len(out.events) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(out.events))
+	}
+	if got := counter.deltas["X"]; got != 2 {
+		t.Fatalf("expected only woken unread items to count, got %d", got)
+	}
+}
@@ -0,0 +1,109 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"inbox-service/internal/application/ports"
+)
+
+const (
+	StatusUnread   = "UNREAD"
+	StatusRead     = "READ"
+	StatusArchived = "ARCHIVED"
+)
+
+var (
+	ErrInvalidCommand = errors.New("invalid command")
+	// ErrPreconditionFailed means the caller's expected version (If-Match) is stale.
+	ErrPreconditionFailed = errors.New("precondition failed")
+)
+
+type ChangeStatusCommand struct {
+	TenantID string
+	UserID   string
+	ItemID   string
+	Status   string
+	// IfVersion, when set, must match the item's current version.
+	IfVersion *int
+}
+
+type StatusHandler struct {
+	Tx      ports.TxManager
+	Items   ports.InboxItemStore
+	Outbox  ports.OutboxWriter
+	Counter ports.UnreadCounter
+	Stream  ports.StreamWriter
+}
+
+func NewStatusHandler(tx ports.TxManager, items ports.InboxItemStore, outbox ports.OutboxWriter, counter ports.UnreadCounter, stream ports.StreamWriter) *StatusHandler {
+	return &StatusHandler{Tx: tx, Items: items, Outbox: outbox, Counter: counter, Stream: stream}
+}
+
+func (h *StatusHandler) MarkRead(ctx context.Context, tenantID, userID, itemID string, ifVersion *int) (ports.InboxItemState, error) {
+	return h.ChangeStatus(ctx, ChangeStatusCommand{TenantID: tenantID, UserID: userID, ItemID: itemID, Status: StatusRead, IfVersion: ifVersion})
+}
+
+func (h *StatusHandler) MarkUnread(ctx context.Context, tenantID, userID, itemID string, ifVersion *int) (ports.InboxItemState, error) {
+	return h.ChangeStatus(ctx, ChangeStatusCommand{TenantID: tenantID, UserID: userID, ItemID: itemID, Status: StatusUnread, IfVersion: ifVersion})
+}
+
+func (h *StatusHandler) Archive(ctx context.Context, tenantID, userID, itemID string, ifVersion *int) (ports.InboxItemState, error) {
+	return h.ChangeStatus(ctx, ChangeStatusCommand{TenantID: tenantID, UserID: userID, ItemID: itemID, Status: StatusArchived, IfVersion: ifVersion})
+}
+
+// ChangeStatus moves an item to cmd.Status. Setting the status an item already
+// has is a no-op (no version bump, no event).
+func (h *StatusHandler) ChangeStatus(ctx context.Context, cmd ChangeStatusCommand) (ports.InboxItemState, error) {
+	if cmd.TenantID == "" || cmd.UserID == "" || cmd.ItemID == "" {
+		return ports.InboxItemState{}, fmt.Errorf("%w: tenant_id, user_id and item id are required", ErrInvalidCommand)
+	}
+	switch cmd.Status {
+	case StatusUnread, StatusRead, StatusArchived:
+	default:
+		return ports.InboxItemState{}, fmt.Errorf("%w: unknown status %q", ErrInvalidCommand, cmd.Status)
+	}
+
+	var out ports.InboxItemState
+	err := h.Tx.WithTx(ctx, func(ctx context.Context, tx ports.Tx) error {
+		cur, err := h.Items.GetItem(ctx, tx, cmd.TenantID, cmd.UserID, cmd.ItemID)
+		if err != nil {
+			return err
+		}
+		if cmd.IfVersion != nil && *cmd.IfVersion != cur.Version {
+			return fmt.Errorf("%w: item is at version %d", ErrPreconditionFailed, cur.Version)
+		}
+		if cur.Status == cmd.Status {
+			out = cur
+			return nil
+		}
+
+		next, err := h.Items.UpdateStatus(ctx, tx, cmd.TenantID, cmd.ItemID, cmd.Status, cur.Version)
+		if err != nil {
+			return err
+		}
+		if err := adjustUnread(ctx, h.Counter, tx, cur, next); err != nil {
+			return err
+		}
+
+		if err := writeItemEvent(ctx, h.Outbox, tx, "InboxItemStatusChanged", next, map[string]any{
+			"from_status": cur.Status,
+			"to_status":   next.Status,
+		}); err != nil {
+			return err
+		}
+		if err := appendItemStream(ctx, h.Stream, tx, ports.StreamItemStatusChanged, next, map[string]any{
+			"from_status": cur.Status,
+		}); err != nil {
+			return err
+		}
+
+		out = next
+		return nil
+	})
+	if err != nil {
+		return ports.InboxItemState{}, err
+	}
+	return out, nil
+}
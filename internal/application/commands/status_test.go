@@ -0,0 +1,152 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"inbox-service/internal/application/ports"
+)
+
+// --- fakes ---
+
+type fakeTxMgr struct{}
+
+func (fakeTxMgr) WithTx(ctx context.Context, fn func(ctx context.Context, tx ports.Tx) error) error {
+	return fn(ctx, nil)
+}
+
+type fakeItems struct {
+	items map[string]ports.InboxItemState
+}
+
+func newFakeItems(items ...ports.InboxItemState) *fakeItems {
+	f := &fakeItems{items: map[string]ports.InboxItemState{}}
+	for _, it := range items {
+		f.items[it.ID] = it
+	}
+	return f
+}
+
+func (f *fakeItems) GetItem(ctx context.Context, tx ports.Tx, tenantID, userID, itemID string) (ports.InboxItemState, error) {
+	it, ok := f.items[itemID]
+	if !ok || it.TenantID != tenantID || it.UserID != userID {
+		return ports.InboxItemState{}, ports.ErrNotFound
+	}
+	return it, nil
+}
+
+func (f *fakeItems) UpdateStatus(ctx context.Context, tx ports.Tx, tenantID, itemID, status string, fromVersion int) (ports.InboxItemState, error) {
+	it := f.items[itemID]
+	if it.Version != fromVersion {
+		return ports.InboxItemState{}, ports.ErrVersionConflict
+	}
+	it.Status = status
+	it.Version++
+	it.UpdatedAt = time.Now().UTC()
+	f.items[itemID] = it
+	return it, nil
+}
+
+type fakeOutbox struct {
+	events []ports.OutboxEvent
+}
+
+func (o *fakeOutbox) InsertOutbox(ctx context.Context, tx ports.Tx, e ports.OutboxEvent) error {
+	o.events = append(o.events, e)
+	return nil
+}
+
+type fakeCounter struct {
+	deltas map[string]int // by item type
+}
+
+func newFakeCounter() *fakeCounter { return &fakeCounter{deltas: map[string]int{}} }
+
+func (c *fakeCounter) Adjust(ctx context.Context, tx ports.Tx, tenantID, userID, itemType string, delta int) error {
+	c.deltas[itemType] += delta
+	return nil
+}
+
+type fakeStream struct {
+	events []ports.StreamEvent
+}
+
+func (s *fakeStream) Append(ctx context.Context, tx ports.Tx, e ports.StreamEvent) error {
+	s.events = append(s.events, e)
+	return nil
+}
+
+func unreadItem() ports.InboxItemState {
+	return ports.InboxItemState{ID: "i", TenantID: "t", UserID: "u", Type: "TASK_ASSIGNED", Status: StatusUnread, Version: 1}
+}
+
+// --- tests ---
+
+func TestChangeStatus_BumpsVersionAndWritesOutbox(t *testing.T) {
+	items := newFakeItems(unreadItem())
+	out := &fakeOutbox{}
+	counter := newFakeCounter()
+	live := &fakeStream{}
+	h := NewStatusHandler(fakeTxMgr{}, items, out, counter, live)
+
+	v := 1
+	it, err := h.MarkRead(context.Background(), "t", "u", "i", &v)
+	if err != nil {
+		t.Fatalf("MarkRead: %v", err)
+	}
+	if it.Status != StatusRead || it.Version != 2 {
+		t.Fatalf("expected READ at version 2, got %s at %d", it.Status, it.Version)
+	}
+	if len(out.events) != 1 || out.events[0].EventType != "InboxItemStatusChanged" {
+		t.Fatalf("expected one InboxItemStatusChanged event, got %+v", out.events)
+	}
+	if got := out.events[0].PartitionKey; got != "t/u" {
+		t.Fatalf("expected the event keyed by user t/u, got %q", got)
+	}
+	if got := counter.deltas["TASK_ASSIGNED"]; got != -1 {
+		t.Fatalf("expected unread counter -1, got %d", got)
+	}
+	if len(live.events) != 1 || live.events[0].Kind != ports.StreamItemStatusChanged || live.events[0].UserID != "u" {
+		t.Fatalf("expected one item.status_changed stream event, got %+v", live.events)
+	}
+}
+
+func TestChangeStatus_SameStatusIsNoop(t *testing.T) {
+	items := newFakeItems(unreadItem())
+	out := &fakeOutbox{}
+	h := NewStatusHandler(fakeTxMgr{}, items, out, newFakeCounter(), &fakeStream{})
+
+	it, err := h.MarkUnread(context.Background(), "t", "u", "i", nil)
+	if err != nil {
+		t.Fatalf("MarkUnread: %v", err)
+	}
+	if it.Version != 1 || len(out.events) != 0 {
+		t.Fatalf("expected no change, got version %d and %d events", it.Version, len(out.events))
+	}
+}
+
+func TestChangeStatus_StaleIfMatch(t *testing.T) {
+	h := NewStatusHandler(fakeTxMgr{}, newFakeItems(unreadItem()), &fakeOutbox{}, newFakeCounter(), &fakeStream{})
+
+	v := 7
+	_, err := h.Archive(context.Background(), "t", "u", "i", &v)
+	if !errors.Is(err, ErrPreconditionFailed) {
+		t.Fatalf("expected ErrPreconditionFailed, got %v", err)
+	}
+}
+
+func TestChangeStatus_Validation(t *testing.T) {
+	h := NewStatusHandler(fakeTxMgr{}, newFakeItems(unreadItem()), &fakeOutbox{}, newFakeCounter(), &fakeStream{})
+
+	_, err := h.ChangeStatus(context.Background(), ChangeStatusCommand{TenantID: "t", UserID: "u", ItemID: "i", Status: "DELETED"})
+	if !errors.Is(err, ErrInvalidCommand) {
+		t.Fatalf("expected ErrInvalidCommand for unknown status, got %v", err)
+	}
+
+	_, err = h.MarkRead(context.Background(), "t", "someone-else", "i", nil)
+	if !errors.Is(err, ports.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for another user's item, got %v", err)
+	}
+}
@@ -0,0 +1,147 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"inbox-service/internal/application/ports"
+)
+
+// ErrBatchTooLarge is returned for a batch over BatchConfig.MaxEvents.
+var ErrBatchTooLarge = errors.New("too many events in batch")
+
+// Per-event outcomes of IngestBatch.
+const (
+	BatchCreated   = "created"
+	BatchDuplicate = "duplicate"
+	// BatchInvalid events will never succeed (see IsPermanent); BatchFailed
+	// ones hit an infrastructure error and can be sent again.
+	BatchInvalid = "invalid"
+	BatchFailed  = "failed"
+)
+
+// BatchConfig bounds IngestBatch.
+type BatchConfig struct {
+	MaxEvents   int // per call; default 500
+	Concurrency int // transactions in flight; default 8
+}
+
+func (c BatchConfig) withDefaults() BatchConfig {
+	if c.MaxEvents <= 0 {
+		c.MaxEvents = 500
+	}
+	if c.Concurrency <= 0 {
+		c.Concurrency = 8
+	}
+	return c
+}
+
+// BatchEvent is one event of a batch with the tracing context it carried, if
+// any, layered over the request's.
+type BatchEvent struct {
+	Envelope Envelope
+	Meta     ports.MessageMeta
+}
+
+// BatchResult is the outcome for the event at the same index.
+type BatchResult struct {
+	ID      string
+	Status  string
+	ItemIDs []string
+	Err     error // set for BatchInvalid and BatchFailed
+}
+
+// IngestBatch ingests events with up to Batch.Concurrency transactions at a
+// time, each event in its own, so one bad event doesn't hold back the rest.
+// Events sharing a tenant and id run one after the other, so repeats within
+// the batch come back as duplicates just like redeliveries do.
+func (h *Handler) IngestBatch(ctx context.Context, events []BatchEvent) ([]BatchResult, error) {
+	if err := h.CheckBatchSize(len(events)); err != nil {
+		return nil, err
+	}
+	cfg := h.Batch.withDefaults()
+
+	results := make([]BatchResult, len(events))
+	ready := make([]prepared, len(events))
+	var groups [][]int
+	byKey := map[string]int{}
+	for i, e := range events {
+		results[i].ID = e.Envelope.ID
+		p, err := h.prepare(ctx, e.Envelope)
+		if err != nil {
+			results[i].Status, results[i].Err = BatchInvalid, err
+			continue
+		}
+		ready[i] = p
+		results[i].ID = p.env.ID
+		key := p.env.TenantID + "/" + p.env.ID
+		g, ok := byKey[key]
+		if !ok {
+			g = len(groups)
+			byKey[key] = g
+			groups = append(groups, nil)
+		}
+		groups[g] = append(groups[g], i)
+	}
+
+	work := make(chan []int)
+	var wg sync.WaitGroup
+	for range min(cfg.Concurrency, len(groups)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for group := range work {
+				for _, i := range group {
+					results[i] = h.applyBatchEvent(ctx, events[i].Meta, ready[i])
+				}
+			}
+		}()
+	}
+	for _, g := range groups {
+		work <- g
+	}
+	close(work)
+	wg.Wait()
+	return results, nil
+}
+
+// CheckBatchSize returns ErrBatchTooLarge if n events are more than one batch
+// may hold.
+func (h *Handler) CheckBatchSize(n int) error {
+	if limit := h.Batch.withDefaults().MaxEvents; n > limit {
+		return fmt.Errorf("%w: %d events, at most %d", ErrBatchTooLarge, n, limit)
+	}
+	return nil
+}
+
+func (h *Handler) applyBatchEvent(ctx context.Context, meta ports.MessageMeta, p prepared) BatchResult {
+	r := BatchResult{ID: p.env.ID}
+	res, err := h.apply(withEventMeta(ctx, meta), p)
+	switch {
+	case err != nil:
+		r.Status, r.Err = BatchFailed, err
+		if IsPermanent(err) {
+			r.Status = BatchInvalid
+		}
+	case res.Duplicate:
+		r.Status = BatchDuplicate
+	default:
+		r.Status, r.ItemIDs = BatchCreated, res.ItemIDs
+	}
+	return r
+}
+
+// withEventMeta layers the non-empty fields of an event's own tracing context
+// over ctx's.
+func withEventMeta(ctx context.Context, m ports.MessageMeta) context.Context {
+	meta := ports.MessageMetaFrom(ctx)
+	if m.CorrelationID != "" {
+		meta.CorrelationID = m.CorrelationID
+	}
+	if m.Traceparent != "" {
+		meta.Traceparent = m.Traceparent
+	}
+	return ports.WithMessageMeta(ctx, meta)
+}
@@ -0,0 +1,171 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"inbox-service/internal/application/ports"
+)
+
+// serialTx runs one transaction at a time, which also keeps the map-backed
+// fakes safe, and records how many callers were waiting at once.
+type serialTx struct {
+	mu      sync.Mutex
+	gate    sync.Mutex
+	waiting int
+	peak    int
+}
+
+func (s *serialTx) WithTx(ctx context.Context, fn func(ctx context.Context, tx ports.Tx) error) error {
+	s.gate.Lock()
+	s.waiting++
+	s.peak = max(s.peak, s.waiting)
+	s.gate.Unlock()
+	defer func() {
+		s.gate.Lock()
+		s.waiting--
+		s.gate.Unlock()
+	}()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	time.Sleep(time.Millisecond)
+	return fn(ctx, nil)
+}
+
+// failingDeduper fails events with id "boom" like a lost connection would,
+// and those with id "bad-uuid" like Postgres rejecting a malformed UUID.
+type failingDeduper struct{ memDeduper }
+
+func (d *failingDeduper) AlreadyProcessed(ctx context.Context, tx ports.Tx, tenantID, eventID string) (bool, error) {
+	switch eventID {
+	case "boom":
+		return false, errors.New("connection reset")
+	case "bad-uuid":
+		return false, fmt.Errorf("check event: %w", sqlStateError("22P02"))
+	}
+	return d.memDeduper.AlreadyProcessed(ctx, tx, tenantID, eventID)
+}
+
+func newBatchPipeline(t *testing.T, cfg BatchConfig) (*Handler, *memOutbox, *serialTx) {
+	t.Helper()
+	reg, err := NewRegistry(TaskAssigned{}, mentioned{})
+	if err != nil {
+		t.Fatalf("NewRegistry: %v", err)
+	}
+	out, tx := &memOutbox{}, &serialTx{}
+	h := NewHandler(tx, &memInbox{keys: map[string]bool{}}, &failingDeduper{memDeduper{seen: map[string]bool{}}}, out, fakeCounter{}, fakeStream{}, reg)
+	h.Batch = cfg
+	return h, out, tx
+}
+
+func mention(id, comment string) BatchEvent {
+	return BatchEvent{Envelope: Envelope{
+		ID:       id,
+		Type:     "UsersMentioned",
+		TenantID: "t",
+		Payload:  json.RawMessage(`{"comment_id":"` + comment + `","users":["u1"]}`),
+	}}
+}
+
+func TestIngestBatch_PerEventResults(t *testing.T) {
+	h, out, _ := newBatchPipeline(t, BatchConfig{Concurrency: 4})
+
+	events := []BatchEvent{
+		mention("e1", "c1"),
+		mention("e1", "c1"), // repeat of the first within the batch
+		{Envelope: Envelope{ID: "e2", Type: "Nope", TenantID: "t"}},
+		{Envelope: Envelope{ID: "e3", Type: "UsersMentioned", TenantID: "t", Payload: json.RawMessage(`{}`)}},
+		mention("boom", "c2"),
+		mention("e4", "c3"),
+	}
+	results, err := h.IngestBatch(context.Background(), events)
+	if err != nil {
+		t.Fatalf("IngestBatch: %v", err)
+	}
+
+	want := []string{BatchCreated, BatchDuplicate, BatchInvalid, BatchInvalid, BatchFailed, BatchCreated}
+	for i, r := range results {
+		if r.Status != want[i] {
+			t.Errorf("event %d: expected %s, got %s (%v)", i, want[i], r.Status, r.Err)
+		}
+	}
+	if results[0].ID != "e1" || len(results[0].ItemIDs) != 1 || results[4].Err == nil || !errors.Is(results[2].Err, ErrUnknownEventType) {
+		t.Fatalf("unexpected results %+v", results)
+	}
+
+	// The repeat wrote nothing; e1 and e4 each wrote an item and a
+	// MentionsProcessed event.
+	if len(out.events) != 4 {
+		t.Fatalf("expected 4 outbox events, got %d", len(out.events))
+	}
+
+	// A later batch repeating an event sees it as a duplicate.
+	again, _ := h.IngestBatch(context.Background(), []BatchEvent{mention("e1", "c1"), mention("e5", "c2")})
+	if again[0].Status != BatchDuplicate || again[1].Status != BatchCreated {
+		t.Fatalf("unexpected retry results %+v", again)
+	}
+}
+
+func TestIngestBatch_NonUUIDIDsAreInvalid(t *testing.T) {
+	h, out, _ := newBatchPipeline(t, BatchConfig{})
+
+	tenant, user := "aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa", "bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbbbbb"
+	assigned := func(id, tenant, user string) BatchEvent {
+		return BatchEvent{Envelope: Envelope{
+			ID:       id,
+			Type:     "TaskAssignedToUser",
+			TenantID: tenant,
+			Payload:  json.RawMessage(`{"task_id":"42","assignee_user_id":"` + user + `","task_title":"X","task_url":"https://x"}`),
+		}}
+	}
+	results, err := h.IngestBatch(context.Background(), []BatchEvent{
+		assigned("e1", "acme", user),
+		assigned("e2", tenant, "bob"),
+		mention("bad-uuid", "c1"), // rejected by the database instead
+		assigned("e3", tenant, user),
+	})
+	if err != nil {
+		t.Fatalf("IngestBatch: %v", err)
+	}
+
+	want := []string{BatchInvalid, BatchInvalid, BatchInvalid, BatchCreated}
+	for i, r := range results {
+		if r.Status != want[i] {
+			t.Errorf("event %d: expected %s, got %s (%v)", i, want[i], r.Status, r.Err)
+		}
+	}
+	if len(out.events) != 1 {
+		t.Fatalf("expected only e3 to reach the outbox, got %d events", len(out.events))
+	}
+}
+
+func TestIngestBatch_BoundsConcurrencyAndSize(t *testing.T) {
+	h, _, tx := newBatchPipeline(t, BatchConfig{MaxEvents: 20, Concurrency: 3})
+
+	events := make([]BatchEvent, 20)
+	for i := range events {
+		events[i] = mention(fmt.Sprintf("e%d", i), fmt.Sprintf("c%d", i))
+	}
+	results, err := h.IngestBatch(context.Background(), events)
+	if err != nil {
+		t.Fatalf("IngestBatch: %v", err)
+	}
+	for i, r := range results {
+		if r.Status != BatchCreated {
+			t.Fatalf("event %d: %s (%v)", i, r.Status, r.Err)
+		}
+	}
+	if tx.peak > 3 {
+		t.Fatalf("expected at most 3 transactions in flight, saw %d", tx.peak)
+	}
+
+	if _, err := h.IngestBatch(context.Background(), append(events, mention("e20", "c20"))); !errors.Is(err, ErrBatchTooLarge) {
+		t.Fatalf("expected ErrBatchTooLarge, got %v", err)
+	}
+}
@@ -0,0 +1,163 @@
+package ingest
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"strings"
+	"time"
+
+	"inbox-service/internal/application/ports"
+)
+
+// CloudEvents 1.0 JSON content types (structured and batched mode).
+const (
+	CloudEventsContentType      = "application/cloudevents+json"
+	CloudEventsBatchContentType = "application/cloudevents-batch+json"
+)
+
+// Extension attributes read from inbound CloudEvents. tenantid picks the
+// tenant; the others carry the tracing context like the message headers do
+// (the causation id is always the event's own id).
+const (
+	ceTenantID      = "tenantid"
+	ceTraceparent   = "traceparent"
+	ceCorrelationID = "correlationid"
+)
+
+// CloudEvent is an inbound CloudEvents 1.0 event mapped onto an Envelope: id,
+// type, source and time map one to one, the tenantid extension becomes the
+// tenant and data the payload.
+type CloudEvent struct {
+	Envelope Envelope
+	// Meta is the tracing context from the event's extensions, if any.
+	Meta ports.MessageMeta
+}
+
+// DecodeCloudEvent reads a structured-mode event (application/cloudevents+json).
+func DecodeCloudEvent(body []byte) (CloudEvent, error) {
+	var attrs map[string]json.RawMessage
+	if err := json.Unmarshal(body, &attrs); err != nil {
+		return CloudEvent{}, fmt.Errorf("%w: cloudevent is not a JSON object: %v", ErrInvalidEvent, err)
+	}
+	str := func(name string) (string, error) {
+		raw, ok := attrs[name]
+		if !ok || string(raw) == "null" {
+			return "", nil
+		}
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return "", fmt.Errorf("%w: cloudevent attribute %s must be a string", ErrInvalidEvent, name)
+		}
+		return s, nil
+	}
+	get := map[string]string{}
+	for _, name := range []string{"specversion", "id", "type", "source", "time", "datacontenttype", "data_base64", ceTenantID, ceTraceparent, ceCorrelationID} {
+		v, err := str(name)
+		if err != nil {
+			return CloudEvent{}, err
+		}
+		get[name] = v
+	}
+
+	var data json.RawMessage
+	switch raw, hasData := attrs["data"]; {
+	case hasData && get["data_base64"] != "":
+		return CloudEvent{}, fmt.Errorf("%w: cloudevent has both data and data_base64", ErrInvalidEvent)
+	case hasData:
+		data = raw
+	case get["data_base64"] != "":
+		decoded, err := base64.StdEncoding.DecodeString(get["data_base64"])
+		if err != nil {
+			return CloudEvent{}, fmt.Errorf("%w: cloudevent data_base64: %v", ErrInvalidEvent, err)
+		}
+		data = decoded
+	}
+	return newCloudEvent(func(name string) string { return get[name] }, data)
+}
+
+// DecodeCloudEventBatch splits a batched-mode body
+// (application/cloudevents-batch+json) into its events, which are decoded one
+// by one so a bad event doesn't sink the others.
+func DecodeCloudEventBatch(body []byte) ([]json.RawMessage, error) {
+	var events []json.RawMessage
+	if err := json.Unmarshal(body, &events); err != nil {
+		return nil, fmt.Errorf("%w: cloudevents batch is not a JSON array: %v", ErrInvalidEvent, err)
+	}
+	return events, nil
+}
+
+// DecodeBinaryCloudEvent reads a binary-mode event: the attributes come from
+// ce-* headers, looked up through header (e.g. http.Header.Get), and the body
+// is the data, described by contentType.
+func DecodeBinaryCloudEvent(header func(name string) string, contentType string, body []byte) (CloudEvent, error) {
+	return newCloudEvent(func(name string) string {
+		if name == "datacontenttype" {
+			return contentType
+		}
+		return header("ce-" + name)
+	}, body)
+}
+
+// IsBinaryCloudEvent reports whether the headers carry a binary-mode event.
+func IsBinaryCloudEvent(header func(name string) string) bool {
+	return header("ce-specversion") != ""
+}
+
+func newCloudEvent(attr func(name string) string, data []byte) (CloudEvent, error) {
+	if v := attr("specversion"); v != "1.0" {
+		return CloudEvent{}, fmt.Errorf("%w: unsupported cloudevents specversion %q", ErrInvalidEvent, v)
+	}
+	for _, name := range []string{"id", "type", "source"} {
+		if attr(name) == "" {
+			return CloudEvent{}, fmt.Errorf("%w: cloudevent %s is required", ErrInvalidEvent, name)
+		}
+	}
+	if ct := attr("datacontenttype"); ct != "" && !isJSONContentType(ct) {
+		return CloudEvent{}, fmt.Errorf("%w: cloudevent data must be JSON, got %q", ErrInvalidEvent, ct)
+	}
+	if len(data) > 0 && !json.Valid(data) {
+		return CloudEvent{}, fmt.Errorf("%w: cloudevent data is not valid JSON", ErrInvalidEvent)
+	}
+
+	env := Envelope{
+		ID:       attr("id"),
+		Type:     attr("type"),
+		Source:   attr("source"),
+		TenantID: attr(ceTenantID),
+		Payload:  json.RawMessage(data),
+	}
+	if v := attr("time"); v != "" {
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return CloudEvent{}, fmt.Errorf("%w: cloudevent time: %v", ErrInvalidEvent, err)
+		}
+		env.OccurredAt = t
+	}
+	meta := ports.MessageMetaFromHeaders(func(name string) string {
+		switch name {
+		case ports.HeaderTraceparent:
+			return attr(ceTraceparent)
+		case ports.HeaderCorrelationID:
+			return attr(ceCorrelationID)
+		}
+		return ""
+	})
+	return CloudEvent{Envelope: env, Meta: meta}, nil
+}
+
+// CloudEventContext returns ctx with the event's tracing extensions layered
+// over whatever the transport already put there.
+func CloudEventContext(ctx context.Context, ce CloudEvent) context.Context {
+	return withEventMeta(ctx, ce.Meta)
+}
+
+func isJSONContentType(ct string) bool {
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return false
+	}
+	return mt == "application/json" || mt == "text/json" || strings.HasSuffix(mt, "+json")
+}
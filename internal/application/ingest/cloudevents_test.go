@@ -0,0 +1,96 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"inbox-service/internal/application/ports"
+)
+
+const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestDecodeCloudEvent_Structured(t *testing.T) {
+	body := []byte(`{
+		"specversion": "1.0",
+		"id": "e1",
+		"type": "TaskAssignedToUser",
+		"source": "//tasks.example.com",
+		"time": "2026-01-02T03:04:05Z",
+		"tenantid": "t",
+		"correlationid": "corr-1",
+		"traceparent": "` + traceparent + `",
+		"datacontenttype": "application/json",
+		"data": {"task_id": "1"}
+	}`)
+	ce, err := DecodeCloudEvent(body)
+	if err != nil {
+		t.Fatalf("DecodeCloudEvent: %v", err)
+	}
+	env := ce.Envelope
+	if env.ID != "e1" || env.Type != "TaskAssignedToUser" || env.Source != "//tasks.example.com" || env.TenantID != "t" {
+		t.Fatalf("unexpected envelope %+v", env)
+	}
+	if !env.OccurredAt.Equal(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)) || string(env.Payload) != `{"task_id": "1"}` {
+		t.Fatalf("unexpected time or payload %+v", env)
+	}
+	if ce.Meta.CorrelationID != "corr-1" || ce.Meta.Traceparent != traceparent {
+		t.Fatalf("unexpected meta %+v", ce.Meta)
+	}
+
+	b64, err := DecodeCloudEvent([]byte(`{"specversion":"1.0","id":"e2","type":"X","source":"s","data_base64":"eyJhIjoxfQ=="}`))
+	if err != nil || string(b64.Envelope.Payload) != `{"a":1}` {
+		t.Fatalf("expected data_base64 decoded, got %q (%v)", b64.Envelope.Payload, err)
+	}
+}
+
+func TestDecodeCloudEvent_Invalid(t *testing.T) {
+	for name, body := range map[string]string{
+		"not an object": `[1]`,
+		"specversion":   `{"specversion":"0.3","id":"e","type":"X","source":"s"}`,
+		"missing id":    `{"specversion":"1.0","type":"X","source":"s"}`,
+		"missing src":   `{"specversion":"1.0","id":"e","type":"X"}`,
+		"bad time":      `{"specversion":"1.0","id":"e","type":"X","source":"s","time":"yesterday"}`,
+		"non-json data": `{"specversion":"1.0","id":"e","type":"X","source":"s","datacontenttype":"text/plain","data":"hi"}`,
+		"non-string":    `{"specversion":"1.0","id":1,"type":"X","source":"s"}`,
+		"both datas":    `{"specversion":"1.0","id":"e","type":"X","source":"s","data":{},"data_base64":"e30="}`,
+	} {
+		if _, err := DecodeCloudEvent([]byte(body)); !errors.Is(err, ErrInvalidEvent) {
+			t.Errorf("%s: expected ErrInvalidEvent, got %v", name, err)
+		}
+	}
+}
+
+func TestDecodeBinaryCloudEvent(t *testing.T) {
+	h := http.Header{}
+	h.Set("Ce-Specversion", "1.0")
+	h.Set("Ce-Id", "e1")
+	h.Set("Ce-Type", "TaskAssignedToUser")
+	h.Set("Ce-Source", "//tasks.example.com")
+	h.Set("Ce-Tenantid", "t")
+	if !IsBinaryCloudEvent(h.Get) {
+		t.Fatalf("expected a binary-mode event")
+	}
+
+	ce, err := DecodeBinaryCloudEvent(h.Get, "application/json; charset=utf-8", []byte(`{"task_id":"1"}`))
+	if err != nil {
+		t.Fatalf("DecodeBinaryCloudEvent: %v", err)
+	}
+	if ce.Envelope.ID != "e1" || ce.Envelope.TenantID != "t" || string(ce.Envelope.Payload) != `{"task_id":"1"}` {
+		t.Fatalf("unexpected envelope %+v", ce.Envelope)
+	}
+	if _, err := DecodeBinaryCloudEvent(h.Get, "application/xml", []byte(`<a/>`)); !errors.Is(err, ErrInvalidEvent) {
+		t.Fatalf("expected non-JSON data to be rejected, got %v", err)
+	}
+}
+
+func TestCloudEventContext_OverridesTransportMeta(t *testing.T) {
+	ctx := ports.WithMessageMeta(context.Background(), ports.MessageMeta{CorrelationID: "http", Traceparent: traceparent})
+	ctx = CloudEventContext(ctx, CloudEvent{Meta: ports.MessageMeta{CorrelationID: "event"}})
+	meta := ports.MessageMetaFrom(ctx)
+	if meta.CorrelationID != "event" || meta.Traceparent != traceparent {
+		t.Fatalf("unexpected meta %+v", meta)
+	}
+}
@@ -0,0 +1,133 @@
+package ingest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrInvalidEvent wraps every validation failure; callers should not retry.
+	ErrInvalidEvent = errors.New("invalid event")
+	// ErrUnknownEventType means no EventType is registered under the envelope's type.
+	ErrUnknownEventType = fmt.Errorf("%w: unknown event type", ErrInvalidEvent)
+)
+
+// checkUUID rejects tenant and user ids the database would refuse, so they
+// fail validation instead of surfacing as a store error.
+func checkUUID(field, v string) error {
+	if _, err := uuid.Parse(v); err != nil {
+		return fmt.Errorf("%w: %s %q is not a UUID", ErrInvalidEvent, field, v)
+	}
+	return nil
+}
+
+// Envelope is the transport-independent shape of an upstream event.
+type Envelope struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	TenantID   string          `json:"tenant_id"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Source     string          `json:"source,omitempty"` // producer, e.g. a CloudEvents source URI
+	Payload    json.RawMessage `json:"payload"`
+}
+
+// EventType plugs one kind of upstream event into the ingest pipeline.
+type EventType interface {
+	Name() string
+	// Validate checks the envelope before any transaction is opened. Errors
+	// should wrap ErrInvalidEvent.
+	Validate(env Envelope) error
+	// DedupeKey identifies the inbox item env produces for userID, so the same
+	// fact delivered under a new event id does not create a second item.
+	DedupeKey(env Envelope, userID string) string
+	// Build maps a valid envelope to the items and events it produces.
+	Build(env Envelope) (Output, error)
+}
+
+// EnvelopeResolver is implemented by types that can read the event id, tenant
+// and time from the payload itself; Ingest calls it before Validate.
+type EnvelopeResolver interface {
+	Resolve(env Envelope) (Envelope, error)
+}
+
+type Output struct {
+	Items  []Item
+	Events []Event
+}
+
+// Item is an inbox item to create. The pipeline emits EventType (default
+// InboxItemCreated) for it if the insert was not deduplicated.
+type Item struct {
+	UserID    string
+	Type      string
+	Title     string
+	Body      string
+	ActionURL string
+	EventType string
+	// EventData is merged into the item's InboxItemCreated payload, except
+	// for the ReservedEventData fields the pipeline sets itself.
+	EventData map[string]any
+}
+
+// ReservedEventData are the item event fields Item.EventData can't set.
+var ReservedEventData = []string{
+	"user_id", "inbox_item_id", "type", "source_event_id",
+	"event_id", "occurred_at", "tenant_id", "schema_version",
+}
+
+// Event is an extra outbox event, written once per processed envelope.
+type Event struct {
+	EventType string
+	Payload   map[string]any
+}
+
+type Registry struct {
+	types map[string]EventType
+}
+
+func NewRegistry(types ...EventType) (*Registry, error) {
+	r := &Registry{types: map[string]EventType{}}
+	for _, t := range types {
+		if err := r.Register(t); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+func (r *Registry) Register(t EventType) error {
+	if _, ok := r.types[t.Name()]; ok {
+		return fmt.Errorf("event type %q registered twice", t.Name())
+	}
+	r.types[t.Name()] = t
+	return nil
+}
+
+func (r *Registry) Lookup(name string) (EventType, bool) {
+	t, ok := r.types[name]
+	return t, ok
+}
+
+// Names lists the registered types, sorted.
+func (r *Registry) Names() []string {
+	out := make([]string, 0, len(r.types))
+	for n := range r.types {
+		out = append(out, n)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// DefaultRegistry holds the built-in event types.
+func DefaultRegistry() *Registry {
+	r, err := NewRegistry(TaskAssigned{})
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
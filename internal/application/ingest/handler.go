@@ -0,0 +1,234 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"inbox-service/internal/application/ports"
+
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	Tx       ports.TxManager
+	Inbox    ports.InboxWriter
+	Deduper  ports.EventDeduper
+	Outbox   ports.OutboxWriter
+	Counter  ports.UnreadCounter
+	Stream   ports.StreamWriter
+	Registry *Registry
+	// Batch bounds IngestBatch.
+	Batch BatchConfig
+}
+
+func NewHandler(tx ports.TxManager, inbox ports.InboxWriter, deduper ports.EventDeduper, outbox ports.OutboxWriter, counter ports.UnreadCounter, stream ports.StreamWriter, registry *Registry) *Handler {
+	return &Handler{Tx: tx, Inbox: inbox, Deduper: deduper, Outbox: outbox, Counter: counter, Stream: stream, Registry: registry}
+}
+
+type Result struct {
+	// Duplicate is set when the event id was already processed.
+	Duplicate bool
+	// ItemIDs lists the items actually created; items deduplicated by their
+	// dedupe key are left out.
+	ItemIDs []string
+}
+
+// Ingest runs one envelope through its registered type. It is idempotent on
+// (tenant_id, id) and safe under at-least-once delivery.
+func (h *Handler) Ingest(ctx context.Context, env Envelope) (Result, error) {
+	p, err := h.prepare(ctx, env)
+	if err != nil {
+		return Result{}, err
+	}
+	return h.apply(ctx, p)
+}
+
+// prepared is an envelope that passed validation, with what it produces.
+type prepared struct {
+	et  EventType
+	env Envelope
+	out Output
+}
+
+// prepare resolves, validates and builds env without touching the database.
+func (h *Handler) prepare(ctx context.Context, env Envelope) (prepared, error) {
+	et, ok := h.Registry.Lookup(env.Type)
+	if !ok {
+		return prepared{}, fmt.Errorf("%w %q", ErrUnknownEventType, env.Type)
+	}
+	if r, ok := et.(EnvelopeResolver); ok {
+		resolved, err := r.Resolve(env)
+		if err != nil {
+			return prepared{}, err
+		}
+		env = resolved
+	}
+	if err := checkScope(ctx, env); err != nil {
+		return prepared{}, err
+	}
+	if err := et.Validate(env); err != nil {
+		return prepared{}, err
+	}
+	out, err := et.Build(env)
+	if err != nil {
+		return prepared{}, err
+	}
+	return prepared{et: et, env: env, out: out}, nil
+}
+
+// apply writes a prepared envelope in one transaction.
+func (h *Handler) apply(ctx context.Context, p prepared) (Result, error) {
+	et, env, out := p.et, p.env, p.out
+
+	// Everything this envelope causes is traced back to it; a fresh chain
+	// is correlated by the envelope's own id.
+	meta := ports.MessageMetaFrom(ctx)
+	meta.CausationID = env.ID
+	if meta.CorrelationID == "" {
+		meta.CorrelationID = env.ID
+	}
+	ctx = ports.WithMessageMeta(ctx, meta)
+
+	var res Result
+	err := h.Tx.WithTx(ctx, func(ctx context.Context, tx ports.Tx) error {
+		res = Result{}
+		dup, err := h.Deduper.AlreadyProcessed(ctx, tx, env.TenantID, env.ID)
+		if err != nil {
+			return err
+		}
+		if dup {
+			// already processed -> idempotent no-op
+			res.Duplicate = true
+			return nil
+		}
+
+		for _, it := range out.Items {
+			id, inserted, err := h.createItem(ctx, tx, et, env, it)
+			if err != nil {
+				return err
+			}
+			if inserted {
+				res.ItemIDs = append(res.ItemIDs, id)
+			}
+		}
+
+		for _, e := range out.Events {
+			if err := h.writeOutbox(ctx, tx, env.TenantID, "", e.EventType, e.Payload); err != nil {
+				return err
+			}
+		}
+
+		// Mark event processed (idempotency)
+		return h.Deduper.MarkProcessed(ctx, tx, env.TenantID, env.ID)
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	return res, nil
+}
+
+func (h *Handler) createItem(ctx context.Context, tx ports.Tx, et EventType, env Envelope, it Item) (string, bool, error) {
+	item := ports.InsertInboxItemParams{
+		ID:            uuid.NewString(),
+		TenantID:      env.TenantID,
+		UserID:        it.UserID,
+		Type:          it.Type,
+		Status:        "UNREAD",
+		Title:         it.Title,
+		Body:          it.Body,
+		ActionURL:     it.ActionURL,
+		SourceEventID: env.ID,
+		DedupeKey:     et.DedupeKey(env, it.UserID),
+	}
+	// Insert inbox item (may still be deduped by unique constraint)
+	inserted, err := h.Inbox.InsertInboxItem(ctx, tx, item)
+	if err != nil || !inserted {
+		return "", false, err
+	}
+
+	if err := h.Counter.Adjust(ctx, tx, item.TenantID, item.UserID, item.Type, 1); err != nil {
+		return "", false, err
+	}
+	if err := h.appendCreated(ctx, tx, item); err != nil {
+		return "", false, err
+	}
+
+	// Event-carried state: include enough for downstream consumers.
+	payload := map[string]any{
+		"user_id":         item.UserID,
+		"inbox_item_id":   item.ID,
+		"type":            item.Type,
+		"source_event_id": env.ID,
+	}
+	for k, v := range it.EventData {
+		if !slices.Contains(ReservedEventData, k) {
+			payload[k] = v
+		}
+	}
+	eventType := it.EventType
+	if eventType == "" {
+		eventType = "InboxItemCreated"
+	}
+	if err := h.writeOutbox(ctx, tx, env.TenantID, ports.UserPartitionKey(item.TenantID, item.UserID), eventType, payload); err != nil {
+		return "", false, err
+	}
+	return item.ID, true, nil
+}
+
+// writeOutbox adds the common envelope fields the payload doesn't set itself.
+// Per-item events are keyed by recipient; an empty key means the tenant. The
+// payload's event_id is the outbox id, which publishers also send as the
+// message id.
+func (h *Handler) writeOutbox(ctx context.Context, tx ports.Tx, tenantID, key, eventType string, fields map[string]any) error {
+	id := uuid.NewString()
+	body := map[string]any{
+		"event_id":       id,
+		"occurred_at":    time.Now().UTC().Format(time.RFC3339Nano),
+		"tenant_id":      tenantID,
+		"schema_version": 1,
+	}
+	for k, v := range fields {
+		body[k] = v
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("marshal outbox payload: %w", err)
+	}
+
+	// Insert outbox event (PENDING)
+	return h.Outbox.InsertOutbox(ctx, tx, ports.OutboxEvent{
+		ID:           id,
+		TenantID:     tenantID,
+		EventType:    eventType,
+		PartitionKey: key,
+		Headers:      ports.MessageMetaFrom(ctx).Headers(),
+		Payload:      payload,
+	})
+}
+
+// appendCreated tells live clients about the new item, with enough fields to
+// render it without refetching the feed.
+func (h *Handler) appendCreated(ctx context.Context, tx ports.Tx, item ports.InsertInboxItemParams) error {
+	data, err := json.Marshal(map[string]any{
+		"inbox_item_id": item.ID,
+		"type":          item.Type,
+		"status":        item.Status,
+		"title":         item.Title,
+		"body":          item.Body,
+		"action_url":    item.ActionURL,
+		"version":       1,
+		"created_at":    time.Now().UTC().Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		return fmt.Errorf("marshal stream event: %w", err)
+	}
+	return h.Stream.Append(ctx, tx, ports.StreamEvent{
+		TenantID: item.TenantID,
+		UserID:   item.UserID,
+		Kind:     ports.StreamItemCreated,
+		Data:     data,
+	})
+}
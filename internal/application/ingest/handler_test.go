@@ -0,0 +1,203 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"inbox-service/internal/application/ports"
+)
+
+// --- fakes ---
+
+type memDeduper struct{ seen map[string]bool }
+
+func (d *memDeduper) AlreadyProcessed(ctx context.Context, tx ports.Tx, tenantID, eventID string) (bool, error) {
+	return d.seen[tenantID+"/"+eventID], nil
+}
+
+func (d *memDeduper) MarkProcessed(ctx context.Context, tx ports.Tx, tenantID, eventID string) error {
+	d.seen[tenantID+"/"+eventID] = true
+	return nil
+}
+
+type memInbox struct{ keys map[string]bool }
+
+func (w *memInbox) InsertInboxItem(ctx context.Context, tx ports.Tx, in ports.InsertInboxItemParams) (bool, error) {
+	k := in.TenantID + "/" + in.DedupeKey
+	if w.keys[k] {
+		return false, nil
+	}
+	w.keys[k] = true
+	return true, nil
+}
+
+type memOutbox struct{ events []ports.OutboxEvent }
+
+func (o *memOutbox) InsertOutbox(ctx context.Context, tx ports.Tx, e ports.OutboxEvent) error {
+	o.events = append(o.events, e)
+	return nil
+}
+
+// runTx runs fn, unlike fakeTxMgr which skips it.
+type runTx struct{}
+
+func (runTx) WithTx(ctx context.Context, fn func(ctx context.Context, tx ports.Tx) error) error {
+	return fn(ctx, nil)
+}
+
+// mentioned fans one comment out to every mentioned user.
+type mentioned struct{}
+
+type mentionedPayload struct {
+	CommentID string   `json:"comment_id"`
+	Users     []string `json:"users"`
+}
+
+func (mentioned) Name() string { return "UsersMentioned" }
+
+func (mentioned) Validate(env Envelope) error {
+	var p mentionedPayload
+	if err := json.Unmarshal(env.Payload, &p); err != nil || len(p.Users) == 0 {
+		return fmt.Errorf("%w: users required", ErrInvalidEvent)
+	}
+	return nil
+}
+
+func (mentioned) DedupeKey(env Envelope, userID string) string {
+	var p mentionedPayload
+	_ = json.Unmarshal(env.Payload, &p)
+	return "MENTION:" + p.CommentID + ":" + userID
+}
+
+func (mentioned) Build(env Envelope) (Output, error) {
+	var p mentionedPayload
+	_ = json.Unmarshal(env.Payload, &p)
+	out := Output{Events: []Event{{EventType: "MentionsProcessed", Payload: map[string]any{"comment_id": p.CommentID}}}}
+	for _, u := range p.Users {
+		out.Items = append(out.Items, Item{UserID: u, Type: "MENTION", Title: "You were mentioned", ActionURL: "https://x"})
+	}
+	return out, nil
+}
+
+func newTestPipeline(t *testing.T) (*Handler, *memOutbox) {
+	t.Helper()
+	reg, err := NewRegistry(TaskAssigned{}, mentioned{})
+	if err != nil {
+		t.Fatalf("NewRegistry: %v", err)
+	}
+	out := &memOutbox{}
+	h := NewHandler(runTx{}, &memInbox{keys: map[string]bool{}}, &memDeduper{seen: map[string]bool{}}, out, fakeCounter{}, fakeStream{}, reg)
+	return h, out
+}
+
+// --- tests ---
+
+func TestIngest_RegisteredTypeFansOut(t *testing.T) {
+	h, out := newTestPipeline(t)
+	env := Envelope{ID: "e1", Type: "UsersMentioned", TenantID: "t", Payload: json.RawMessage(`{"comment_id":"c1","users":["u1","u2"]}`)}
+
+	res, err := h.Ingest(context.Background(), env)
+	if err != nil {
+		t.Fatalf("Ingest: %v", err)
+	}
+	if res.Duplicate || len(res.ItemIDs) != 2 {
+		t.Fatalf("expected 2 new items, got %+v", res)
+	}
+	var created, extra int
+	for _, e := range out.events {
+		switch e.EventType {
+		case "InboxItemCreated":
+			created++
+			if e.PartitionKey != "t/u1" && e.PartitionKey != "t/u2" {
+				t.Fatalf("expected InboxItemCreated keyed by recipient, got %q", e.PartitionKey)
+			}
+		case "MentionsProcessed":
+			extra++
+		}
+	}
+	if created != 2 || extra != 1 {
+		t.Fatalf("expected 2 InboxItemCreated and 1 MentionsProcessed, got %d and %d", created, extra)
+	}
+
+	// Same event id: no-op.
+	res, err = h.Ingest(context.Background(), env)
+	if err != nil || !res.Duplicate {
+		t.Fatalf("expected duplicate, got %+v (%v)", res, err)
+	}
+
+	// New event id, same comment: items are deduplicated by key.
+	env.ID = "e2"
+	res, err = h.Ingest(context.Background(), env)
+	if err != nil || res.Duplicate || len(res.ItemIDs) != 0 {
+		t.Fatalf("expected no new items, got %+v (%v)", res, err)
+	}
+}
+
+func TestIngest_PropagatesTraceHeaders(t *testing.T) {
+	h, out := newTestPipeline(t)
+	env := Envelope{ID: "e1", Type: "UsersMentioned", TenantID: "t", Payload: json.RawMessage(`{"comment_id":"c1","users":["u1"]}`)}
+	traceparent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	ctx := MessageContext(context.Background(), map[string]string{
+		ports.HeaderCorrelationID: "corr-1",
+		ports.HeaderCausationID:   "upstream",
+		ports.HeaderTraceparent:   traceparent,
+	})
+
+	if _, err := h.Ingest(ctx, env); err != nil {
+		t.Fatalf("Ingest: %v", err)
+	}
+	if len(out.events) != 2 {
+		t.Fatalf("expected 2 outbox events, got %d", len(out.events))
+	}
+	for _, e := range out.events {
+		want := map[string]string{
+			ports.HeaderCorrelationID: "corr-1",
+			ports.HeaderCausationID:   "e1",
+			ports.HeaderTraceparent:   traceparent,
+		}
+		if fmt.Sprint(e.Headers) != fmt.Sprint(want) {
+			t.Fatalf("%s: expected headers %v, got %v", e.EventType, want, e.Headers)
+		}
+		var body struct {
+			EventID string `json:"event_id"`
+		}
+		if err := json.Unmarshal(e.Payload, &body); err != nil || body.EventID != e.ID {
+			t.Fatalf("%s: expected event_id %q in payload, got %q (%v)", e.EventType, e.ID, body.EventID, err)
+		}
+	}
+
+	// Without inbound headers the envelope starts a new correlation chain.
+	out.events = nil
+	env.ID, env.Payload = "e2", json.RawMessage(`{"comment_id":"c2","users":["u1"]}`)
+	if _, err := h.Ingest(context.Background(), env); err != nil {
+		t.Fatalf("Ingest: %v", err)
+	}
+	for _, e := range out.events {
+		if e.Headers[ports.HeaderCorrelationID] != "e2" || e.Headers[ports.HeaderCausationID] != "e2" || e.Headers[ports.HeaderTraceparent] != "" {
+			t.Fatalf("%s: expected correlation and causation e2, got %v", e.EventType, e.Headers)
+		}
+	}
+}
+
+func TestIngest_RejectsUnknownAndInvalid(t *testing.T) {
+	h, _ := newTestPipeline(t)
+
+	_, err := h.Ingest(context.Background(), Envelope{ID: "e", Type: "Nope", TenantID: "t"})
+	if !errors.Is(err, ErrUnknownEventType) || !errors.Is(err, ErrInvalidEvent) {
+		t.Fatalf("expected ErrUnknownEventType, got %v", err)
+	}
+
+	_, err = h.Ingest(context.Background(), Envelope{ID: "e", Type: "UsersMentioned", TenantID: "t", Payload: json.RawMessage(`{}`)})
+	if !errors.Is(err, ErrInvalidEvent) {
+		t.Fatalf("expected ErrInvalidEvent, got %v", err)
+	}
+}
+
+func TestRegistry_RejectsDuplicateNames(t *testing.T) {
+	if _, err := NewRegistry(TaskAssigned{}, TaskAssigned{}); err == nil {
+		t.Fatalf("expected an error for a duplicate registration")
+	}
+}
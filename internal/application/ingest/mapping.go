@@ -0,0 +1,354 @@
+package ingest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MappingFile is the declarative event-type config (YAML or JSON).
+type MappingFile struct {
+	EventTypes []Mapping `yaml:"event_types" json:"event_types"`
+}
+
+// Mapping declares one inbound event type. Paths ("$.a.b[0]") are evaluated
+// against the event payload; templates are text/template with .payload,
+// .recipient, .tenant_id, .event_id, .occurred_at and .source in scope.
+type Mapping struct {
+	Name string `yaml:"name" json:"name"`
+
+	EventIDPath    string `yaml:"event_id_path" json:"event_id_path"`
+	TenantIDPath   string `yaml:"tenant_id_path" json:"tenant_id_path"`
+	OccurredAtPath string `yaml:"occurred_at_path" json:"occurred_at_path"`
+	// RecipientsPath points at a user id or an array of user ids.
+	RecipientsPath string `yaml:"recipients_path" json:"recipients_path"`
+
+	// DedupeKey identifies the notification an event is about, e.g.
+	// "MENTION:{{.payload.comment_id}}". Each recipient's item is deduped on
+	// it plus the recipient's id, which is appended.
+	DedupeKey string `yaml:"dedupe_key" json:"dedupe_key"`
+
+	Item struct {
+		Type      string `yaml:"type" json:"type"`
+		Title     string `yaml:"title" json:"title"`
+		Body      string `yaml:"body" json:"body"`
+		ActionURL string `yaml:"action_url" json:"action_url"`
+	} `yaml:"item" json:"item"`
+
+	Outbox struct {
+		// EventType names the per-item event; defaults to InboxItemCreated.
+		EventType string `yaml:"event_type" json:"event_type"`
+		// Fields may not use the ReservedEventData names.
+		Fields map[string]string `yaml:"fields" json:"fields"`
+	} `yaml:"outbox" json:"outbox"`
+}
+
+// LoadMappings reads and validates a mapping file.
+func LoadMappings(path string) ([]*MappedType, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read mappings: %w", err)
+	}
+	// YAML is a superset of JSON, so one decoder covers both.
+	var f MappingFile
+	dec := yaml.NewDecoder(bytes.NewReader(raw))
+	dec.KnownFields(true)
+	if err := dec.Decode(&f); err != nil {
+		return nil, fmt.Errorf("parse mappings %s: %w", path, err)
+	}
+
+	out := make([]*MappedType, 0, len(f.EventTypes))
+	seen := map[string]bool{}
+	for i, m := range f.EventTypes {
+		t, err := NewMappedType(m)
+		if err != nil {
+			return nil, fmt.Errorf("mappings %s: event_types[%d]: %w", path, i, err)
+		}
+		if seen[m.Name] {
+			return nil, fmt.Errorf("mappings %s: event type %q declared twice", path, m.Name)
+		}
+		seen[m.Name] = true
+		out = append(out, t)
+	}
+	return out, nil
+}
+
+// MappedType is an EventType driven by a Mapping instead of code.
+type MappedType struct {
+	m         Mapping
+	dedupe    *template.Template
+	title     *template.Template
+	body      *template.Template
+	actionURL *template.Template
+	fields    map[string]*template.Template
+}
+
+func NewMappedType(m Mapping) (*MappedType, error) {
+	required := map[string]string{
+		"name":            m.Name,
+		"event_id_path":   m.EventIDPath,
+		"tenant_id_path":  m.TenantIDPath,
+		"recipients_path": m.RecipientsPath,
+		"dedupe_key":      m.DedupeKey,
+		"item.type":       m.Item.Type,
+		"item.title":      m.Item.Title,
+		"item.action_url": m.Item.ActionURL,
+	}
+	for field, v := range required {
+		if strings.TrimSpace(v) == "" {
+			return nil, fmt.Errorf("%s is required", field)
+		}
+	}
+	for k := range m.Outbox.Fields {
+		if slices.Contains(ReservedEventData, k) {
+			return nil, fmt.Errorf("outbox.fields.%s is set by the pipeline", k)
+		}
+	}
+	if m.Outbox.EventType == "" {
+		m.Outbox.EventType = "InboxItemCreated"
+	}
+
+	t := &MappedType{m: m, fields: map[string]*template.Template{}}
+	var err error
+	parse := func(name, text string) *template.Template {
+		if err != nil {
+			return nil
+		}
+		var tpl *template.Template
+		tpl, err = template.New(name).Option("missingkey=error").Parse(text)
+		if err != nil {
+			err = fmt.Errorf("%s: %w", name, err)
+		}
+		return tpl
+	}
+	t.dedupe = parse("dedupe_key", m.DedupeKey)
+	t.title = parse("item.title", m.Item.Title)
+	t.body = parse("item.body", m.Item.Body)
+	t.actionURL = parse("item.action_url", m.Item.ActionURL)
+	for k, v := range m.Outbox.Fields {
+		t.fields[k] = parse("outbox.fields."+k, v)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *MappedType) Name() string { return t.m.Name }
+
+// Resolve fills the envelope's id, tenant and time from the payload paths
+// when the transport did not set them.
+func (t *MappedType) Resolve(env Envelope) (Envelope, error) {
+	doc, err := t.doc(env)
+	if err != nil {
+		return env, err
+	}
+	if env.ID == "" {
+		env.ID = pathString(doc, t.m.EventIDPath)
+	}
+	if env.TenantID == "" {
+		env.TenantID = pathString(doc, t.m.TenantIDPath)
+	}
+	if env.OccurredAt.IsZero() && t.m.OccurredAtPath != "" {
+		if ts, err := time.Parse(time.RFC3339Nano, pathString(doc, t.m.OccurredAtPath)); err == nil {
+			env.OccurredAt = ts
+		}
+	}
+	return env, nil
+}
+
+func (t *MappedType) Validate(env Envelope) error {
+	doc, err := t.doc(env)
+	if err != nil {
+		return err
+	}
+	if env.ID == "" {
+		return fmt.Errorf("%w: no event id at %s", ErrInvalidEvent, t.m.EventIDPath)
+	}
+	if env.TenantID == "" {
+		return fmt.Errorf("%w: no tenant id at %s", ErrInvalidEvent, t.m.TenantIDPath)
+	}
+	if err := checkUUID("tenant id", env.TenantID); err != nil {
+		return err
+	}
+	users := t.recipients(doc)
+	if len(users) == 0 {
+		return fmt.Errorf("%w: no recipients at %s", ErrInvalidEvent, t.m.RecipientsPath)
+	}
+	for _, u := range users {
+		if err := checkUUID("recipient", u); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *MappedType) DedupeKey(env Envelope, userID string) string {
+	doc, _ := t.doc(env)
+	key, err := render(t.dedupe, t.data(env, doc, userID))
+	if err != nil {
+		// Build renders the same template first, so this is unreachable for
+		// envelopes that got that far.
+		key = t.m.Item.Type + ":" + env.ID
+	}
+	return key + ":" + userID
+}
+
+func (t *MappedType) Build(env Envelope) (Output, error) {
+	doc, err := t.doc(env)
+	if err != nil {
+		return Output{}, err
+	}
+
+	var out Output
+	for _, userID := range t.recipients(doc) {
+		data := t.data(env, doc, userID)
+		it := Item{UserID: userID, Type: t.m.Item.Type, EventType: t.m.Outbox.EventType, EventData: map[string]any{}}
+		for _, r := range []struct {
+			tpl *template.Template
+			dst *string
+		}{
+			{t.title, &it.Title},
+			{t.body, &it.Body},
+			{t.actionURL, &it.ActionURL},
+		} {
+			if *r.dst, err = render(r.tpl, data); err != nil {
+				return Output{}, err
+			}
+		}
+		if _, err := render(t.dedupe, data); err != nil {
+			return Output{}, err
+		}
+		for k, tpl := range t.fields {
+			v, err := render(tpl, data)
+			if err != nil {
+				return Output{}, err
+			}
+			it.EventData[k] = v
+		}
+		out.Items = append(out.Items, it)
+	}
+	return out, nil
+}
+
+// doc decodes the payload with numbers kept as json.Number, so large numeric
+// ids aren't rounded through float64.
+func (t *MappedType) doc(env Envelope) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(env.Payload))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("%w: payload: %v", ErrInvalidEvent, err)
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("%w: payload: trailing data", ErrInvalidEvent)
+	}
+	return doc, nil
+}
+
+func (t *MappedType) recipients(doc any) []string {
+	v, _ := lookupPath(doc, t.m.RecipientsPath)
+	switch r := v.(type) {
+	case string:
+		if r != "" {
+			return []string{r}
+		}
+	case []any:
+		out := make([]string, 0, len(r))
+		for _, u := range r {
+			if s, ok := u.(string); ok && s != "" {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func (t *MappedType) data(env Envelope, doc any, userID string) map[string]any {
+	return map[string]any{
+		"payload":     doc,
+		"recipient":   userID,
+		"tenant_id":   env.TenantID,
+		"event_id":    env.ID,
+		"occurred_at": env.OccurredAt,
+		"source":      env.Source,
+	}
+}
+
+func render(tpl *template.Template, data map[string]any) (string, error) {
+	var b strings.Builder
+	if err := tpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+	}
+	return b.String(), nil
+}
+
+// lookupPath evaluates "$.a.b[0].c" (the "$." prefix is optional).
+func lookupPath(doc any, path string) (any, bool) {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	path = strings.ReplaceAll(strings.ReplaceAll(path, "[", "."), "]", "")
+	cur := doc
+	for _, part := range strings.Split(path, ".") {
+		if part == "" {
+			continue
+		}
+		switch c := cur.(type) {
+		case map[string]any:
+			v, ok := c[part]
+			if !ok {
+				return nil, false
+			}
+			cur = v
+		case []any:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(c) {
+				return nil, false
+			}
+			cur = c[i]
+		default:
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// pathString returns the value at path as a string; numbers keep the digits
+// they were sent with, so numeric ids survive.
+func pathString(doc any, path string) string {
+	v, _ := lookupPath(doc, path)
+	switch s := v.(type) {
+	case string:
+		return s
+	case json.Number:
+		return s.String()
+	}
+	return ""
+}
+
+// RegistryWithMappings returns the built-in types plus those declared in the
+// mapping file at path (none if path is empty).
+func RegistryWithMappings(path string) (*Registry, error) {
+	r := DefaultRegistry()
+	if path == "" {
+		return r, nil
+	}
+	types, err := LoadMappings(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range types {
+		if err := r.Register(t); err != nil {
+			return nil, fmt.Errorf("mappings %s: %w", path, err)
+		}
+	}
+	return r, nil
+}
@@ -0,0 +1,182 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	mentionTenant = "aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa"
+	mentionUser2  = "22222222-2222-2222-2222-222222222222"
+)
+
+const mentionPayload = `{
+	"event_id": "e-1",
+	"tenant_id": "aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa",
+	"occurred_at": "2026-01-22T10:00:00Z",
+	"mentioned_user_ids": ["11111111-1111-1111-1111-111111111111", "22222222-2222-2222-2222-222222222222"],
+	"comment_id": "c-7",
+	"comment_url": "https://app.example.com/c/7",
+	"excerpt": "please review",
+	"author": {"id": "a-1", "name": "Ana"}
+}`
+
+func TestLoadMappings_ExampleFile(t *testing.T) {
+	types, err := LoadMappings(filepath.Join("..", "..", "..", "config", "mappings.example.yaml"))
+	if err != nil {
+		t.Fatalf("LoadMappings: %v", err)
+	}
+	if len(types) != 2 || types[0].Name() != "CommentMentioned" {
+		t.Fatalf("unexpected types %+v", types)
+	}
+}
+
+func TestMappedType_BuildsItemsFromPathsAndTemplates(t *testing.T) {
+	types, err := LoadMappings(filepath.Join("..", "..", "..", "config", "mappings.example.yaml"))
+	if err != nil {
+		t.Fatalf("LoadMappings: %v", err)
+	}
+	mt := types[0]
+
+	env, err := mt.Resolve(Envelope{Type: mt.Name(), Payload: json.RawMessage(mentionPayload)})
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if env.ID != "e-1" || env.TenantID != mentionTenant || env.OccurredAt.IsZero() {
+		t.Fatalf("expected id, tenant and time from the payload, got %+v", env)
+	}
+	if err := mt.Validate(env); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	out, err := mt.Build(env)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if len(out.Items) != 2 {
+		t.Fatalf("expected an item per recipient, got %d", len(out.Items))
+	}
+	it := out.Items[1]
+	if it.UserID != mentionUser2 || it.Type != "MENTION" || it.Title != "Ana mentioned you" || it.ActionURL != "https://app.example.com/c/7" {
+		t.Fatalf("unexpected item %+v", it)
+	}
+	if it.EventData["author_id"] != "a-1" {
+		t.Fatalf("expected outbox fields, got %+v", it.EventData)
+	}
+	if got := mt.DedupeKey(env, mentionUser2); got != "MENTION:c-7:"+mentionUser2 {
+		t.Fatalf("unexpected dedupe key %q", got)
+	}
+}
+
+func TestMappedType_RejectsEventsMissingData(t *testing.T) {
+	types, _ := LoadMappings(filepath.Join("..", "..", "..", "config", "mappings.example.yaml"))
+	mt := types[0]
+
+	var doc map[string]any
+	_ = json.Unmarshal([]byte(mentionPayload), &doc)
+	delete(doc, "mentioned_user_ids")
+	raw, _ := json.Marshal(doc)
+	if err := mt.Validate(Envelope{ID: "e", TenantID: mentionTenant, Payload: raw}); !errors.Is(err, ErrInvalidEvent) {
+		t.Fatalf("expected ErrInvalidEvent without recipients, got %v", err)
+	}
+
+	_ = json.Unmarshal([]byte(mentionPayload), &doc)
+	delete(doc, "author")
+	raw, _ = json.Marshal(doc)
+	if _, err := mt.Build(Envelope{ID: "e", TenantID: mentionTenant, Payload: raw}); !errors.Is(err, ErrInvalidEvent) {
+		t.Fatalf("expected ErrInvalidEvent for a missing template key, got %v", err)
+	}
+}
+
+func TestMappedType_RejectsNonUUIDIDs(t *testing.T) {
+	types, _ := LoadMappings(filepath.Join("..", "..", "..", "config", "mappings.example.yaml"))
+	mt := types[0]
+
+	for _, p := range []string{
+		strings.Replace(mentionPayload, mentionTenant, "t", 1),
+		strings.Replace(mentionPayload, mentionUser2, "u2", 1),
+	} {
+		env, err := mt.Resolve(Envelope{Type: mt.Name(), Payload: json.RawMessage(p)})
+		if err != nil {
+			t.Fatalf("Resolve: %v", err)
+		}
+		if err := mt.Validate(env); !errors.Is(err, ErrInvalidEvent) {
+			t.Fatalf("expected ErrInvalidEvent for a non-UUID id, got %v", err)
+		}
+	}
+}
+
+func TestMappedType_KeepsLargeNumericIDs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "m.json")
+	_ = os.WriteFile(path, []byte(`{"event_types":[{"name":"X","event_id_path":"$.id","tenant_id_path":"$.t","recipients_path":"$.u","dedupe_key":"K:{{.payload.id}}","item":{"type":"T","title":"t","action_url":"u"}}]}`), 0o600)
+	types, err := LoadMappings(path)
+	if err != nil {
+		t.Fatalf("LoadMappings: %v", err)
+	}
+	mt := types[0]
+
+	env, err := mt.Resolve(Envelope{Type: "X", Payload: json.RawMessage(`{"id": 9007199254740993, "t": "t", "u": "11111111-1111-1111-1111-111111111111"}`)})
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if env.ID != "9007199254740993" {
+		t.Fatalf("expected the id digits as sent, got %q", env.ID)
+	}
+	if got := mt.DedupeKey(env, "u1"); got != "K:9007199254740993:u1" {
+		t.Fatalf("unexpected dedupe key %q", got)
+	}
+}
+
+func TestLoadMappings_Validation(t *testing.T) {
+	cases := map[string]string{
+		"missing field":  `{"event_types":[{"name":"X"}]}`,
+		"unknown field":  `{"event_types":[{"name":"X","titel":"typo"}]}`,
+		"bad template":   `{"event_types":[{"name":"X","event_id_path":"$.id","tenant_id_path":"$.t","recipients_path":"$.u","dedupe_key":"{{.recipient}","item":{"type":"T","title":"t","action_url":"u"}}]}`,
+		"reserved field": `{"event_types":[{"name":"X","event_id_path":"$.id","tenant_id_path":"$.t","recipients_path":"$.u","dedupe_key":"K","item":{"type":"T","title":"t","action_url":"u"},"outbox":{"fields":{"user_id":"$.u"}}}]}`,
+	}
+	for name, body := range cases {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "m.json")
+			_ = os.WriteFile(path, []byte(body), 0o600)
+			if _, err := LoadMappings(path); err == nil {
+				t.Fatalf("expected a validation error")
+			}
+		})
+	}
+
+	// Built-in names cannot be redeclared.
+	path := filepath.Join(t.TempDir(), "m.yaml")
+	_ = os.WriteFile(path, []byte(`event_types:
+  - name: TaskAssignedToUser
+    event_id_path: $.id
+    tenant_id_path: $.t
+    recipients_path: $.u
+    dedupe_key: "K:{{.recipient}}"
+    item: {type: T, title: t, action_url: u}
+`), 0o600)
+	if _, err := RegistryWithMappings(path); err == nil {
+		t.Fatalf("expected a clash with the built-in type")
+	}
+}
+
+func TestIngest_MappedTypeThroughPipeline(t *testing.T) {
+	reg, err := RegistryWithMappings(filepath.Join("..", "..", "..", "config", "mappings.example.yaml"))
+	if err != nil {
+		t.Fatalf("RegistryWithMappings: %v", err)
+	}
+	out := &memOutbox{}
+	h := NewHandler(runTx{}, &memInbox{keys: map[string]bool{}}, &memDeduper{seen: map[string]bool{}}, out, fakeCounter{}, fakeStream{}, reg)
+
+	res, err := h.Ingest(context.Background(), Envelope{Type: "CommentMentioned", Payload: json.RawMessage(mentionPayload)})
+	if err != nil {
+		t.Fatalf("Ingest: %v", err)
+	}
+	if len(res.ItemIDs) != 2 || len(out.events) != 2 || out.events[0].TenantID != mentionTenant {
+		t.Fatalf("expected 2 items and 2 outbox events for the tenant, got %+v / %+v", res, out.events)
+	}
+}
@@ -0,0 +1,65 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"inbox-service/internal/application/ports"
+)
+
+// DecodeMessage turns a broker message into an Envelope. A JSON body with
+// "type" and "payload" is taken as an Envelope as is. Anything else is treated
+// as a bare payload whose type comes from the event-type header or, failing
+// that, fallbackType (e.g. configured per topic).
+func DecodeMessage(body []byte, headers map[string]string, fallbackType string) (Envelope, error) {
+	var probe struct {
+		Type    string          `json:"type"`
+		Payload json.RawMessage `json:"payload"`
+	}
+	if err := json.Unmarshal(body, &probe); err != nil {
+		return Envelope{}, fmt.Errorf("%w: body is not JSON: %v", ErrInvalidEvent, err)
+	}
+	if probe.Type != "" && len(probe.Payload) > 0 {
+		var env Envelope
+		if err := json.Unmarshal(body, &env); err != nil {
+			return Envelope{}, fmt.Errorf("%w: envelope: %v", ErrInvalidEvent, err)
+		}
+		return env, nil
+	}
+
+	env := Envelope{
+		Type:     headers[ports.HeaderEventType],
+		TenantID: headers[ports.HeaderTenantID],
+		Payload:  json.RawMessage(body),
+	}
+	if env.Type == "" {
+		env.Type = fallbackType
+	}
+	if env.Type == "" {
+		return Envelope{}, fmt.Errorf("%w: no event type in body, %s header or topic config", ErrInvalidEvent, ports.HeaderEventType)
+	}
+	return env, nil
+}
+
+// MessageContext returns ctx carrying the message's correlation id, causation
+// id and traceparent headers, for Ingest to copy onto the outbox events.
+func MessageContext(ctx context.Context, headers map[string]string) context.Context {
+	return ports.WithMessageMeta(ctx, ports.MessageMetaFromHeaders(func(name string) string {
+		return headers[name]
+	}))
+}
+
+// IsPermanent reports whether redelivering the message can never succeed, so
+// consumers should dead-letter it instead of retrying. Besides invalid events
+// that covers database data exceptions (SQLSTATE class 22, e.g. a malformed
+// UUID), which fail the same way on every attempt.
+func IsPermanent(err error) bool {
+	if errors.Is(err, ErrInvalidEvent) {
+		return true
+	}
+	var pgErr interface{ SQLState() string }
+	return errors.As(err, &pgErr) && strings.HasPrefix(pgErr.SQLState(), "22")
+}
@@ -0,0 +1,65 @@
+package ingest
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"inbox-service/internal/application/ports"
+)
+
+func TestDecodeMessage_Envelope(t *testing.T) {
+	body := []byte(`{"id":"e1","type":"TaskAssignedToUser","tenant_id":"t","payload":{"task_id":"1"}}`)
+	env, err := DecodeMessage(body, nil, "Other")
+	if err != nil {
+		t.Fatalf("DecodeMessage: %v", err)
+	}
+	if env.ID != "e1" || env.Type != "TaskAssignedToUser" || env.TenantID != "t" {
+		t.Fatalf("unexpected envelope %+v", env)
+	}
+}
+
+func TestDecodeMessage_BarePayload(t *testing.T) {
+	body := []byte(`{"task_id":"1"}`)
+
+	env, err := DecodeMessage(body, map[string]string{ports.HeaderTenantID: "t"}, "TaskAssignedToUser")
+	if err != nil {
+		t.Fatalf("DecodeMessage: %v", err)
+	}
+	if env.Type != "TaskAssignedToUser" || env.TenantID != "t" || string(env.Payload) != string(body) {
+		t.Fatalf("expected fallback type and raw payload, got %+v", env)
+	}
+
+	env, err = DecodeMessage(body, map[string]string{ports.HeaderEventType: "CommentMentioned"}, "TaskAssignedToUser")
+	if err != nil {
+		t.Fatalf("DecodeMessage: %v", err)
+	}
+	if env.Type != "CommentMentioned" {
+		t.Fatalf("expected header to win over fallback, got %q", env.Type)
+	}
+}
+
+func TestDecodeMessage_Permanent(t *testing.T) {
+	for _, body := range []string{`not json`, `{"task_id":"1"}`} {
+		_, err := DecodeMessage([]byte(body), nil, "")
+		if !errors.Is(err, ErrInvalidEvent) || !IsPermanent(err) {
+			t.Fatalf("%s: expected permanent ErrInvalidEvent, got %v", body, err)
+		}
+	}
+}
+
+type sqlStateError string
+
+func (e sqlStateError) Error() string    { return "pg error " + string(e) }
+func (e sqlStateError) SQLState() string { return string(e) }
+
+func TestIsPermanent_DataExceptions(t *testing.T) {
+	// 22P02: invalid text representation, e.g. a malformed UUID.
+	if !IsPermanent(fmt.Errorf("insert item: %w", sqlStateError("22P02"))) {
+		t.Fatalf("expected a data exception to be permanent")
+	}
+	// 40001: serialization failure, worth retrying.
+	if IsPermanent(fmt.Errorf("insert item: %w", sqlStateError("40001"))) {
+		t.Fatalf("expected a serialization failure to be retried")
+	}
+}
@@ -0,0 +1,45 @@
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// ErrOutOfScope is returned for events the caller may not ingest: another
+// tenant's, or a type its credentials don't allow.
+var ErrOutOfScope = fmt.Errorf("%w: outside the caller's scope", ErrInvalidEvent)
+
+// Scope limits what a caller may ingest. Transports that aren't tied to a
+// caller, such as the broker consumers, run without one.
+type Scope struct {
+	TenantIDs  []string
+	EventTypes []string // empty means all
+}
+
+type scopeKey struct{}
+
+func WithScope(ctx context.Context, s Scope) context.Context {
+	return context.WithValue(ctx, scopeKey{}, s)
+}
+
+func ScopeFrom(ctx context.Context) (Scope, bool) {
+	s, ok := ctx.Value(scopeKey{}).(Scope)
+	return s, ok
+}
+
+// checkScope is run on the resolved envelope, so a tenant taken from the payload
+// is held to the scope too.
+func checkScope(ctx context.Context, env Envelope) error {
+	s, ok := ScopeFrom(ctx)
+	if !ok {
+		return nil
+	}
+	if !slices.Contains(s.TenantIDs, env.TenantID) {
+		return fmt.Errorf("%w: tenant %q", ErrOutOfScope, env.TenantID)
+	}
+	if len(s.EventTypes) > 0 && !slices.Contains(s.EventTypes, env.Type) {
+		return fmt.Errorf("%w: event type %q", ErrOutOfScope, env.Type)
+	}
+	return nil
+}
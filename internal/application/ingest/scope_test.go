@@ -0,0 +1,35 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestIngest_RejectsEventsOutsideScope(t *testing.T) {
+	h, out := newTestPipeline(t)
+	tenant := "aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa"
+	ctx := WithScope(context.Background(), Scope{TenantIDs: []string{tenant}, EventTypes: []string{"TaskAssignedToUser"}})
+
+	// Another tenant, named in the envelope or only in the payload.
+	other := Envelope{ID: "e1", Type: "TaskAssignedToUser", TenantID: "other", Payload: json.RawMessage(`{"task_id":"1","assignee_user_id":"bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbbbbb","task_url":"https://x"}`)}
+	bare := Envelope{Type: "TaskAssignedToUser", Payload: json.RawMessage(`{"event_id":"e2","tenant_id":"other","task_id":"1","assignee_user_id":"bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbbbbb","task_url":"https://x"}`)}
+	// A type the caller may not send.
+	mention := Envelope{ID: "e3", Type: "UsersMentioned", TenantID: tenant, Payload: json.RawMessage(`{"comment_id":"c1","users":["u1"]}`)}
+
+	for _, env := range []Envelope{other, bare, mention} {
+		if _, err := h.Ingest(ctx, env); !errors.Is(err, ErrOutOfScope) || !errors.Is(err, ErrInvalidEvent) {
+			t.Fatalf("%+v: expected ErrOutOfScope, got %v", env, err)
+		}
+	}
+	if len(out.events) != 0 {
+		t.Fatalf("expected nothing written, got %d outbox events", len(out.events))
+	}
+
+	ok := other
+	ok.TenantID = tenant
+	if _, err := h.Ingest(ctx, ok); err != nil {
+		t.Fatalf("Ingest in scope: %v", err)
+	}
+}
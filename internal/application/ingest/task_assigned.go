@@ -5,10 +5,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"time"
-
-	"inbox-service/internal/application/ports"
-
-	"github.com/google/uuid"
 )
 
 type TaskAssignedToUser struct {
@@ -23,95 +19,106 @@ type TaskAssignedToUser struct {
 	Version         int       `json:"version"`
 }
 
-type Handler struct {
-	Tx       ports.TxManager
-	Inbox    ports.InboxWriter
-	Deduper  ports.EventDeduper
-	Outbox   ports.OutboxWriter
+// TaskAssigned is the EventType for TaskAssignedToUser. The payload is the
+// TaskAssignedToUser JSON; its event_id, tenant_id and occurred_at may be left
+// out in favour of the envelope's.
+type TaskAssigned struct{}
+
+func (TaskAssigned) Name() string { return "TaskAssignedToUser" }
+
+func (TaskAssigned) decode(env Envelope) (TaskAssignedToUser, error) {
+	var evt TaskAssignedToUser
+	if len(env.Payload) > 0 {
+		if err := json.Unmarshal(env.Payload, &evt); err != nil {
+			return TaskAssignedToUser{}, fmt.Errorf("%w: payload: %v", ErrInvalidEvent, err)
+		}
+	}
+	evt.EventID, evt.TenantID = env.ID, env.TenantID
+	if !env.OccurredAt.IsZero() {
+		evt.OccurredAt = env.OccurredAt
+	}
+	return evt, nil
 }
 
-func NewHandler(tx ports.TxManager, inbox ports.InboxWriter, deduper ports.EventDeduper, outbox ports.OutboxWriter) *Handler {
-	return &Handler{Tx: tx, Inbox: inbox, Deduper: deduper, Outbox: outbox}
+// Resolve takes id, tenant and time from the payload when a transport
+// delivers a bare TaskAssignedToUser.
+func (TaskAssigned) Resolve(env Envelope) (Envelope, error) {
+	var evt TaskAssignedToUser
+	if len(env.Payload) > 0 {
+		if err := json.Unmarshal(env.Payload, &evt); err != nil {
+			return env, fmt.Errorf("%w: payload: %v", ErrInvalidEvent, err)
+		}
+	}
+	if env.ID == "" {
+		env.ID = evt.EventID
+	}
+	if env.TenantID == "" {
+		env.TenantID = evt.TenantID
+	}
+	if env.OccurredAt.IsZero() {
+		env.OccurredAt = evt.OccurredAt
+	}
+	return env, nil
 }
 
-// HandleTaskAssigned is idempotent: safe under at-least-once delivery.
-func (h *Handler) HandleTaskAssigned(ctx context.Context, evt TaskAssignedToUser) (string, error) {
+func (t TaskAssigned) Validate(env Envelope) error {
+	evt, err := t.decode(env)
+	if err != nil {
+		return err
+	}
 	// minimal validation
 	if evt.EventID == "" || evt.TenantID == "" || evt.AssigneeUserID == "" || evt.TaskID == "" {
-		return "", fmt.Errorf("invalid event: missing required fields")
+		return fmt.Errorf("%w: missing required fields", ErrInvalidEvent)
 	}
 	if evt.TaskURL == "" {
-		return "", fmt.Errorf("invalid event: task_url required")
+		return fmt.Errorf("%w: task_url required", ErrInvalidEvent)
 	}
+	if err := checkUUID("tenant_id", evt.TenantID); err != nil {
+		return err
+	}
+	return checkUUID("assignee_user_id", evt.AssigneeUserID)
+}
 
-	inboxItemID := uuid.NewString()
-	dedupeKey := fmt.Sprintf("TASK_ASSIGNED:%s:%s", evt.TaskID, evt.AssigneeUserID)
+func (t TaskAssigned) DedupeKey(env Envelope, userID string) string {
+	evt, _ := t.decode(env)
+	return fmt.Sprintf("TASK_ASSIGNED:%s:%s", evt.TaskID, userID)
+}
 
-	// Prepare outbox payload (event-carried state: we include enough for downstream)
-	outPayload, err := json.Marshal(map[string]any{
-		"event_id":         uuid.NewString(),
-		"occurred_at":      time.Now().UTC().Format(time.RFC3339Nano),
-		"tenant_id":        evt.TenantID,
-		"user_id":          evt.AssigneeUserID,
-		"inbox_item_id":    inboxItemID,
-		"type":             "TASK_ASSIGNED",
-		"source_event_id":  evt.EventID,
-		"task_id":          evt.TaskID,
-		"task_title":       evt.TaskTitle,
-		"task_url":         evt.TaskURL,
-		"schema_version":   1,
-	})
+func (t TaskAssigned) Build(env Envelope) (Output, error) {
+	evt, err := t.decode(env)
 	if err != nil {
-		return "", fmt.Errorf("marshal outbox payload: %w", err)
+		return Output{}, err
 	}
+	return Output{Items: []Item{{
+		UserID:    evt.AssigneeUserID,
+		Type:      "TASK_ASSIGNED",
+		Title:     "Task assigned to you",
+		Body:      fmt.Sprintf("You have been assigned a new task: %s", evt.TaskTitle),
+		ActionURL: evt.TaskURL,
+		EventData: map[string]any{
+			"task_id":    evt.TaskID,
+			"task_title": evt.TaskTitle,
+			"task_url":   evt.TaskURL,
+		},
+	}}}, nil
+}
 
-	err = h.Tx.WithTx(ctx, func(ctx context.Context, tx ports.Tx) error {
-		dup, err := h.Deduper.AlreadyProcessed(ctx, tx, evt.TenantID, evt.EventID)
-		if err != nil {
-			return err
-		}
-		if dup {
-			// already processed -> idempotent no-op
-			return nil
-		}
-
-		// Insert inbox item (may still be deduped by unique constraint)
-		err = h.Inbox.InsertInboxItem(ctx, tx, ports.InsertInboxItemParams{
-			ID:            inboxItemID,
-			TenantID:      evt.TenantID,
-			UserID:        evt.AssigneeUserID,
-			Type:          "TASK_ASSIGNED",
-			Status:        "UNREAD",
-			Title:         "Task assigned to you",
-			Body:          fmt.Sprintf("You have been assigned a new task: %s", evt.TaskTitle),
-			ActionURL:     evt.TaskURL,
-			SourceEventID: evt.EventID,
-			DedupeKey:     dedupeKey,
-		})
-		if err != nil {
-			return err
-		}
-
-		// Mark event processed (idempotency)
-		if err := h.Deduper.MarkProcessed(ctx, tx, evt.TenantID, evt.EventID); err != nil {
-			return err
-		}
-
-		// Insert outbox event (PENDING)
-		if err := h.Outbox.InsertOutbox(ctx, tx, ports.OutboxEvent{
-			ID:        uuid.NewString(),
-			TenantID:  evt.TenantID,
-			EventType: "InboxItemCreated",
-			Payload:   outPayload,
-		}); err != nil {
-			return err
-		}
-
-		return nil
-	})
-
+// HandleTaskAssigned ingests a TaskAssignedToUser and returns the created
+// item's id, or "" if the event or item was a duplicate.
+func (h *Handler) HandleTaskAssigned(ctx context.Context, evt TaskAssignedToUser) (string, error) {
+	payload, err := json.Marshal(evt)
 	if err != nil {
+		return "", fmt.Errorf("marshal event: %w", err)
+	}
+	res, err := h.Ingest(ctx, Envelope{
+		ID:         evt.EventID,
+		Type:       TaskAssigned{}.Name(),
+		TenantID:   evt.TenantID,
+		OccurredAt: evt.OccurredAt,
+		Payload:    payload,
+	})
+	if err != nil || len(res.ItemIDs) == 0 {
 		return "", err
 	}
-	return inboxItemID, nil
+	return res.ItemIDs[0], nil
 }
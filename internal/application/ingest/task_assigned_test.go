@@ -2,6 +2,7 @@ package ingest
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -23,7 +24,7 @@ func (m fakeTxMgr) WithTx(ctx context.Context, fn func(ctx context.Context, tx p
 }
 
 type fakeInboxWriter struct{}
-func (w fakeInboxWriter) InsertInboxItem(ctx context.Context, tx ports.Tx, in ports.InsertInboxItemParams) error { return nil }
+func (w fakeInboxWriter) InsertInboxItem(ctx context.Context, tx ports.Tx, in ports.InsertInboxItemParams) (bool, error) { return true, nil }
 
 type fakeDeduper struct{}
 func (d fakeDeduper) AlreadyProcessed(ctx context.Context, tx ports.Tx, tenantID, eventID string) (bool, error) { return false, nil }
@@ -32,19 +33,26 @@ func (d fakeDeduper) MarkProcessed(ctx context.Context, tx ports.Tx, tenantID, e
 type fakeOutboxWriter struct{}
 func (w fakeOutboxWriter) InsertOutbox(ctx context.Context, tx ports.Tx, e ports.OutboxEvent) error { return nil }
 
+type fakeCounter struct{}
+func (c fakeCounter) Adjust(ctx context.Context, tx ports.Tx, tenantID, userID, itemType string, delta int) error { return nil }
+
+type fakeStream struct{}
+func (s fakeStream) Append(ctx context.Context, tx ports.Tx, e ports.StreamEvent) error { return nil }
+
 // --- tests ---
 
 func TestHandleTaskAssigned_Validation(t *testing.T) {
-	h := NewHandler(fakeTxMgr{}, fakeInboxWriter{}, fakeDeduper{}, fakeOutboxWriter{})
+	h := NewHandler(fakeTxMgr{}, fakeInboxWriter{}, fakeDeduper{}, fakeOutboxWriter{}, fakeCounter{}, fakeStream{}, DefaultRegistry())
 
 	now := time.Now().UTC()
+	tenant, user := "aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa", "bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbbbbb"
 
 	_, err := h.HandleTaskAssigned(context.Background(), TaskAssignedToUser{
 		EventID:        "",
 		OccurredAt:     now,
-		TenantID:       "t",
+		TenantID:       tenant,
 		TaskID:         "42",
-		AssigneeUserID: "u",
+		AssigneeUserID: user,
 		TaskTitle:      "X",
 		TaskURL:        "https://x",
 	})
@@ -57,7 +65,7 @@ func TestHandleTaskAssigned_Validation(t *testing.T) {
 		OccurredAt:     now,
 		TenantID:       "",
 		TaskID:         "42",
-		AssigneeUserID: "u",
+		AssigneeUserID: user,
 		TaskTitle:      "X",
 		TaskURL:        "https://x",
 	})
@@ -68,9 +76,9 @@ func TestHandleTaskAssigned_Validation(t *testing.T) {
 	_, err = h.HandleTaskAssigned(context.Background(), TaskAssignedToUser{
 		EventID:        "e",
 		OccurredAt:     now,
-		TenantID:       "t",
+		TenantID:       tenant,
 		TaskID:         "",
-		AssigneeUserID: "u",
+		AssigneeUserID: user,
 		TaskTitle:      "X",
 		TaskURL:        "https://x",
 	})
@@ -81,13 +89,22 @@ func TestHandleTaskAssigned_Validation(t *testing.T) {
 	_, err = h.HandleTaskAssigned(context.Background(), TaskAssignedToUser{
 		EventID:        "e",
 		OccurredAt:     now,
-		TenantID:       "t",
+		TenantID:       tenant,
 		TaskID:         "42",
-		AssigneeUserID: "u",
+		AssigneeUserID: user,
 		TaskTitle:      "X",
 		TaskURL:        "",
 	})
 	if err == nil {
 		t.Fatalf("expected error for missing task_url")
 	}
+	// Ids the database stores as UUIDs are checked up front.
+	for _, evt := range []TaskAssignedToUser{
+		{EventID: "e", OccurredAt: now, TenantID: "t", TaskID: "42", AssigneeUserID: user, TaskURL: "https://x"},
+		{EventID: "e", OccurredAt: now, TenantID: tenant, TaskID: "42", AssigneeUserID: "u", TaskURL: "https://x"},
+	} {
+		if _, err := h.HandleTaskAssigned(context.Background(), evt); !errors.Is(err, ErrInvalidEvent) {
+			t.Fatalf("expected ErrInvalidEvent for a non-UUID id in %+v, got %v", evt, err)
+		}
+	}
 }
@@ -0,0 +1,25 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Every runs fn right away and then every interval until ctx is cancelled.
+// Errors are logged and the job keeps going; it blocks, so start it with go.
+func Every(ctx context.Context, name string, interval time.Duration, fn func(ctx context.Context) error) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		if err := fn(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("%s: %v", name, err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
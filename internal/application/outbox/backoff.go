@@ -0,0 +1,35 @@
+package outbox
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// Backoff returns the exponential delay for the given attempt (1-based),
+// capped at max: base, 2*base, 4*base, ...
+func Backoff(attempt int, base, max time.Duration) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	d := base
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if d >= max || d <= 0 {
+			return max
+		}
+	}
+	if d > max {
+		return max
+	}
+	return d
+}
+
+// equalJitter keeps half of d and randomizes the other half, so retries from
+// many replicas don't line up while the delay still grows with each attempt.
+func equalJitter(d time.Duration) time.Duration {
+	half := d / 2
+	if half <= 0 {
+		return d
+	}
+	return half + rand.N(half+1)
+}
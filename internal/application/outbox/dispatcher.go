@@ -0,0 +1,268 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"inbox-service/internal/application/ports"
+)
+
+type Config struct {
+	BatchSize    int
+	PollInterval time.Duration
+	// ListenPollInterval replaces PollInterval while wake-ups are being
+	// delivered (see SetListening). Polling then only picks up retries that
+	// came due.
+	ListenPollInterval time.Duration
+	// Lease is how long a claimed row stays PROCESSING before another
+	// dispatcher may pick it up again (e.g. after a crash).
+	Lease       time.Duration
+	MaxAttempts int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// CircuitWait postpones rows the publisher refused because an
+	// endpoint's breaker is open.
+	CircuitWait time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		BatchSize:          50,
+		PollInterval:       time.Second,
+		ListenPollInterval: 5 * time.Second,
+		Lease:              30 * time.Second,
+		MaxAttempts:        10,
+		BaseBackoff:        time.Second,
+		MaxBackoff:         10 * time.Minute,
+		CircuitWait:        30 * time.Second,
+	}
+}
+
+// Dispatcher relays committed outbox rows to an EventPublisher.
+// Delivery is at-least-once: a row is only marked SENT after Publish returns.
+type Dispatcher struct {
+	store ports.OutboxStore
+	pub   ports.EventPublisher
+	cfg   Config
+
+	wake      chan struct{}
+	listening atomic.Bool
+
+	now    func() time.Time
+	jitter func(time.Duration) time.Duration
+}
+
+func NewDispatcher(store ports.OutboxStore, pub ports.EventPublisher, cfg Config) *Dispatcher {
+	def := DefaultConfig()
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = def.BatchSize
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = def.PollInterval
+	}
+	if cfg.ListenPollInterval <= 0 {
+		cfg.ListenPollInterval = def.ListenPollInterval
+	}
+	if cfg.Lease <= 0 {
+		cfg.Lease = def.Lease
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = def.MaxAttempts
+	}
+	if cfg.BaseBackoff <= 0 {
+		cfg.BaseBackoff = def.BaseBackoff
+	}
+	if cfg.MaxBackoff < cfg.BaseBackoff {
+		cfg.MaxBackoff = cfg.BaseBackoff
+	}
+	if cfg.CircuitWait <= 0 {
+		cfg.CircuitWait = def.CircuitWait
+	}
+	return &Dispatcher{
+		store:  store,
+		pub:    pub,
+		cfg:    cfg,
+		wake:   make(chan struct{}, 1),
+		now:    func() time.Time { return time.Now().UTC() },
+		jitter: equalJitter,
+	}
+}
+
+// Wake makes Run start a round right away, e.g. on an outbox NOTIFY. It never
+// blocks; wake-ups during a round coalesce into one more round.
+func (d *Dispatcher) Wake() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// SetListening tells Run whether Wake is being called for new rows, so it can
+// poll less often. Turning it on also wakes Run, since rows committed while
+// nobody was listening were never announced.
+func (d *Dispatcher) SetListening(on bool) {
+	d.listening.Store(on)
+	if on {
+		d.Wake()
+	}
+}
+
+// Run dispatches until ctx is cancelled. Full batches are drained back to back;
+// otherwise it waits for Wake or the poll interval between rounds.
+func (d *Dispatcher) Run(ctx context.Context) error {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		case <-d.wake:
+			timer.Stop()
+		}
+
+		n, err := d.DispatchOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("outbox dispatcher: %v", err)
+		}
+
+		if err == nil && n == d.cfg.BatchSize {
+			timer.Reset(0)
+		} else if d.listening.Load() {
+			timer.Reset(d.cfg.ListenPollInterval)
+		} else {
+			timer.Reset(d.cfg.PollInterval)
+		}
+	}
+}
+
+// DispatchOnce claims one batch and publishes it. It returns the number of
+// rows claimed.
+//
+// Each partition key's rows are published in order. When one of them is not
+// sent (retry scheduled, dead-lettered or lease lost) the rest of that key's
+// run is released unpublished, while other keys carry on.
+//
+// The whole batch is claimed under one lease, so publishing stops when it
+// runs out; rows not published by then are released, since another
+// dispatcher may own them already.
+func (d *Dispatcher) DispatchOnce(ctx context.Context) (int, error) {
+	leaseCtx, cancel := context.WithTimeout(ctx, d.cfg.Lease)
+	defer cancel()
+	now := d.now()
+	recs, err := d.store.ClaimBatch(ctx, now, now.Add(d.cfg.Lease), d.cfg.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("claim batch: %w", err)
+	}
+
+	blocked := ""
+	for i, r := range recs {
+		if i > 0 && r.PartitionKey != recs[i-1].PartitionKey {
+			blocked = ""
+		}
+		if blocked != "" || leaseCtx.Err() != nil {
+			if err := d.store.Release(ctx, r); err != nil && !errors.Is(err, ports.ErrLeaseLost) {
+				return len(recs), fmt.Errorf("release %s: %w", r.ID, err)
+			}
+			continue
+		}
+
+		sent, err := d.dispatch(ctx, leaseCtx, r)
+		if err != nil {
+			if !errors.Is(err, ports.ErrLeaseLost) {
+				return len(recs), err
+			}
+			log.Printf("outbox dispatcher: %s: %v", r.ID, err)
+		}
+		if !sent {
+			blocked = r.PartitionKey
+		}
+	}
+	return len(recs), nil
+}
+
+// dispatch publishes one row within leaseCtx and records the outcome. It
+// reports whether the row ended up SENT.
+func (d *Dispatcher) dispatch(ctx, leaseCtx context.Context, r ports.OutboxRecord) (bool, error) {
+	// A row re-claimed after an expired lease may already be over budget
+	// (e.g. it keeps crashing the process); dead-letter it without publishing.
+	if r.Attempts >= d.cfg.MaxAttempts {
+		if err := d.store.MarkFailed(ctx, r, r.Attempts, "max attempts exceeded"); err != nil {
+			return false, fmt.Errorf("mark failed %s: %w", r.ID, err)
+		}
+		logParked(r)
+		return false, nil
+	}
+
+	pubErr := d.pub.Publish(leaseCtx, MessageFromRecord(r))
+
+	if pubErr == nil {
+		if err := d.store.MarkSent(ctx, r); err != nil {
+			return false, fmt.Errorf("mark sent %s: %w", r.ID, err)
+		}
+		return true, nil
+	}
+	if errors.Is(pubErr, ports.ErrWebhookCircuitOpen) {
+		// Nothing was sent, so it doesn't use up an attempt.
+		if err := d.store.Postpone(ctx, r, d.now().Add(d.cfg.CircuitWait)); err != nil {
+			return false, fmt.Errorf("postpone %s: %w", r.ID, err)
+		}
+		return false, nil
+	}
+
+	attempts := r.Attempts + 1
+	if attempts >= d.cfg.MaxAttempts {
+		if err := d.store.MarkFailed(ctx, r, attempts, pubErr.Error()); err != nil {
+			return false, fmt.Errorf("mark failed %s: %w", r.ID, err)
+		}
+		logParked(r)
+		return false, nil
+	}
+
+	delay := d.jitter(Backoff(attempts, d.cfg.BaseBackoff, d.cfg.MaxBackoff))
+	if err := d.store.Reschedule(ctx, r, attempts, d.now().Add(delay), pubErr.Error()); err != nil {
+		return false, fmt.Errorf("reschedule %s: %w", r.ID, err)
+	}
+	return false, nil
+}
+
+// MessageFromRecord maps a claimed outbox row to the transport-neutral message.
+// The partition key becomes the message key.
+func MessageFromRecord(r ports.OutboxRecord) ports.Message {
+	key := r.PartitionKey
+	if key == "" {
+		key = r.TenantID
+	}
+	// Stored headers come first so they can't override the relay's own.
+	headers := make(map[string]string, len(r.Headers)+4)
+	for k, v := range r.Headers {
+		headers[k] = v
+	}
+	headers[ports.HeaderContentType] = "application/json"
+	headers[ports.HeaderEventType] = r.EventType
+	headers[ports.HeaderTenantID] = r.TenantID
+	if r.Seq > 0 {
+		headers[ports.HeaderSequence] = strconv.FormatInt(r.Seq, 10)
+	}
+	return ports.Message{
+		ID:        r.ID,
+		TenantID:  r.TenantID,
+		EventType: r.EventType,
+		Key:       key,
+		Headers:   headers,
+		Payload:   r.Payload,
+	}
+}
+
+// logParked reports a dead-lettered row. Its key stays parked, so later rows
+// of the key aren't published, until the row is requeued or skipped; the
+// admin API lists such keys under /v1/admin/outbox/blocked-keys.
+func logParked(r ports.OutboxRecord) {
+	log.Printf("outbox dispatcher: %s failed for good; key %q is parked behind seq %d until it is requeued or skipped", r.ID, r.PartitionKey, r.Seq)
+}
@@ -0,0 +1,338 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"inbox-service/internal/application/ports"
+)
+
+// --- fakes ---
+
+type outcome struct {
+	status    string
+	attempts  int
+	nextRunAt time.Time
+	lastErr   string
+}
+
+type fakeStore struct {
+	mu       sync.Mutex
+	batch    []ports.OutboxRecord
+	outcomes map[string]outcome
+}
+
+func newFakeStore(recs ...ports.OutboxRecord) *fakeStore {
+	return &fakeStore{batch: recs, outcomes: map[string]outcome{}}
+}
+
+func (s *fakeStore) add(recs ...ports.OutboxRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batch = append(s.batch, recs...)
+}
+
+func (s *fakeStore) ClaimBatch(ctx context.Context, now, leaseUntil time.Time, limit int) ([]ports.OutboxRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := s.batch
+	s.batch = nil
+	return out, nil
+}
+
+func (s *fakeStore) MarkSent(ctx context.Context, r ports.OutboxRecord) error {
+	s.outcomes[r.ID] = outcome{status: "SENT", attempts: r.Attempts}
+	return nil
+}
+
+func (s *fakeStore) Reschedule(ctx context.Context, r ports.OutboxRecord, attempts int, nextRunAt time.Time, lastErr string) error {
+	s.outcomes[r.ID] = outcome{status: "PENDING", attempts: attempts, nextRunAt: nextRunAt, lastErr: lastErr}
+	return nil
+}
+
+func (s *fakeStore) MarkFailed(ctx context.Context, r ports.OutboxRecord, attempts int, lastErr string) error {
+	s.outcomes[r.ID] = outcome{status: "FAILED", attempts: attempts, lastErr: lastErr}
+	return nil
+}
+
+func (s *fakeStore) Release(ctx context.Context, r ports.OutboxRecord) error {
+	s.outcomes[r.ID] = outcome{status: "RELEASED", attempts: r.Attempts}
+	return nil
+}
+
+func (s *fakeStore) Postpone(ctx context.Context, r ports.OutboxRecord, nextRunAt time.Time) error {
+	s.outcomes[r.ID] = outcome{status: "POSTPONED", attempts: r.Attempts, nextRunAt: nextRunAt}
+	return nil
+}
+
+type fakePublisher struct {
+	fail      map[string]error
+	published []string
+}
+
+func (p *fakePublisher) Publish(ctx context.Context, m ports.Message) error {
+	if err := p.fail[m.ID]; err != nil {
+		return err
+	}
+	p.published = append(p.published, m.ID)
+	return nil
+}
+
+func (p *fakePublisher) PublishBatch(ctx context.Context, ms []ports.Message) error {
+	for _, m := range ms {
+		if err := p.Publish(ctx, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// chanPublisher hands published ids to the test goroutine.
+type chanPublisher chan string
+
+func (p chanPublisher) Publish(ctx context.Context, m ports.Message) error {
+	p <- m.ID
+	return nil
+}
+
+func (p chanPublisher) PublishBatch(ctx context.Context, ms []ports.Message) error {
+	for _, m := range ms {
+		_ = p.Publish(ctx, m)
+	}
+	return nil
+}
+
+func newTestDispatcher(store ports.OutboxStore, pub ports.EventPublisher) (*Dispatcher, time.Time) {
+	now := time.Date(2026, 1, 22, 10, 0, 0, 0, time.UTC)
+	d := NewDispatcher(store, pub, Config{MaxAttempts: 3, BaseBackoff: time.Second, MaxBackoff: time.Minute})
+	d.now = func() time.Time { return now }
+	d.jitter = func(d time.Duration) time.Duration { return d }
+	return d, now
+}
+
+// --- tests ---
+
+func TestDispatcher_PublishesAndMarksSent(t *testing.T) {
+	store := newFakeStore(ports.OutboxRecord{ID: "a"}, ports.OutboxRecord{ID: "b"})
+	pub := &fakePublisher{}
+	d, _ := newTestDispatcher(store, pub)
+
+	n, err := d.DispatchOnce(context.Background())
+	if err != nil {
+		t.Fatalf("DispatchOnce: %v", err)
+	}
+	if n != 2 {
+		t.Fatalf("expected 2 claimed, got %d", n)
+	}
+	if len(pub.published) != 2 {
+		t.Fatalf("expected 2 published, got %d", len(pub.published))
+	}
+	for _, id := range []string{"a", "b"} {
+		if got := store.outcomes[id].status; got != "SENT" {
+			t.Fatalf("%s: expected SENT, got %q", id, got)
+		}
+	}
+}
+
+func TestDispatcher_ReschedulesWithBackoff(t *testing.T) {
+	store := newFakeStore(ports.OutboxRecord{ID: "a", Attempts: 1})
+	pub := &fakePublisher{fail: map[string]error{"a": errors.New("broker down")}}
+	d, now := newTestDispatcher(store, pub)
+
+	if _, err := d.DispatchOnce(context.Background()); err != nil {
+		t.Fatalf("DispatchOnce: %v", err)
+	}
+
+	got := store.outcomes["a"]
+	if got.status != "PENDING" {
+		t.Fatalf("expected PENDING, got %q", got.status)
+	}
+	if got.attempts != 2 {
+		t.Fatalf("expected attempts 2, got %d", got.attempts)
+	}
+	if want := now.Add(2 * time.Second); !got.nextRunAt.Equal(want) {
+		t.Fatalf("expected next_run_at %v, got %v", want, got.nextRunAt)
+	}
+	if got.lastErr != "broker down" {
+		t.Fatalf("expected last error to be recorded, got %q", got.lastErr)
+	}
+}
+
+func TestDispatcher_DeadLettersAfterMaxAttempts(t *testing.T) {
+	store := newFakeStore(
+		ports.OutboxRecord{ID: "a", Attempts: 2},
+		ports.OutboxRecord{ID: "b", Attempts: 3}, // re-claimed after crashes
+	)
+	pub := &fakePublisher{fail: map[string]error{"a": errors.New("boom")}}
+	d, _ := newTestDispatcher(store, pub)
+
+	if _, err := d.DispatchOnce(context.Background()); err != nil {
+		t.Fatalf("DispatchOnce: %v", err)
+	}
+
+	if got := store.outcomes["a"]; got.status != "FAILED" || got.attempts != 3 {
+		t.Fatalf("a: expected FAILED after 3 attempts, got %+v", got)
+	}
+	if got := store.outcomes["b"]; got.status != "FAILED" {
+		t.Fatalf("b: expected FAILED, got %+v", got)
+	}
+	if len(pub.published) != 0 {
+		t.Fatalf("expected nothing published, got %v", pub.published)
+	}
+}
+
+func TestDispatcher_FailureBlocksOnlyItsKey(t *testing.T) {
+	store := newFakeStore(
+		ports.OutboxRecord{ID: "a1", PartitionKey: "t/alice", Seq: 1},
+		ports.OutboxRecord{ID: "a2", PartitionKey: "t/alice", Seq: 2},
+		ports.OutboxRecord{ID: "a3", PartitionKey: "t/alice", Seq: 3},
+		ports.OutboxRecord{ID: "b1", PartitionKey: "t/bob", Seq: 1},
+		ports.OutboxRecord{ID: "b2", PartitionKey: "t/bob", Seq: 2},
+	)
+	pub := &fakePublisher{fail: map[string]error{"a2": errors.New("broker down")}}
+	d, _ := newTestDispatcher(store, pub)
+
+	if _, err := d.DispatchOnce(context.Background()); err != nil {
+		t.Fatalf("DispatchOnce: %v", err)
+	}
+
+	want := map[string]string{"a1": "SENT", "a2": "PENDING", "a3": "RELEASED", "b1": "SENT", "b2": "SENT"}
+	for id, status := range want {
+		if got := store.outcomes[id].status; got != status {
+			t.Fatalf("%s: expected %s, got %q", id, status, got)
+		}
+	}
+	if len(pub.published) != 3 || pub.published[0] != "a1" || pub.published[1] != "b1" || pub.published[2] != "b2" {
+		t.Fatalf("unexpected publish order %v", pub.published)
+	}
+}
+
+func TestDispatcher_OpenCircuitPostponesWithoutUsingAnAttempt(t *testing.T) {
+	store := newFakeStore(
+		ports.OutboxRecord{ID: "a1", PartitionKey: "t/alice", Seq: 1, Attempts: 2},
+		ports.OutboxRecord{ID: "a2", PartitionKey: "t/alice", Seq: 2},
+		ports.OutboxRecord{ID: "b1", PartitionKey: "t/bob", Seq: 1},
+	)
+	pub := &fakePublisher{fail: map[string]error{"a1": fmt.Errorf("https://x: %w", ports.ErrWebhookCircuitOpen)}}
+	d, now := newTestDispatcher(store, pub)
+
+	if _, err := d.DispatchOnce(context.Background()); err != nil {
+		t.Fatalf("DispatchOnce: %v", err)
+	}
+
+	// One attempt short of the budget, and still not dead-lettered.
+	if got := store.outcomes["a1"]; got.status != "POSTPONED" || got.attempts != 2 || !got.nextRunAt.Equal(now.Add(30*time.Second)) {
+		t.Fatalf("a1: expected POSTPONED with 2 attempts for the default circuit wait, got %+v", got)
+	}
+	if got := store.outcomes["a2"].status; got != "RELEASED" {
+		t.Fatalf("a2: expected RELEASED behind a1, got %q", got)
+	}
+	if got := store.outcomes["b1"].status; got != "SENT" {
+		t.Fatalf("b1: expected SENT, got %q", got)
+	}
+}
+
+// slowPublisher takes a while per message unless ctx ends first.
+type slowPublisher struct {
+	fakePublisher
+	delay time.Duration
+}
+
+func (p *slowPublisher) Publish(ctx context.Context, m ports.Message) error {
+	select {
+	case <-time.After(p.delay):
+		return p.fakePublisher.Publish(ctx, m)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestDispatcher_StopsPublishingWhenBatchLeaseRunsOut(t *testing.T) {
+	store := newFakeStore(ports.OutboxRecord{ID: "a"}, ports.OutboxRecord{ID: "b"}, ports.OutboxRecord{ID: "c"})
+	pub := &slowPublisher{delay: 30 * time.Millisecond}
+	d, _ := newTestDispatcher(store, pub)
+	d.cfg.Lease = 50 * time.Millisecond
+
+	if _, err := d.DispatchOnce(context.Background()); err != nil {
+		t.Fatalf("DispatchOnce: %v", err)
+	}
+
+	// b was cut off by the lease mid-publish; c was never started.
+	want := map[string]string{"a": "SENT", "b": "PENDING", "c": "RELEASED"}
+	for id, status := range want {
+		if got := store.outcomes[id].status; got != status {
+			t.Fatalf("%s: expected %s, got %q", id, status, got)
+		}
+	}
+}
+
+func TestMessageFromRecord_KeyAndSequence(t *testing.T) {
+	m := MessageFromRecord(ports.OutboxRecord{ID: "a", TenantID: "t", EventType: "InboxItemCreated", PartitionKey: "t/u", Seq: 7})
+	if m.Key != "t/u" || m.Headers[ports.HeaderSequence] != "7" {
+		t.Fatalf("expected key t/u and sequence 7, got %q / %v", m.Key, m.Headers)
+	}
+
+	m = MessageFromRecord(ports.OutboxRecord{ID: "b", TenantID: "t", EventType: "InboxItemCreated"})
+	if m.Key != "t" {
+		t.Fatalf("expected the tenant as fallback key, got %q", m.Key)
+	}
+}
+
+func TestMessageFromRecord_StoredHeaders(t *testing.T) {
+	m := MessageFromRecord(ports.OutboxRecord{ID: "a", TenantID: "t", EventType: "InboxItemCreated", Headers: map[string]string{
+		ports.HeaderCorrelationID: "corr-1",
+		ports.HeaderEventType:     "Spoofed",
+	}})
+	if m.Headers[ports.HeaderCorrelationID] != "corr-1" {
+		t.Fatalf("expected the stored correlation id, got %v", m.Headers)
+	}
+	if m.Headers[ports.HeaderEventType] != "InboxItemCreated" {
+		t.Fatalf("expected the relay's event type to win, got %q", m.Headers[ports.HeaderEventType])
+	}
+}
+
+func TestDispatcher_WakeSkipsPollInterval(t *testing.T) {
+	store := newFakeStore()
+	pub := make(chanPublisher, 1)
+	d := NewDispatcher(store, pub, Config{PollInterval: time.Hour, ListenPollInterval: time.Hour})
+	d.SetListening(true)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = d.Run(ctx) }()
+
+	// Nothing would be claimed for an hour without a wake-up.
+	store.add(ports.OutboxRecord{ID: "a"})
+	d.Wake()
+
+	select {
+	case id := <-pub:
+		if id != "a" {
+			t.Fatalf("unexpected message %s", id)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Wake did not trigger a round")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	cases := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{4, 8 * time.Second},
+		{10, time.Minute},
+		{100, time.Minute},
+	}
+	for _, c := range cases {
+		if got := Backoff(c.attempt, time.Second, time.Minute); got != c.want {
+			t.Fatalf("Backoff(%d) = %v, want %v", c.attempt, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"inbox-service/internal/application/ports"
+)
+
+type RetentionConfig struct {
+	// SentAge is how long SENT rows are kept after they were published, and
+	// FailedAge the same for FAILED and SKIPPED rows. 0 keeps them forever.
+	SentAge   time.Duration
+	FailedAge time.Duration
+	// BatchSize rows are archived and deleted per transaction, with
+	// BatchPause in between so a large backlog doesn't hog the table.
+	BatchSize  int
+	BatchPause time.Duration
+}
+
+// Retention moves old SENT, FAILED and SKIPPED outbox rows to an archive and
+// deletes them, so the outbox only holds rows that are still in flight.
+type Retention struct {
+	tx      ports.TxManager
+	store   ports.OutboxRetentionStore
+	archive ports.OutboxArchive
+	cfg     RetentionConfig
+
+	now func() time.Time
+}
+
+func NewRetention(tx ports.TxManager, store ports.OutboxRetentionStore, archive ports.OutboxArchive, cfg RetentionConfig) *Retention {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 500
+	}
+	return &Retention{
+		tx:      tx,
+		store:   store,
+		archive: archive,
+		cfg:     cfg,
+		now:     func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// Run archives everything that expired and returns the number of rows moved.
+// Only one replica runs it at a time; the others return 0 immediately.
+func (r *Retention) Run(ctx context.Context) (int, error) {
+	policies := []struct {
+		status string
+		age    time.Duration
+	}{
+		{ports.OutboxSent, r.cfg.SentAge},
+		{ports.OutboxFailed, r.cfg.FailedAge},
+		{ports.OutboxSkipped, r.cfg.FailedAge},
+	}
+
+	var total int
+	_, err := r.store.WithRetentionLock(ctx, func(ctx context.Context) error {
+		now := r.now()
+		for _, p := range policies {
+			if p.age <= 0 {
+				continue
+			}
+			// The cutoff is fixed for the run, so the loop ends even while
+			// new rows keep expiring.
+			cutoff := now.Add(-p.age)
+			for {
+				n, err := r.batch(ctx, p.status, cutoff)
+				total += n
+				if err != nil {
+					return fmt.Errorf("archive %s rows: %w", p.status, err)
+				}
+				if n < r.cfg.BatchSize {
+					break
+				}
+				if err := sleep(ctx, r.cfg.BatchPause); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	return total, err
+}
+
+func (r *Retention) batch(ctx context.Context, status string, cutoff time.Time) (int, error) {
+	var n int
+	err := r.tx.WithTx(ctx, func(ctx context.Context, tx ports.Tx) error {
+		rows, err := r.store.ExpiredOutbox(ctx, tx, status, cutoff, r.cfg.BatchSize)
+		if err != nil || len(rows) == 0 {
+			return err
+		}
+		if err := r.archive.ArchiveOutbox(ctx, tx, rows); err != nil {
+			return err
+		}
+		ids := make([]string, len(rows))
+		for i, row := range rows {
+			ids[i] = row.ID
+		}
+		n, err = r.store.DeleteOutbox(ctx, tx, ids)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
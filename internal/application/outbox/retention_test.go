@@ -0,0 +1,167 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"inbox-service/internal/application/ports"
+)
+
+// --- fakes ---
+
+type fakeTxMgr struct{}
+
+func (fakeTxMgr) WithTx(ctx context.Context, fn func(ctx context.Context, tx ports.Tx) error) error {
+	return fn(ctx, nil)
+}
+
+type fakeRetentionStore struct {
+	rows     []ports.OutboxRow
+	locked   bool
+	cutoffs  map[string]time.Time
+	batches  int
+	deleteFn func(ids []string) error
+}
+
+func (s *fakeRetentionStore) WithRetentionLock(ctx context.Context, fn func(ctx context.Context) error) (bool, error) {
+	if s.locked {
+		return false, nil
+	}
+	return true, fn(ctx)
+}
+
+func (s *fakeRetentionStore) ExpiredOutbox(ctx context.Context, tx ports.Tx, status string, olderThan time.Time, limit int) ([]ports.OutboxRow, error) {
+	s.cutoffs[status] = olderThan
+	var out []ports.OutboxRow
+	for _, r := range s.rows {
+		if r.Status == status && r.UpdatedAt.Before(olderThan) && len(out) < limit {
+			out = append(out, r)
+		}
+	}
+	if len(out) > 0 {
+		s.batches++
+	}
+	return out, nil
+}
+
+func (s *fakeRetentionStore) DeleteOutbox(ctx context.Context, tx ports.Tx, ids []string) (int, error) {
+	if s.deleteFn != nil {
+		if err := s.deleteFn(ids); err != nil {
+			return 0, err
+		}
+	}
+	gone := map[string]bool{}
+	for _, id := range ids {
+		gone[id] = true
+	}
+	kept := s.rows[:0]
+	for _, r := range s.rows {
+		if !gone[r.ID] {
+			kept = append(kept, r)
+		}
+	}
+	s.rows = kept
+	return len(ids), nil
+}
+
+type fakeArchive struct {
+	rows []ports.OutboxRow
+}
+
+func (a *fakeArchive) ArchiveOutbox(ctx context.Context, tx ports.Tx, rows []ports.OutboxRow) error {
+	a.rows = append(a.rows, rows...)
+	return nil
+}
+
+func newTestRetention(store *fakeRetentionStore, archive *fakeArchive, cfg RetentionConfig) (*Retention, time.Time) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	r := NewRetention(fakeTxMgr{}, store, archive, cfg)
+	r.now = func() time.Time { return now }
+	return r, now
+}
+
+// --- tests ---
+
+func TestRetention_ArchivesExpiredRowsInBatches(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	store := &fakeRetentionStore{cutoffs: map[string]time.Time{}}
+	for i := 0; i < 5; i++ {
+		store.rows = append(store.rows, ports.OutboxRow{ID: fmt.Sprintf("sent-%d", i), Status: ports.OutboxSent, UpdatedAt: now.AddDate(0, 0, -10)})
+	}
+	store.rows = append(store.rows,
+		ports.OutboxRow{ID: "sent-fresh", Status: ports.OutboxSent, UpdatedAt: now.Add(-time.Hour)},
+		ports.OutboxRow{ID: "failed-old", Status: ports.OutboxFailed, UpdatedAt: now.AddDate(0, 0, -40)},
+		ports.OutboxRow{ID: "failed-fresh", Status: ports.OutboxFailed, UpdatedAt: now.AddDate(0, 0, -10)},
+		ports.OutboxRow{ID: "skipped-old", Status: ports.OutboxSkipped, UpdatedAt: now.AddDate(0, 0, -40)},
+		ports.OutboxRow{ID: "pending-old", Status: ports.OutboxPending, UpdatedAt: now.AddDate(0, 0, -40)},
+	)
+	archive := &fakeArchive{}
+	r, _ := newTestRetention(store, archive, RetentionConfig{SentAge: 7 * 24 * time.Hour, FailedAge: 30 * 24 * time.Hour, BatchSize: 2})
+
+	n, err := r.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if n != 7 || len(archive.rows) != 7 {
+		t.Fatalf("expected 7 rows archived, got %d (archive has %d)", n, len(archive.rows))
+	}
+	if store.batches != 5 {
+		t.Fatalf("expected 5 batches (3 SENT, 1 FAILED, 1 SKIPPED), got %d", store.batches)
+	}
+	left := map[string]bool{}
+	for _, row := range store.rows {
+		left[row.ID] = true
+	}
+	if len(left) != 3 || !left["sent-fresh"] || !left["failed-fresh"] || !left["pending-old"] {
+		t.Fatalf("unexpected rows left %v", left)
+	}
+	if want := now.Add(-7 * 24 * time.Hour); !store.cutoffs[ports.OutboxSent].Equal(want) {
+		t.Fatalf("expected SENT cutoff %v, got %v", want, store.cutoffs[ports.OutboxSent])
+	}
+}
+
+func TestRetention_ZeroAgeKeepsRows(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	store := &fakeRetentionStore{cutoffs: map[string]time.Time{}, rows: []ports.OutboxRow{
+		{ID: "failed-old", Status: ports.OutboxFailed, UpdatedAt: now.AddDate(-1, 0, 0)},
+	}}
+	r, _ := newTestRetention(store, &fakeArchive{}, RetentionConfig{SentAge: time.Hour})
+
+	if n, err := r.Run(context.Background()); err != nil || n != 0 {
+		t.Fatalf("expected nothing archived, got %d / %v", n, err)
+	}
+	if _, ok := store.cutoffs[ports.OutboxFailed]; ok {
+		t.Fatalf("FAILED rows should not be looked at with FailedAge 0")
+	}
+}
+
+func TestRetention_SkipsWhenLockedElsewhere(t *testing.T) {
+	store := &fakeRetentionStore{locked: true, cutoffs: map[string]time.Time{}}
+	r, _ := newTestRetention(store, &fakeArchive{}, RetentionConfig{SentAge: time.Hour})
+
+	if n, err := r.Run(context.Background()); err != nil || n != 0 {
+		t.Fatalf("expected a no-op, got %d / %v", n, err)
+	}
+	if len(store.cutoffs) != 0 {
+		t.Fatalf("expected no queries without the lock, got %v", store.cutoffs)
+	}
+}
+
+func TestRetention_StopsOnDeleteError(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	store := &fakeRetentionStore{cutoffs: map[string]time.Time{}, rows: []ports.OutboxRow{
+		{ID: "sent-old", Status: ports.OutboxSent, UpdatedAt: now.AddDate(0, 0, -10)},
+	}}
+	store.deleteFn = func([]string) error { return errors.New("boom") }
+	r, _ := newTestRetention(store, &fakeArchive{}, RetentionConfig{SentAge: time.Hour, FailedAge: time.Hour})
+
+	if _, err := r.Run(context.Background()); err == nil {
+		t.Fatalf("expected the delete error")
+	}
+	if _, ok := store.cutoffs[ports.OutboxFailed]; ok {
+		t.Fatalf("expected the run to stop after the failing batch")
+	}
+}
@@ -0,0 +1,32 @@
+package ports
+
+import (
+	"context"
+	"time"
+)
+
+// APIKey is a service credential for the ingest endpoints. Only a hash of the
+// key itself is stored.
+type APIKey struct {
+	ID     string
+	Name   string
+	Prefix string // start of the key, to tell keys apart in listings
+	// TenantIDs are the tenants the key may ingest events for.
+	TenantIDs []string
+	// EventTypes are the event types it may ingest; empty means all.
+	EventTypes []string
+	CreatedAt  time.Time
+	RevokedAt  *time.Time
+}
+
+type APIKeyStore interface {
+	CreateAPIKey(ctx context.Context, tx Tx, k APIKey, hash []byte) error
+	// ListAPIKeys returns the keys issued for tenantID alone, revoked ones
+	// included, newest first.
+	ListAPIKeys(ctx context.Context, tenantID string) ([]APIKey, error)
+	// FindAPIKey returns the unrevoked key with hash, or ErrNotFound.
+	FindAPIKey(ctx context.Context, hash []byte) (APIKey, error)
+	// RevokeAPIKey returns ErrNotFound for unknown or already revoked keys
+	// and for keys not issued for tenantID alone.
+	RevokeAPIKey(ctx context.Context, tx Tx, tenantID, id string, at time.Time) error
+}
@@ -0,0 +1,32 @@
+package ports
+
+import "context"
+
+// Well-known message headers set by the outbox relay. Transports map them to
+// their native header mechanism.
+const (
+	HeaderContentType = "content-type"
+	HeaderEventType   = "event-type"
+	HeaderTenantID    = "tenant-id"
+	// HeaderSequence is the event's position within its partition key, so
+	// consumers can spot gaps and stale redeliveries.
+	HeaderSequence = "sequence"
+)
+
+// Message is an outbox event on its way to a transport.
+type Message struct {
+	ID        string // outbox id; stable across retries, usable for consumer-side dedupe
+	TenantID  string
+	EventType string
+	Key       string // partition key: messages with the same key should keep their order
+	Headers   map[string]string
+	Payload   []byte // JSON
+}
+
+// EventPublisher delivers outbox events after commit. Implementations must be
+// safe for concurrent use; delivery is at-least-once.
+type EventPublisher interface {
+	Publish(ctx context.Context, m Message) error
+	// PublishBatch publishes messages in order and stops at the first failure.
+	PublishBatch(ctx context.Context, ms []Message) error
+}
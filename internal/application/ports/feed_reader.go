@@ -12,16 +12,19 @@ type FeedItem struct {
 	Title     string
 	Body      string
 	ActionURL string
+	Version   int
 	CreatedAt time.Time
 }
 
+// FeedCursor is a position in the feed: (sort_at, id) of the last item seen.
 type FeedCursor struct {
-	CreatedAt time.Time
-	ID        string
+	SortAt time.Time
+	ID     string
 }
 
 type FeedFilter struct {
 	Status string // optional: "UNREAD", "READ", ...
+	Type   string // optional: "TASK_ASSIGNED", ...
 	Limit  int
 	Cursor *FeedCursor
 }
@@ -0,0 +1,60 @@
+package ports
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrNotFound        = errors.New("not found")
+	ErrVersionConflict = errors.New("version conflict")
+)
+
+type InboxItemState struct {
+	ID       string
+	TenantID string
+	UserID   string
+	Type     string
+	Status   string
+	// SnoozeUntil is set while the item is snoozed (hidden from the feed).
+	SnoozeUntil *time.Time
+	Version     int
+	UpdatedAt   time.Time
+}
+
+// InboxItemStore is the write-side access to existing inbox items.
+type InboxItemStore interface {
+	// GetItem returns ErrNotFound if the item doesn't exist or belongs to another user.
+	GetItem(ctx context.Context, tx Tx, tenantID, userID, itemID string) (InboxItemState, error)
+	// UpdateStatus only applies if the item is still at fromVersion; otherwise
+	// it returns ErrVersionConflict.
+	UpdateStatus(ctx context.Context, tx Tx, tenantID, itemID, status string, fromVersion int) (InboxItemState, error)
+}
+
+// BulkReadFilter selects the UNREAD items a bulk mark-as-read applies to.
+// Before is inclusive and pins the set, so items arriving mid-operation are untouched.
+type BulkReadFilter struct {
+	Type   string
+	Before FeedCursor
+}
+
+type InboxItemBulkStore interface {
+	// MarkReadBatch marks up to limit matching, non-snoozed items READ (newest
+	// first) and returns how many it updated per item type.
+	MarkReadBatch(ctx context.Context, tx Tx, tenantID, userID string, f BulkReadFilter, limit int) (map[string]int, error)
+}
+
+type WokenItem struct {
+	Item         InboxItemState
+	SnoozedUntil time.Time
+}
+
+type InboxItemSnoozeStore interface {
+	// SetSnooze sets (or clears, with nil) snooze_until, fenced on fromVersion
+	// like UpdateStatus.
+	SetSnooze(ctx context.Context, tx Tx, tenantID, itemID string, until *time.Time, fromVersion int) (InboxItemState, error)
+	// WakeDue un-snoozes up to limit items whose snooze_until <= now and bumps
+	// them to the top of the feed.
+	WakeDue(ctx context.Context, tx Tx, now time.Time, limit int) ([]WokenItem, error)
+}
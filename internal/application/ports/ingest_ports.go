@@ -3,7 +3,8 @@ package ports
 import "context"
 
 type InboxWriter interface {
-	InsertInboxItem(ctx context.Context, tx Tx, in InsertInboxItemParams) error
+	// InsertInboxItem reports false if an item with the same dedupe key already exists.
+	InsertInboxItem(ctx context.Context, tx Tx, in InsertInboxItemParams) (bool, error)
 }
 
 type InsertInboxItemParams struct {
@@ -32,5 +33,17 @@ type OutboxEvent struct {
 	ID        string
 	TenantID  string
 	EventType string
-	Payload   []byte // JSON
+	// PartitionKey orders events: rows with the same key get increasing
+	// sequence numbers at commit and are published in that order. Empty
+	// means the tenant id.
+	PartitionKey string
+	// Headers are published with the event; see MessageMeta.
+	Headers map[string]string
+	Payload []byte // JSON
+}
+
+// UserPartitionKey keys the events of one recipient, so everything that
+// happens to a user's inbox is published in order.
+func UserPartitionKey(tenantID, userID string) string {
+	return tenantID + "/" + userID
 }
@@ -0,0 +1,83 @@
+package ports
+
+import (
+	"context"
+	"strings"
+)
+
+// Tracing headers carried from the inbound request or message to every
+// outbox event it causes.
+const (
+	HeaderCorrelationID = "correlation-id"
+	HeaderCausationID   = "causation-id"
+	HeaderTraceparent   = "traceparent"
+)
+
+// MessageMeta is the tracing context of the request or message being handled.
+// CorrelationID ties together everything caused by one original event,
+// CausationID is the id of the event that directly caused this one and
+// Traceparent is the W3C trace context.
+type MessageMeta struct {
+	CorrelationID string
+	CausationID   string
+	Traceparent   string
+}
+
+// MessageMetaFromHeaders reads the tracing headers through get, which looks a
+// header up by its lower-case name. An invalid traceparent is dropped, as the
+// W3C spec asks.
+func MessageMetaFromHeaders(get func(name string) string) MessageMeta {
+	m := MessageMeta{
+		CorrelationID: strings.TrimSpace(get(HeaderCorrelationID)),
+		CausationID:   strings.TrimSpace(get(HeaderCausationID)),
+		Traceparent:   strings.ToLower(strings.TrimSpace(get(HeaderTraceparent))),
+	}
+	if !validTraceparent(m.Traceparent) {
+		m.Traceparent = ""
+	}
+	return m
+}
+
+// Headers returns the non-empty fields as message headers.
+func (m MessageMeta) Headers() map[string]string {
+	h := map[string]string{}
+	if m.CorrelationID != "" {
+		h[HeaderCorrelationID] = m.CorrelationID
+	}
+	if m.CausationID != "" {
+		h[HeaderCausationID] = m.CausationID
+	}
+	if m.Traceparent != "" {
+		h[HeaderTraceparent] = m.Traceparent
+	}
+	return h
+}
+
+type messageMetaKey struct{}
+
+func WithMessageMeta(ctx context.Context, m MessageMeta) context.Context {
+	return context.WithValue(ctx, messageMetaKey{}, m)
+}
+
+// MessageMetaFrom returns the zero MessageMeta if ctx carries none.
+func MessageMetaFrom(ctx context.Context) MessageMeta {
+	m, _ := ctx.Value(messageMetaKey{}).(MessageMeta)
+	return m
+}
+
+// validTraceparent checks the version-00 layout:
+// 00-<32 hex trace id>-<16 hex parent id>-<2 hex flags>, ids not all zero.
+func validTraceparent(v string) bool {
+	parts := strings.Split(v, "-")
+	if len(parts) != 4 || parts[0] != "00" || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return false
+	}
+	for _, p := range parts[1:] {
+		for _, c := range p {
+			if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+				return false
+			}
+		}
+	}
+	return strings.Trim(parts[1], "0") != "" && strings.Trim(parts[2], "0") != ""
+}
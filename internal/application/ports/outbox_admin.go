@@ -0,0 +1,102 @@
+package ports
+
+import (
+	"context"
+	"time"
+)
+
+// Outbox statuses. SKIPPED rows were taken out of the relay by an operator.
+const (
+	OutboxPending    = "PENDING"
+	OutboxProcessing = "PROCESSING"
+	OutboxSent       = "SENT"
+	OutboxFailed     = "FAILED"
+	OutboxSkipped    = "SKIPPED"
+)
+
+// OutboxFilter selects outbox rows for the admin API. Empty fields match
+// everything.
+type OutboxFilter struct {
+	IDs       []string
+	Status    string
+	TenantID  string
+	EventType string
+	// CreatedBefore/CreatedAfter bound the row's age.
+	CreatedBefore *time.Time
+	CreatedAfter  *time.Time
+}
+
+// OutboxPosition is a keyset position in the admin listing (newest first).
+type OutboxPosition struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+type OutboxRow struct {
+	ID           string
+	TenantID     string
+	EventType    string
+	PartitionKey string
+	Seq          int64
+	Status       string
+	Attempts     int
+	NextRunAt    time.Time
+	LastError    string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Version      int
+	Headers      map[string]string
+	Payload      []byte // JSON; not set by ListOutbox
+}
+
+// BlockedKey is a partition key parked behind a FAILED row: its later rows
+// wait until that row is requeued or skipped.
+type BlockedKey struct {
+	PartitionKey string
+	TenantID     string
+	// FailedID and FailedSeq name the oldest FAILED row of the key.
+	FailedID  string
+	FailedSeq int64
+	FailedAt  time.Time
+	LastError string
+	// Waiting counts the PENDING rows held back behind it.
+	Waiting int
+}
+
+// OutboxAttempt is one recorded publish attempt.
+type OutboxAttempt struct {
+	Attempt   int
+	Outcome   string // SENT | RETRY | FAILED
+	Error     string
+	CreatedAt time.Time
+}
+
+// OutboxAdminStore backs the operator endpoints. Mutations run in the caller's
+// transaction so they are audited atomically.
+type OutboxAdminStore interface {
+	// ListOutbox returns rows newest first, without payloads.
+	ListOutbox(ctx context.Context, f OutboxFilter, after *OutboxPosition, limit int) ([]OutboxRow, error)
+	// GetOutbox returns ErrNotFound for unknown ids.
+	GetOutbox(ctx context.Context, id string) (OutboxRow, []OutboxAttempt, error)
+	// ListBlockedKeys returns parked keys, longest parked first.
+	ListBlockedKeys(ctx context.Context, limit int) ([]BlockedKey, error)
+
+	// RequeueOutbox resets matching FAILED rows to PENDING with a fresh budget.
+	RequeueOutbox(ctx context.Context, tx Tx, f OutboxFilter, now time.Time) (int, error)
+	// SkipOutbox marks matching PENDING or FAILED rows SKIPPED.
+	SkipOutbox(ctx context.Context, tx Tx, f OutboxFilter, now time.Time) (int, error)
+	// PurgeSentOutbox deletes SENT rows last updated before olderThan.
+	PurgeSentOutbox(ctx context.Context, tx Tx, olderThan time.Time) (int, error)
+}
+
+// AuditEntry records an operator action.
+type AuditEntry struct {
+	Actor    string
+	Action   string
+	Params   map[string]any
+	Affected int
+}
+
+type AuditLog interface {
+	Record(ctx context.Context, tx Tx, e AuditEntry) error
+}
@@ -0,0 +1,45 @@
+package ports
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrLeaseLost is returned when a claimed outbox row was re-claimed by another
+// dispatcher (its lease expired) before we could record the outcome.
+var ErrLeaseLost = errors.New("outbox lease lost")
+
+type OutboxRecord struct {
+	ID           string
+	TenantID     string
+	EventType    string
+	PartitionKey string
+	Seq          int64 // position within PartitionKey, from 1
+	Headers      map[string]string
+	Payload      []byte // JSON
+	Attempts     int
+	Version      int
+	CreatedAt    time.Time
+}
+
+// OutboxStore is the relay side of the outbox: claim due rows and record the
+// outcome of publishing them. Rows are leased, so several dispatchers can run
+// against the same table.
+type OutboxStore interface {
+	// ClaimBatch moves up to limit due rows (PENDING, or PROCESSING with an
+	// expired lease) to PROCESSING with a lease until leaseUntil. Only the
+	// head of each partition key is eligible, together with the due rows
+	// right behind it: a key whose oldest unfinished row is leased, backing
+	// off or FAILED is skipped. Rows come back ordered by key and Seq.
+	ClaimBatch(ctx context.Context, now, leaseUntil time.Time, limit int) ([]OutboxRecord, error)
+	MarkSent(ctx context.Context, r OutboxRecord) error
+	Reschedule(ctx context.Context, r OutboxRecord, attempts int, nextRunAt time.Time, lastErr string) error
+	MarkFailed(ctx context.Context, r OutboxRecord, attempts int, lastErr string) error
+	// Release hands a claimed row back unpublished, without counting an
+	// attempt; used for the rest of a key's run after an earlier row failed.
+	Release(ctx context.Context, r OutboxRecord) error
+	// Postpone is Release with the row due again at nextRunAt; used when the
+	// publisher made no attempt (ErrWebhookCircuitOpen).
+	Postpone(ctx context.Context, r OutboxRecord, nextRunAt time.Time) error
+}
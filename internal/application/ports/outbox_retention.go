@@ -0,0 +1,27 @@
+package ports
+
+import (
+	"context"
+	"time"
+)
+
+// OutboxRetentionStore removes outbox rows that reached a final status.
+type OutboxRetentionStore interface {
+	// WithRetentionLock runs fn while holding a cluster-wide lock, so only one
+	// replica does retention at a time. It returns false without calling fn
+	// when another replica holds the lock.
+	WithRetentionLock(ctx context.Context, fn func(ctx context.Context) error) (bool, error)
+	// ExpiredOutbox locks up to limit rows with the given status, last updated
+	// before olderThan, oldest first and with payloads.
+	ExpiredOutbox(ctx context.Context, tx Tx, status string, olderThan time.Time, limit int) ([]OutboxRow, error)
+	// DeleteOutbox deletes rows (and their attempt history) by id.
+	DeleteOutbox(ctx context.Context, tx Tx, ids []string) (int, error)
+}
+
+// OutboxArchive keeps a copy of outbox rows before they are deleted. It is
+// called inside the deleting transaction; an archive outside the database must
+// have made the rows durable when it returns, and may see them again if the
+// transaction then fails.
+type OutboxArchive interface {
+	ArchiveOutbox(ctx context.Context, tx Tx, rows []OutboxRow) error
+}
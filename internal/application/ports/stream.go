@@ -0,0 +1,41 @@
+package ports
+
+import (
+	"context"
+	"time"
+)
+
+// Stream event kinds pushed to live clients.
+const (
+	StreamItemCreated       = "item.created"
+	StreamItemStatusChanged = "item.status_changed"
+	StreamItemSnoozed       = "item.snoozed"
+	StreamItemUnsnoozed     = "item.unsnoozed"
+	StreamItemsMarkedRead   = "items.marked_read"
+)
+
+type StreamEvent struct {
+	TenantID string
+	UserID   string
+	Kind     string
+	Data     []byte // JSON
+}
+
+type StoredStreamEvent struct {
+	Seq       int64
+	Kind      string
+	Data      []byte
+	CreatedAt time.Time
+}
+
+// StreamWriter records a per-user change for live clients. It is called inside
+// the write transaction; subscribers are woken only after commit.
+type StreamWriter interface {
+	Append(ctx context.Context, tx Tx, e StreamEvent) error
+}
+
+type StreamReader interface {
+	// EventsSince returns up to limit events with seq > afterSeq, oldest first.
+	EventsSince(ctx context.Context, tenantID, userID string, afterSeq int64, limit int) ([]StoredStreamEvent, error)
+	LatestSeq(ctx context.Context, tenantID, userID string) (int64, error)
+}
@@ -0,0 +1,19 @@
+package ports
+
+import "context"
+
+// UnreadCounter maintains per-(tenant, user, type) unread counts. An item counts
+// while it is UNREAD and not snoozed. Writers call Adjust in the same
+// transaction as the item change.
+type UnreadCounter interface {
+	Adjust(ctx context.Context, tx Tx, tenantID, userID, itemType string, delta int) error
+}
+
+type UnreadCount struct {
+	Total  int
+	ByType map[string]int
+}
+
+type UnreadCountReader interface {
+	GetUnreadCount(ctx context.Context, tenantID, userID string) (UnreadCount, error)
+}
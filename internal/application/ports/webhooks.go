@@ -0,0 +1,109 @@
+package ports
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrWebhookCircuitOpen is returned by a WebhookSender that refused to call an
+// endpoint because it has been failing; no request was made.
+var ErrWebhookCircuitOpen = errors.New("webhook circuit open")
+
+// Webhook delivery statuses.
+const (
+	DeliveryPending    = "PENDING"
+	DeliveryProcessing = "PROCESSING"
+	DeliverySent       = "SENT"
+	DeliveryFailed     = "FAILED"
+)
+
+// WebhookSubscription is a tenant's endpoint for outbox events.
+type WebhookSubscription struct {
+	ID         string
+	TenantID   string
+	URL        string
+	EventTypes []string // empty means all
+	Secret     string
+	// PreviousSecret still signs deliveries until PreviousSecretExpiresAt, so
+	// receivers can switch over after a rotation.
+	PreviousSecret          string
+	PreviousSecretExpiresAt *time.Time
+	Enabled                 bool
+	CreatedAt               time.Time
+	UpdatedAt               time.Time
+}
+
+// WebhookSubscriptionStore is scoped by tenant; other tenants' ids are
+// ErrNotFound.
+type WebhookSubscriptionStore interface {
+	CreateSubscription(ctx context.Context, s WebhookSubscription) error
+	ListSubscriptions(ctx context.Context, tenantID string) ([]WebhookSubscription, error)
+	GetSubscription(ctx context.Context, tenantID, id string) (WebhookSubscription, error)
+	// UpdateSubscription saves URL, EventTypes, Enabled and the secrets.
+	UpdateSubscription(ctx context.Context, s WebhookSubscription) error
+	DeleteSubscription(ctx context.Context, tenantID, id string) error
+}
+
+// WebhookDelivery is one outbox event on its way to one subscription.
+type WebhookDelivery struct {
+	ID             string
+	SubscriptionID string
+	TenantID       string
+	OutboxID       string
+	EventType      string
+	Status         string
+	Attempts       int
+	NextRunAt      time.Time
+	LastError      string
+	LastStatusCode int
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	Version        int
+}
+
+// WebhookTarget is a claimed delivery with what is needed to send it.
+type WebhookTarget struct {
+	Delivery WebhookDelivery
+	URL      string
+	Secrets  []string // current secret first
+	Message  Message
+}
+
+// WebhookAttempt is one recorded HTTP call for a delivery.
+type WebhookAttempt struct {
+	Attempt    int
+	StatusCode int // 0 if no response
+	Error      string
+	Duration   time.Duration
+	CreatedAt  time.Time
+}
+
+// WebhookDeliveryStore tracks deliveries independently per subscription, with
+// the same leasing as the outbox relay.
+type WebhookDeliveryStore interface {
+	// EnqueueDeliveries creates a PENDING delivery of m for every enabled
+	// subscription of its tenant that wants its event type. It is idempotent
+	// per (subscription, outbox id) and returns the number created.
+	EnqueueDeliveries(ctx context.Context, m Message) (int, error)
+	// ClaimDeliveries leases up to limit due deliveries until leaseUntil.
+	ClaimDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]WebhookTarget, error)
+	// RecordAttempt stores the outcome of one call and moves the delivery to
+	// status, fenced on its version; ErrLeaseLost if it was re-claimed.
+	RecordAttempt(ctx context.Context, d WebhookDelivery, a WebhookAttempt, status string, nextRunAt time.Time) error
+	// Postpone reschedules a claimed delivery without counting an attempt.
+	Postpone(ctx context.Context, d WebhookDelivery, nextRunAt time.Time) error
+
+	// ListDeliveries returns a subscription's deliveries, newest first.
+	ListDeliveries(ctx context.Context, tenantID, subscriptionID string, limit int) ([]WebhookDelivery, error)
+	GetDelivery(ctx context.Context, tenantID, id string) (WebhookDelivery, []WebhookAttempt, error)
+	// ReplayDelivery makes a delivery PENDING again with a fresh attempt
+	// budget, whatever its status.
+	ReplayDelivery(ctx context.Context, tenantID, id string, now time.Time) error
+}
+
+// WebhookSender makes one signed HTTP call. It returns the response status
+// code (0 if there was none) and an error unless the endpoint answered 2xx.
+type WebhookSender interface {
+	Send(ctx context.Context, t WebhookTarget) (int, error)
+}
@@ -11,25 +11,60 @@ type FeedQuery struct {
 	TenantID string
 	UserID   string
 	Status   string
+	Type     string
 	Limit    int
-	Cursor   *ports.FeedCursor
+	// CursorToken is the opaque next_page_token of the previous page.
+	CursorToken string
+	// Cursor is the legacy raw position (cursor_created_at / cursor_id).
+	// Deprecated: use CursorToken.
+	Cursor *ports.FeedCursor
+}
+
+type FeedResult struct {
+	Items []ports.FeedItem
+	// NextCursor is empty on the last page.
+	NextCursor string
+	// NextPosition backs the deprecated raw cursor fields.
+	NextPosition *ports.FeedCursor
 }
 
 type FeedHandler struct {
-	reader ports.FeedReader
+	reader  ports.FeedReader
+	cursors *CursorCodec
 }
 
-func NewFeedHandler(reader ports.FeedReader) *FeedHandler {
-	return &FeedHandler{reader: reader}
+func NewFeedHandler(reader ports.FeedReader, cursors *CursorCodec) *FeedHandler {
+	return &FeedHandler{reader: reader, cursors: cursors}
 }
 
-func (h *FeedHandler) Handle(ctx context.Context, q FeedQuery) (ports.FeedPage, error) {
+func (h *FeedHandler) Handle(ctx context.Context, q FeedQuery) (FeedResult, error) {
 	if q.TenantID == "" || q.UserID == "" {
-		return ports.FeedPage{}, fmt.Errorf("tenant_id and user_id are required")
+		return FeedResult{}, fmt.Errorf("tenant_id and user_id are required")
+	}
+
+	scope := CursorScope{TenantID: q.TenantID, UserID: q.UserID, Status: q.Status, Type: q.Type}
+	cursor := q.Cursor
+	if q.CursorToken != "" {
+		c, err := h.cursors.Decode(scope, q.CursorToken)
+		if err != nil {
+			return FeedResult{}, err
+		}
+		cursor = &c
 	}
-	return h.reader.GetFeed(ctx, q.TenantID, q.UserID, ports.FeedFilter{
+
+	page, err := h.reader.GetFeed(ctx, q.TenantID, q.UserID, ports.FeedFilter{
 		Status: q.Status,
+		Type:   q.Type,
 		Limit:  q.Limit,
-		Cursor: q.Cursor,
+		Cursor: cursor,
 	})
+	if err != nil {
+		return FeedResult{}, err
+	}
+
+	res := FeedResult{Items: page.Items, NextPosition: page.NextCursor}
+	if page.NextCursor != nil {
+		res.NextCursor = h.cursors.Encode(scope, *page.NextCursor)
+	}
+	return res, nil
 }
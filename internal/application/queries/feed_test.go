@@ -2,7 +2,9 @@ package queries
 
 import (
 	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"inbox-service/internal/application/ports"
 )
@@ -10,14 +12,19 @@ import (
 type fakeFeedReader struct {
 	page ports.FeedPage
 	err  error
+	// got records the filter of the last call.
+	got *ports.FeedFilter
 }
 
 func (f fakeFeedReader) GetFeed(ctx context.Context, tenantID, userID string, flt ports.FeedFilter) (ports.FeedPage, error) {
+	if f.got != nil {
+		*f.got = flt
+	}
 	return f.page, f.err
 }
 
 func TestFeedHandler_RequiresTenantAndUser(t *testing.T) {
-	h := NewFeedHandler(fakeFeedReader{})
+	h := NewFeedHandler(fakeFeedReader{}, NewCursorCodec([]byte("k")))
 
 	_, err := h.Handle(context.Background(), FeedQuery{TenantID: "", UserID: "u"})
 	if err == nil {
@@ -29,3 +36,49 @@ func TestFeedHandler_RequiresTenantAndUser(t *testing.T) {
 		t.Fatalf("expected error for missing user_id")
 	}
 }
+
+func TestFeedHandler_CursorRoundTrip(t *testing.T) {
+	pos := ports.FeedCursor{SortAt: time.Date(2026, 1, 22, 10, 0, 0, 123, time.UTC), ID: "i-9"}
+	var got ports.FeedFilter
+	h := NewFeedHandler(fakeFeedReader{
+		page: ports.FeedPage{Items: []ports.FeedItem{{ID: "i-9"}}, NextCursor: &pos},
+		got:  &got,
+	}, NewCursorCodec([]byte("k")))
+
+	q := FeedQuery{TenantID: "t", UserID: "u", Status: "UNREAD"}
+	page1, err := h.Handle(context.Background(), q)
+	if err != nil {
+		t.Fatalf("page 1: %v", err)
+	}
+	if page1.NextCursor == "" {
+		t.Fatalf("expected a next cursor")
+	}
+
+	q.CursorToken = page1.NextCursor
+	if _, err := h.Handle(context.Background(), q); err != nil {
+		t.Fatalf("page 2: %v", err)
+	}
+	if got.Cursor == nil || !got.Cursor.SortAt.Equal(pos.SortAt) || got.Cursor.ID != pos.ID {
+		t.Fatalf("expected cursor %+v, got %+v", pos, got.Cursor)
+	}
+}
+
+func TestFeedHandler_RejectsForeignCursors(t *testing.T) {
+	codec := NewCursorCodec([]byte("k"))
+	h := NewFeedHandler(fakeFeedReader{}, codec)
+	token := codec.Encode(CursorScope{TenantID: "t", UserID: "u", Status: "UNREAD"}, ports.FeedCursor{SortAt: time.Now(), ID: "i"})
+
+	cases := map[string]FeedQuery{
+		"other user":   {TenantID: "t", UserID: "someone-else", Status: "UNREAD", CursorToken: token},
+		"other filter": {TenantID: "t", UserID: "u", Status: "READ", CursorToken: token},
+		"tampered":     {TenantID: "t", UserID: "u", Status: "UNREAD", CursorToken: "x" + token},
+		"other secret": {TenantID: "t", UserID: "u", Status: "UNREAD", CursorToken: NewCursorCodec([]byte("other")).Encode(CursorScope{TenantID: "t", UserID: "u", Status: "UNREAD"}, ports.FeedCursor{ID: "i"})},
+	}
+	for name, q := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := h.Handle(context.Background(), q); !errors.Is(err, ErrInvalidCursor) {
+				t.Fatalf("expected ErrInvalidCursor, got %v", err)
+			}
+		})
+	}
+}
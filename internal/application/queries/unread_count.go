@@ -0,0 +1,23 @@
+package queries
+
+import (
+	"context"
+	"fmt"
+
+	"inbox-service/internal/application/ports"
+)
+
+type UnreadCountHandler struct {
+	reader ports.UnreadCountReader
+}
+
+func NewUnreadCountHandler(reader ports.UnreadCountReader) *UnreadCountHandler {
+	return &UnreadCountHandler{reader: reader}
+}
+
+func (h *UnreadCountHandler) Handle(ctx context.Context, tenantID, userID string) (ports.UnreadCount, error) {
+	if tenantID == "" || userID == "" {
+		return ports.UnreadCount{}, fmt.Errorf("tenant_id and user_id are required")
+	}
+	return h.reader.GetUnreadCount(ctx, tenantID, userID)
+}
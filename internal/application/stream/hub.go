@@ -0,0 +1,58 @@
+package stream
+
+import "sync"
+
+// Hub fans change notifications out to the live connections of one process.
+// It carries no data: a signal only tells a subscriber to re-read its events,
+// so dropped or coalesced signals are harmless.
+type Hub struct {
+	mu   sync.Mutex
+	subs map[string]map[chan struct{}]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{subs: map[string]map[chan struct{}]struct{}{}}
+}
+
+// Subscribe returns a signal channel for the user and a func to unsubscribe.
+func (h *Hub) Subscribe(tenantID, userID string) (<-chan struct{}, func()) {
+	k := key(tenantID, userID)
+	ch := make(chan struct{}, 1)
+
+	h.mu.Lock()
+	if h.subs[k] == nil {
+		h.subs[k] = map[chan struct{}]struct{}{}
+	}
+	h.subs[k][ch] = struct{}{}
+	h.mu.Unlock()
+
+	return ch, func() {
+		h.mu.Lock()
+		delete(h.subs[k], ch)
+		if len(h.subs[k]) == 0 {
+			delete(h.subs, k)
+		}
+		h.mu.Unlock()
+	}
+}
+
+// Notify wakes every subscriber of the user without blocking.
+func (h *Hub) Notify(tenantID, userID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs[key(tenantID, userID)] {
+		select {
+		case ch <- struct{}{}:
+		default: // a wake-up is already pending
+		}
+	}
+}
+
+// Subscribers reports how many connections are open for the user.
+func (h *Hub) Subscribers(tenantID, userID string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs[key(tenantID, userID)])
+}
+
+func key(tenantID, userID string) string { return tenantID + "/" + userID }
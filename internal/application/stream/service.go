@@ -0,0 +1,136 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"inbox-service/internal/application/ports"
+)
+
+// EventUnreadCount is sent on connect and whenever the badge changes. It has
+// no id: after a reconnect the client gets a fresh count anyway.
+const EventUnreadCount = "unread_count"
+
+// Frame is one server-sent event. A frame with only Comment set is a heartbeat.
+type Frame struct {
+	ID      string
+	Event   string
+	Data    []byte
+	Comment string
+}
+
+// Service drives one live connection: it replays events after the client's
+// Last-Event-ID, then pushes new ones as the hub signals them.
+type Service struct {
+	Events ports.StreamReader
+	Unread ports.UnreadCountReader
+	Hub    *Hub
+	// Heartbeat keeps proxies from closing idle connections. Each beat also
+	// re-reads the log, which covers notifications lost while the listener
+	// was reconnecting.
+	Heartbeat time.Duration
+	BatchSize int
+}
+
+func NewService(events ports.StreamReader, unread ports.UnreadCountReader, hub *Hub) *Service {
+	return &Service{Events: events, Unread: unread, Hub: hub, Heartbeat: 15 * time.Second, BatchSize: 100}
+}
+
+// Run blocks until ctx is done or send fails. lastEventID nil means "start
+// from now" without replay.
+func (s *Service) Run(ctx context.Context, tenantID, userID string, lastEventID *int64, send func(Frame) error) error {
+	if tenantID == "" || userID == "" {
+		return fmt.Errorf("tenant_id and user_id are required")
+	}
+
+	// Subscribe before reading so nothing committed in between is missed.
+	wake, unsubscribe := s.Hub.Subscribe(tenantID, userID)
+	defer unsubscribe()
+
+	var last int64
+	if lastEventID != nil {
+		last = *lastEventID
+	} else {
+		seq, err := s.Events.LatestSeq(ctx, tenantID, userID)
+		if err != nil {
+			return err
+		}
+		last = seq
+	}
+
+	unread := -1
+	pushUnread := func() error {
+		cnt, err := s.Unread.GetUnreadCount(ctx, tenantID, userID)
+		if err != nil {
+			return err
+		}
+		if cnt.Total == unread {
+			return nil
+		}
+		unread = cnt.Total
+		data, _ := json.Marshal(map[string]any{"unread": cnt.Total, "by_type": cnt.ByType})
+		return send(Frame{Event: EventUnreadCount, Data: data})
+	}
+
+	if err := pushUnread(); err != nil {
+		return err
+	}
+
+	heartbeat := s.Heartbeat
+	if heartbeat <= 0 {
+		heartbeat = 15 * time.Second
+	}
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+
+	for {
+		sent, err := s.drain(ctx, tenantID, userID, &last, send)
+		if err != nil {
+			return err
+		}
+		if sent > 0 {
+			if err := pushUnread(); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-wake:
+		case <-ticker.C:
+			if err := send(Frame{Comment: "ping"}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// drain sends every event after *last and advances it.
+func (s *Service) drain(ctx context.Context, tenantID, userID string, last *int64, send func(Frame) error) (int, error) {
+	batch := s.BatchSize
+	if batch <= 0 {
+		batch = 100
+	}
+
+	sent := 0
+	for {
+		events, err := s.Events.EventsSince(ctx, tenantID, userID, *last, batch)
+		if err != nil {
+			return sent, err
+		}
+		for _, e := range events {
+			if err := send(Frame{ID: strconv.FormatInt(e.Seq, 10), Event: e.Kind, Data: e.Data}); err != nil {
+				return sent, err
+			}
+			*last = e.Seq
+			sent++
+		}
+		if len(events) < batch {
+			return sent, nil
+		}
+	}
+}
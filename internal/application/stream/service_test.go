@@ -0,0 +1,186 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"inbox-service/internal/application/ports"
+)
+
+// --- fakes ---
+
+type fakeEvents struct {
+	mu     sync.Mutex
+	events []ports.StoredStreamEvent
+}
+
+func (f *fakeEvents) add(kind string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.events = append(f.events, ports.StoredStreamEvent{Seq: int64(len(f.events) + 1), Kind: kind, Data: []byte(`{}`)})
+}
+
+func (f *fakeEvents) EventsSince(ctx context.Context, tenantID, userID string, afterSeq int64, limit int) ([]ports.StoredStreamEvent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []ports.StoredStreamEvent
+	for _, e := range f.events {
+		if e.Seq > afterSeq && len(out) < limit {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeEvents) LatestSeq(ctx context.Context, tenantID, userID string) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return int64(len(f.events)), nil
+}
+
+type fakeUnread struct {
+	mu sync.Mutex
+	n  int
+}
+
+func (f *fakeUnread) set(n int) { f.mu.Lock(); f.n = n; f.mu.Unlock() }
+
+func (f *fakeUnread) GetUnreadCount(ctx context.Context, tenantID, userID string) (ports.UnreadCount, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return ports.UnreadCount{Total: f.n}, nil
+}
+
+// run starts the service in the background and collects frames.
+func run(t *testing.T, s *Service, lastEventID *int64) (<-chan Frame, context.CancelFunc) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	frames := make(chan Frame, 100)
+	go func() {
+		_ = s.Run(ctx, "t", "u", lastEventID, func(f Frame) error {
+			frames <- f
+			return nil
+		})
+	}()
+	return frames, cancel
+}
+
+func next(t *testing.T, frames <-chan Frame) Frame {
+	t.Helper()
+	select {
+	case f := <-frames:
+		return f
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for a frame")
+		return Frame{}
+	}
+}
+
+// --- tests ---
+
+func TestRun_ReplaysAfterLastEventID(t *testing.T) {
+	events := &fakeEvents{}
+	events.add(ports.StreamItemCreated)
+	events.add(ports.StreamItemStatusChanged)
+	events.add(ports.StreamItemSnoozed)
+
+	s := NewService(events, &fakeUnread{n: 2}, NewHub())
+	last := int64(1)
+	frames, cancel := run(t, s, &last)
+	defer cancel()
+
+	if f := next(t, frames); f.Event != EventUnreadCount {
+		t.Fatalf("expected unread_count first, got %+v", f)
+	}
+	if f := next(t, frames); f.ID != "2" || f.Event != ports.StreamItemStatusChanged {
+		t.Fatalf("expected event 2, got %+v", f)
+	}
+	if f := next(t, frames); f.ID != "3" || f.Event != ports.StreamItemSnoozed {
+		t.Fatalf("expected event 3, got %+v", f)
+	}
+}
+
+func TestRun_PushesOnNotifyAndSkipsHistoryWithoutLastEventID(t *testing.T) {
+	events := &fakeEvents{}
+	events.add(ports.StreamItemCreated) // before connect: not replayed
+	unread := &fakeUnread{n: 1}
+	hub := NewHub()
+
+	s := NewService(events, unread, hub)
+	frames, cancel := run(t, s, nil)
+	defer cancel()
+
+	if f := next(t, frames); f.Event != EventUnreadCount {
+		t.Fatalf("expected unread_count first, got %+v", f)
+	}
+
+	// Wait until the connection is subscribed before committing the change.
+	for hub.Subscribers("t", "u") == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	events.add(ports.StreamItemCreated)
+	unread.set(2)
+	hub.Notify("t", "u")
+
+	if f := next(t, frames); f.ID != "2" || f.Event != ports.StreamItemCreated {
+		t.Fatalf("expected event 2, got %+v", f)
+	}
+	if f := next(t, frames); f.Event != EventUnreadCount || string(f.Data) != `{"by_type":null,"unread":2}` {
+		t.Fatalf("expected updated unread_count, got %+v (%s)", f, f.Data)
+	}
+}
+
+func TestRun_HeartbeatCatchesMissedNotifications(t *testing.T) {
+	events := &fakeEvents{}
+	s := NewService(events, &fakeUnread{}, NewHub())
+	s.Heartbeat = 10 * time.Millisecond
+	zero := int64(0)
+	frames, cancel := run(t, s, &zero)
+	defer cancel()
+
+	next(t, frames) // unread_count
+	events.add(ports.StreamItemCreated)
+
+	for {
+		f := next(t, frames)
+		if f.Comment != "" {
+			continue
+		}
+		if f.ID != "1" {
+			t.Fatalf("expected event 1 after a heartbeat, got %+v", f)
+		}
+		return
+	}
+}
+
+func TestRun_StopsWhenSendFails(t *testing.T) {
+	s := NewService(&fakeEvents{}, &fakeUnread{}, NewHub())
+	errGone := errors.New("client gone")
+	err := s.Run(context.Background(), "t", "u", nil, func(Frame) error { return errGone })
+	if !errors.Is(err, errGone) {
+		t.Fatalf("expected send error, got %v", err)
+	}
+}
+
+func TestHub_UnsubscribeAndCoalesce(t *testing.T) {
+	hub := NewHub()
+	ch, unsubscribe := hub.Subscribe("t", "u")
+
+	hub.Notify("t", "u")
+	hub.Notify("t", "u") // coalesced, must not block
+	<-ch
+	select {
+	case <-ch:
+		t.Fatalf("expected a single pending signal")
+	default:
+	}
+
+	unsubscribe()
+	if n := hub.Subscribers("t", "u"); n != 0 {
+		t.Fatalf("expected no subscribers, got %d", n)
+	}
+	hub.Notify("t", "u") // no-op
+}
@@ -0,0 +1,28 @@
+package webhooks
+
+import "net/netip"
+
+// Ranges tenant webhooks may not reach besides those netip classifies:
+// "this network" and carrier-grade NAT, both only reachable from inside.
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+}
+
+// PublicAddr reports whether a tenant's webhook may be sent to a. Loopback,
+// private (RFC 1918, unique local), link-local (which includes cloud metadata
+// endpoints), multicast and unspecified addresses are refused, so a
+// subscription can't be used to probe the service's own network.
+func PublicAddr(a netip.Addr) bool {
+	a = a.Unmap()
+	if !a.IsValid() || a.IsLoopback() || a.IsPrivate() || a.IsUnspecified() ||
+		a.IsLinkLocalUnicast() || a.IsLinkLocalMulticast() || a.IsInterfaceLocalMulticast() || a.IsMulticast() {
+		return false
+	}
+	for _, p := range blockedPrefixes {
+		if p.Contains(a) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,33 @@
+package webhooks
+
+import (
+	"context"
+
+	"inbox-service/internal/application/ports"
+)
+
+// FanoutPublisher is the outbox transport for tenant subscriptions: it turns
+// each event into one delivery row per matching subscription. The deliveries
+// are sent by DeliveryWorker, so a slow or dead endpoint never holds up the
+// outbox relay or the other subscriptions.
+type FanoutPublisher struct {
+	store ports.WebhookDeliveryStore
+}
+
+func NewFanoutPublisher(store ports.WebhookDeliveryStore) *FanoutPublisher {
+	return &FanoutPublisher{store: store}
+}
+
+func (p *FanoutPublisher) Publish(ctx context.Context, m ports.Message) error {
+	_, err := p.store.EnqueueDeliveries(ctx, m)
+	return err
+}
+
+func (p *FanoutPublisher) PublishBatch(ctx context.Context, ms []ports.Message) error {
+	for _, m := range ms {
+		if err := p.Publish(ctx, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,262 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"inbox-service/internal/application/ports"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidRequest = errors.New("invalid request")
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 500
+)
+
+// SubscriptionInput creates a subscription. Enabled defaults to true.
+type SubscriptionInput struct {
+	URL        string
+	EventTypes []string
+	Enabled    *bool
+}
+
+// SubscriptionPatch changes the fields that are set.
+type SubscriptionPatch struct {
+	URL        *string
+	EventTypes *[]string
+	Enabled    *bool
+}
+
+// Subscriptions lets tenants manage their webhook endpoints and look at, or
+// replay, what was delivered to them. Every call is scoped to one tenant.
+type Subscriptions struct {
+	Store      ports.WebhookSubscriptionStore
+	Deliveries ports.WebhookDeliveryStore
+	// SecretGrace is how long the previous secret keeps signing after a
+	// rotation.
+	SecretGrace time.Duration
+	// AllowPrivateURLs accepts URLs on loopback and private networks; only
+	// for local development.
+	AllowPrivateURLs bool
+
+	now       func() time.Time
+	newSecret func() (string, error)
+	lookup    func(ctx context.Context, host string) ([]netip.Addr, error)
+}
+
+func NewSubscriptions(store ports.WebhookSubscriptionStore, deliveries ports.WebhookDeliveryStore, secretGrace time.Duration) *Subscriptions {
+	return &Subscriptions{
+		Store:       store,
+		Deliveries:  deliveries,
+		SecretGrace: secretGrace,
+		now:         func() time.Time { return time.Now().UTC() },
+		newSecret:   randomSecret,
+		lookup: func(ctx context.Context, host string) ([]netip.Addr, error) {
+			return net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+		},
+	}
+}
+
+// Create returns the new subscription including its secret; it is the only
+// time the caller sees it besides RotateSecret.
+func (s *Subscriptions) Create(ctx context.Context, tenantID string, in SubscriptionInput) (ports.WebhookSubscription, error) {
+	u, err := s.validURL(ctx, in.URL)
+	if err != nil {
+		return ports.WebhookSubscription{}, err
+	}
+	types, err := validEventTypes(in.EventTypes)
+	if err != nil {
+		return ports.WebhookSubscription{}, err
+	}
+	secret, err := s.newSecret()
+	if err != nil {
+		return ports.WebhookSubscription{}, err
+	}
+	now := s.now()
+	sub := ports.WebhookSubscription{
+		ID:         uuid.NewString(),
+		TenantID:   tenantID,
+		URL:        u,
+		EventTypes: types,
+		Secret:     secret,
+		Enabled:    in.Enabled == nil || *in.Enabled,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := s.Store.CreateSubscription(ctx, sub); err != nil {
+		return ports.WebhookSubscription{}, err
+	}
+	return sub, nil
+}
+
+func (s *Subscriptions) List(ctx context.Context, tenantID string) ([]ports.WebhookSubscription, error) {
+	return s.Store.ListSubscriptions(ctx, tenantID)
+}
+
+func (s *Subscriptions) Get(ctx context.Context, tenantID, id string) (ports.WebhookSubscription, error) {
+	if err := validID(id); err != nil {
+		return ports.WebhookSubscription{}, err
+	}
+	return s.Store.GetSubscription(ctx, tenantID, id)
+}
+
+func (s *Subscriptions) Update(ctx context.Context, tenantID, id string, p SubscriptionPatch) (ports.WebhookSubscription, error) {
+	sub, err := s.Get(ctx, tenantID, id)
+	if err != nil {
+		return ports.WebhookSubscription{}, err
+	}
+	if p.URL != nil {
+		if sub.URL, err = s.validURL(ctx, *p.URL); err != nil {
+			return ports.WebhookSubscription{}, err
+		}
+	}
+	if p.EventTypes != nil {
+		if sub.EventTypes, err = validEventTypes(*p.EventTypes); err != nil {
+			return ports.WebhookSubscription{}, err
+		}
+	}
+	if p.Enabled != nil {
+		sub.Enabled = *p.Enabled
+	}
+	sub.UpdatedAt = s.now()
+	if err := s.Store.UpdateSubscription(ctx, sub); err != nil {
+		return ports.WebhookSubscription{}, err
+	}
+	return sub, nil
+}
+
+// RotateSecret issues a new secret. The old one keeps signing alongside it
+// for SecretGrace, so receivers can accept either while they switch.
+func (s *Subscriptions) RotateSecret(ctx context.Context, tenantID, id string) (ports.WebhookSubscription, error) {
+	sub, err := s.Get(ctx, tenantID, id)
+	if err != nil {
+		return ports.WebhookSubscription{}, err
+	}
+	secret, err := s.newSecret()
+	if err != nil {
+		return ports.WebhookSubscription{}, err
+	}
+	now := s.now()
+	sub.PreviousSecret, sub.PreviousSecretExpiresAt = "", nil
+	if s.SecretGrace > 0 {
+		until := now.Add(s.SecretGrace)
+		sub.PreviousSecret, sub.PreviousSecretExpiresAt = sub.Secret, &until
+	}
+	sub.Secret = secret
+	sub.UpdatedAt = now
+	if err := s.Store.UpdateSubscription(ctx, sub); err != nil {
+		return ports.WebhookSubscription{}, err
+	}
+	return sub, nil
+}
+
+// Delete removes the subscription together with its deliveries.
+func (s *Subscriptions) Delete(ctx context.Context, tenantID, id string) error {
+	if err := validID(id); err != nil {
+		return err
+	}
+	return s.Store.DeleteSubscription(ctx, tenantID, id)
+}
+
+// ListDeliveries lists the subscription's most recent deliveries.
+func (s *Subscriptions) ListDeliveries(ctx context.Context, tenantID, subscriptionID string, limit int) ([]ports.WebhookDelivery, error) {
+	if _, err := s.Get(ctx, tenantID, subscriptionID); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	if limit > maxListLimit {
+		limit = maxListLimit
+	}
+	return s.Deliveries.ListDeliveries(ctx, tenantID, subscriptionID, limit)
+}
+
+// GetDelivery returns a delivery with its attempts, oldest first.
+func (s *Subscriptions) GetDelivery(ctx context.Context, tenantID, id string) (ports.WebhookDelivery, []ports.WebhookAttempt, error) {
+	if err := validID(id); err != nil {
+		return ports.WebhookDelivery{}, nil, err
+	}
+	return s.Deliveries.GetDelivery(ctx, tenantID, id)
+}
+
+// Replay sends a delivery again, e.g. after the receiver lost it or was
+// fixed after the delivery FAILED. Receivers see the same idempotency key.
+func (s *Subscriptions) Replay(ctx context.Context, tenantID, id string) error {
+	if err := validID(id); err != nil {
+		return err
+	}
+	return s.Deliveries.ReplayDelivery(ctx, tenantID, id, s.now())
+}
+
+func validID(id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return fmt.Errorf("%w: id must be a UUID", ErrInvalidRequest)
+	}
+	return nil
+}
+
+// validURL also resolves the host and refuses it unless every address is
+// public (see PublicAddr). The sender checks again when it connects, since
+// DNS can change after the subscription was saved.
+func (s *Subscriptions) validURL(ctx context.Context, raw string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Hostname() == "" {
+		return "", fmt.Errorf("%w: url must be an absolute http(s) URL", ErrInvalidRequest)
+	}
+	if u.User != nil {
+		return "", fmt.Errorf("%w: url must not carry credentials", ErrInvalidRequest)
+	}
+	if s.AllowPrivateURLs {
+		return u.String(), nil
+	}
+
+	addrs := []netip.Addr{}
+	if a, err := netip.ParseAddr(u.Hostname()); err == nil {
+		addrs = append(addrs, a)
+	} else if addrs, err = s.lookup(ctx, u.Hostname()); err != nil || len(addrs) == 0 {
+		return "", fmt.Errorf("%w: url host %q does not resolve", ErrInvalidRequest, u.Hostname())
+	}
+	for _, a := range addrs {
+		if !PublicAddr(a) {
+			return "", fmt.Errorf("%w: url host %q is not a public address", ErrInvalidRequest, u.Hostname())
+		}
+	}
+	return u.String(), nil
+}
+
+// validEventTypes trims, dedupes and sorts; an empty list means all types.
+func validEventTypes(in []string) ([]string, error) {
+	out := []string{}
+	for _, t := range in {
+		t = strings.TrimSpace(t)
+		if t == "" {
+			return nil, fmt.Errorf("%w: event types must not be empty", ErrInvalidRequest)
+		}
+		if !slices.Contains(out, t) {
+			out = append(out, t)
+		}
+	}
+	slices.Sort(out)
+	return out, nil
+}
+
+func randomSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,302 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"testing"
+	"time"
+
+	"inbox-service/internal/application/ports"
+)
+
+// --- fakes ---
+
+type fakeSubStore struct {
+	subs map[string]ports.WebhookSubscription
+}
+
+func (f *fakeSubStore) CreateSubscription(ctx context.Context, s ports.WebhookSubscription) error {
+	f.subs[s.ID] = s
+	return nil
+}
+
+func (f *fakeSubStore) ListSubscriptions(ctx context.Context, tenantID string) ([]ports.WebhookSubscription, error) {
+	var out []ports.WebhookSubscription
+	for _, s := range f.subs {
+		if s.TenantID == tenantID {
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeSubStore) GetSubscription(ctx context.Context, tenantID, id string) (ports.WebhookSubscription, error) {
+	s, ok := f.subs[id]
+	if !ok || s.TenantID != tenantID {
+		return ports.WebhookSubscription{}, ports.ErrNotFound
+	}
+	return s, nil
+}
+
+func (f *fakeSubStore) UpdateSubscription(ctx context.Context, s ports.WebhookSubscription) error {
+	if _, err := f.GetSubscription(ctx, s.TenantID, s.ID); err != nil {
+		return err
+	}
+	f.subs[s.ID] = s
+	return nil
+}
+
+func (f *fakeSubStore) DeleteSubscription(ctx context.Context, tenantID, id string) error {
+	if _, err := f.GetSubscription(ctx, tenantID, id); err != nil {
+		return err
+	}
+	delete(f.subs, id)
+	return nil
+}
+
+// fakeDeliveries records what the worker decided for each delivery.
+type fakeDeliveries struct {
+	due       []ports.WebhookTarget
+	attempts  map[string]ports.WebhookAttempt
+	status    map[string]string
+	nextRun   map[string]time.Time
+	postponed map[string]time.Time
+	replayed  []string
+}
+
+func newFakeDeliveries(due ...ports.WebhookTarget) *fakeDeliveries {
+	return &fakeDeliveries{
+		due:       due,
+		attempts:  map[string]ports.WebhookAttempt{},
+		status:    map[string]string{},
+		nextRun:   map[string]time.Time{},
+		postponed: map[string]time.Time{},
+	}
+}
+
+func (f *fakeDeliveries) EnqueueDeliveries(ctx context.Context, m ports.Message) (int, error) {
+	return 0, nil
+}
+
+func (f *fakeDeliveries) ClaimDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]ports.WebhookTarget, error) {
+	out := f.due
+	f.due = nil
+	return out, nil
+}
+
+func (f *fakeDeliveries) RecordAttempt(ctx context.Context, d ports.WebhookDelivery, a ports.WebhookAttempt, status string, nextRunAt time.Time) error {
+	f.attempts[d.ID], f.status[d.ID], f.nextRun[d.ID] = a, status, nextRunAt
+	return nil
+}
+
+func (f *fakeDeliveries) Postpone(ctx context.Context, d ports.WebhookDelivery, nextRunAt time.Time) error {
+	f.postponed[d.ID] = nextRunAt
+	return nil
+}
+
+func (f *fakeDeliveries) ListDeliveries(ctx context.Context, tenantID, subscriptionID string, limit int) ([]ports.WebhookDelivery, error) {
+	return nil, nil
+}
+
+func (f *fakeDeliveries) GetDelivery(ctx context.Context, tenantID, id string) (ports.WebhookDelivery, []ports.WebhookAttempt, error) {
+	return ports.WebhookDelivery{}, nil, ports.ErrNotFound
+}
+
+func (f *fakeDeliveries) ReplayDelivery(ctx context.Context, tenantID, id string, now time.Time) error {
+	f.replayed = append(f.replayed, id)
+	return nil
+}
+
+// fakeSender answers per delivery id.
+type fakeSender map[string]error
+
+func (f fakeSender) Send(ctx context.Context, t ports.WebhookTarget) (int, error) {
+	if err := f[t.Delivery.ID]; err != nil {
+		return 503, err
+	}
+	return 200, nil
+}
+
+func newTestSubscriptions() (*Subscriptions, *fakeSubStore) {
+	store := &fakeSubStore{subs: map[string]ports.WebhookSubscription{}}
+	s := NewSubscriptions(store, newFakeDeliveries(), time.Hour)
+	n := 0
+	s.newSecret = func() (string, error) {
+		n++
+		return fmt.Sprintf("secret-%d", n), nil
+	}
+	s.now = func() time.Time { return time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC) }
+	s.lookup = func(ctx context.Context, host string) ([]netip.Addr, error) {
+		addrs, ok := map[string][]netip.Addr{
+			"example.com":      {netip.MustParseAddr("93.184.215.14")},
+			"internal.example": {netip.MustParseAddr("93.184.215.14"), netip.MustParseAddr("10.0.0.7")},
+		}[host]
+		if !ok {
+			return nil, errors.New("no such host")
+		}
+		return addrs, nil
+	}
+	return s, store
+}
+
+// --- tests ---
+
+func TestSubscriptions_CreateValidates(t *testing.T) {
+	s, _ := newTestSubscriptions()
+	ctx := context.Background()
+
+	for _, in := range []SubscriptionInput{
+		{URL: "ftp://example.com/hook"},
+		{URL: "/relative"},
+		{URL: "https://user:pw@example.com/hook"},
+		{URL: "https://example.com/hook", EventTypes: []string{" "}},
+		// Addresses inside the service's network, literal or resolved.
+		{URL: "http://127.0.0.1:8080/hook"},
+		{URL: "http://[::1]/hook"},
+		{URL: "http://169.254.169.254/latest/meta-data"},
+		{URL: "http://192.168.1.10/hook"},
+		{URL: "https://internal.example/hook"},
+		{URL: "https://nowhere.example/hook"},
+	} {
+		if _, err := s.Create(ctx, "t", in); !errors.Is(err, ErrInvalidRequest) {
+			t.Fatalf("%+v: expected ErrInvalidRequest, got %v", in, err)
+		}
+	}
+
+	sub, err := s.Create(ctx, "t", SubscriptionInput{URL: "https://example.com/hook", EventTypes: []string{"InboxItemRead", "InboxItemCreated", "InboxItemRead"}})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if !sub.Enabled || sub.Secret != "secret-1" || fmt.Sprint(sub.EventTypes) != "[InboxItemCreated InboxItemRead]" {
+		t.Fatalf("unexpected subscription %+v", sub)
+	}
+}
+
+func TestSubscriptions_RotateKeepsPreviousSecretForGrace(t *testing.T) {
+	s, _ := newTestSubscriptions()
+	ctx := context.Background()
+	sub, _ := s.Create(ctx, "t", SubscriptionInput{URL: "https://example.com/hook"})
+
+	rotated, err := s.RotateSecret(ctx, "t", sub.ID)
+	if err != nil {
+		t.Fatalf("RotateSecret: %v", err)
+	}
+	if rotated.Secret != "secret-2" || rotated.PreviousSecret != "secret-1" {
+		t.Fatalf("expected secret-2 with secret-1 as previous, got %+v", rotated)
+	}
+	if want := s.now().Add(time.Hour); rotated.PreviousSecretExpiresAt == nil || !rotated.PreviousSecretExpiresAt.Equal(want) {
+		t.Fatalf("expected previous secret to expire at %v, got %v", want, rotated.PreviousSecretExpiresAt)
+	}
+}
+
+func TestSubscriptions_ScopedToTenant(t *testing.T) {
+	s, _ := newTestSubscriptions()
+	ctx := context.Background()
+	sub, _ := s.Create(ctx, "t1", SubscriptionInput{URL: "https://example.com/hook"})
+
+	off := false
+	if _, err := s.Update(ctx, "t2", sub.ID, SubscriptionPatch{Enabled: &off}); !errors.Is(err, ports.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for another tenant, got %v", err)
+	}
+	if _, err := s.ListDeliveries(ctx, "t2", sub.ID, 0); !errors.Is(err, ports.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for another tenant's deliveries, got %v", err)
+	}
+	updated, err := s.Update(ctx, "t1", sub.ID, SubscriptionPatch{Enabled: &off})
+	if err != nil || updated.Enabled {
+		t.Fatalf("expected the subscription disabled, got %+v (%v)", updated, err)
+	}
+	if err := s.Replay(ctx, "t1", "not-a-uuid"); !errors.Is(err, ErrInvalidRequest) {
+		t.Fatalf("expected ErrInvalidRequest, got %v", err)
+	}
+}
+
+func TestDeliveryWorker_Outcomes(t *testing.T) {
+	target := func(id string, attempts int) ports.WebhookTarget {
+		return ports.WebhookTarget{Delivery: ports.WebhookDelivery{ID: id, Attempts: attempts}}
+	}
+	store := newFakeDeliveries(target("ok", 0), target("retry", 1), target("last", 2), target("open", 0))
+	sender := fakeSender{
+		"retry": errors.New("status 503"),
+		"last":  errors.New("status 503"),
+		"open":  fmt.Errorf("x: %w", ports.ErrWebhookCircuitOpen),
+	}
+	w := NewDeliveryWorker(store, sender, WorkerConfig{MaxAttempts: 3, BaseBackoff: time.Second, MaxBackoff: time.Minute, CircuitWait: time.Minute})
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	w.now = func() time.Time { return now }
+
+	if n, err := w.DeliverOnce(context.Background()); err != nil || n != 4 {
+		t.Fatalf("DeliverOnce: %d, %v", n, err)
+	}
+	if store.status["ok"] != ports.DeliverySent || store.attempts["ok"].StatusCode != 200 {
+		t.Fatalf("expected ok SENT with 200, got %s / %+v", store.status["ok"], store.attempts["ok"])
+	}
+	if store.status["retry"] != ports.DeliveryPending || store.attempts["retry"].Attempt != 2 || !store.nextRun["retry"].Equal(now.Add(2*time.Second)) {
+		t.Fatalf("expected retry rescheduled as attempt 2 in 2s, got %s / %+v / %v", store.status["retry"], store.attempts["retry"], store.nextRun["retry"])
+	}
+	if store.status["last"] != ports.DeliveryFailed || store.attempts["last"].Error == "" {
+		t.Fatalf("expected last FAILED with its error, got %s / %+v", store.status["last"], store.attempts["last"])
+	}
+	if _, recorded := store.attempts["open"]; recorded || !store.postponed["open"].Equal(now.Add(time.Minute)) {
+		t.Fatalf("expected open postponed without an attempt, got %v", store.postponed)
+	}
+}
+
+// slowSender takes a while per call unless ctx ends first.
+type slowSender time.Duration
+
+func (s slowSender) Send(ctx context.Context, t ports.WebhookTarget) (int, error) {
+	select {
+	case <-time.After(time.Duration(s)):
+		return 200, nil
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+func TestDeliveryWorker_StopsSendingWhenBatchLeaseRunsOut(t *testing.T) {
+	target := func(id string) ports.WebhookTarget {
+		return ports.WebhookTarget{Delivery: ports.WebhookDelivery{ID: id}}
+	}
+	store := newFakeDeliveries(target("a"), target("b"), target("c"))
+	w := NewDeliveryWorker(store, slowSender(30*time.Millisecond), WorkerConfig{Lease: 50 * time.Millisecond})
+
+	if n, err := w.DeliverOnce(context.Background()); err != nil || n != 3 {
+		t.Fatalf("DeliverOnce: %d, %v", n, err)
+	}
+	// a fits in the lease, b is cut off by it, c is handed back unsent.
+	if store.status["a"] != ports.DeliverySent {
+		t.Fatalf("expected a SENT, got %q", store.status["a"])
+	}
+	if store.status["b"] != ports.DeliveryPending || store.attempts["b"].Error == "" {
+		t.Fatalf("expected b retried after the lease ran out, got %q / %+v", store.status["b"], store.attempts["b"])
+	}
+	if _, recorded := store.attempts["c"]; recorded {
+		t.Fatalf("expected c not to be sent, got %+v", store.attempts["c"])
+	}
+	if _, ok := store.postponed["c"]; !ok {
+		t.Fatalf("expected c handed back, got %v", store.postponed)
+	}
+}
+
+func TestPublicAddr(t *testing.T) {
+	for addr, want := range map[string]bool{
+		"93.184.215.14":   true,
+		"2606:4700::1111": true,
+		"127.0.0.1":       false,
+		"10.1.2.3":        false,
+		"172.16.0.1":      false,
+		"100.64.0.1":      false,
+		"169.254.169.254": false,
+		"0.0.0.0":         false,
+		"::1":             false,
+		"fd00::1":         false,
+		"fe80::1":         false,
+		"::ffff:10.0.0.1": false,
+	} {
+		if got := PublicAddr(netip.MustParseAddr(addr)); got != want {
+			t.Fatalf("%s: expected %v, got %v", addr, want, got)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"inbox-service/internal/application/outbox"
+	"inbox-service/internal/application/ports"
+)
+
+type WorkerConfig struct {
+	BatchSize int
+	// Lease is how long a claimed batch is ours; a delivery still PROCESSING
+	// after it is claimed again (e.g. after a crash). Calls stop when it runs
+	// out.
+	Lease       time.Duration
+	MaxAttempts int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// CircuitWait postpones deliveries whose endpoint's breaker is open.
+	CircuitWait time.Duration
+}
+
+// DeliveryWorker sends due webhook deliveries, retrying each one on its own
+// schedule until it is accepted or runs out of attempts.
+type DeliveryWorker struct {
+	store  ports.WebhookDeliveryStore
+	sender ports.WebhookSender
+	cfg    WorkerConfig
+
+	now func() time.Time
+}
+
+func NewDeliveryWorker(store ports.WebhookDeliveryStore, sender ports.WebhookSender, cfg WorkerConfig) *DeliveryWorker {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 50
+	}
+	if cfg.Lease <= 0 {
+		cfg.Lease = 30 * time.Second
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 10
+	}
+	if cfg.BaseBackoff <= 0 {
+		cfg.BaseBackoff = 5 * time.Second
+	}
+	if cfg.MaxBackoff < cfg.BaseBackoff {
+		cfg.MaxBackoff = cfg.BaseBackoff
+	}
+	if cfg.CircuitWait <= 0 {
+		cfg.CircuitWait = 30 * time.Second
+	}
+	return &DeliveryWorker{
+		store:  store,
+		sender: sender,
+		cfg:    cfg,
+		now:    func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// Run drains due deliveries; it is meant for jobs.Every.
+func (w *DeliveryWorker) Run(ctx context.Context) error {
+	for {
+		n, err := w.DeliverOnce(ctx)
+		if err != nil || n < w.cfg.BatchSize {
+			return err
+		}
+	}
+}
+
+// DeliverOnce claims one batch and sends it. It returns the number claimed.
+//
+// The whole batch is claimed under one lease, so sending stops when it runs
+// out; deliveries not sent by then are handed back, since another worker may
+// own them already.
+func (w *DeliveryWorker) DeliverOnce(ctx context.Context) (int, error) {
+	leaseCtx, cancel := context.WithTimeout(ctx, w.cfg.Lease)
+	defer cancel()
+	now := w.now()
+	targets, err := w.store.ClaimDeliveries(ctx, now, now.Add(w.cfg.Lease), w.cfg.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("claim deliveries: %w", err)
+	}
+	for _, t := range targets {
+		if leaseCtx.Err() != nil {
+			if err := w.store.Postpone(ctx, t.Delivery, w.now()); err != nil && !errors.Is(err, ports.ErrLeaseLost) {
+				return len(targets), fmt.Errorf("release delivery %s: %w", t.Delivery.ID, err)
+			}
+			continue
+		}
+		if err := w.deliver(ctx, leaseCtx, t); err != nil {
+			if !errors.Is(err, ports.ErrLeaseLost) {
+				return len(targets), err
+			}
+			log.Printf("webhook delivery %s: %v", t.Delivery.ID, err)
+		}
+	}
+	return len(targets), nil
+}
+
+// deliver sends one delivery within leaseCtx and records the outcome.
+func (w *DeliveryWorker) deliver(ctx, leaseCtx context.Context, t ports.WebhookTarget) error {
+	d := t.Delivery
+	if d.Attempts >= w.cfg.MaxAttempts {
+		a := ports.WebhookAttempt{Attempt: d.Attempts, Error: "max attempts exceeded", CreatedAt: w.now()}
+		return w.store.RecordAttempt(ctx, d, a, ports.DeliveryFailed, w.now())
+	}
+
+	start := time.Now()
+	code, sendErr := w.sender.Send(leaseCtx, t)
+
+	if errors.Is(sendErr, ports.ErrWebhookCircuitOpen) {
+		// Nothing was sent, so it doesn't use up an attempt.
+		return w.store.Postpone(ctx, d, w.now().Add(w.cfg.CircuitWait))
+	}
+
+	a := ports.WebhookAttempt{
+		Attempt:    d.Attempts + 1,
+		StatusCode: code,
+		Duration:   time.Since(start),
+		CreatedAt:  w.now(),
+	}
+	switch {
+	case sendErr == nil:
+		return w.store.RecordAttempt(ctx, d, a, ports.DeliverySent, a.CreatedAt)
+	case a.Attempt >= w.cfg.MaxAttempts:
+		a.Error = sendErr.Error()
+		return w.store.RecordAttempt(ctx, d, a, ports.DeliveryFailed, a.CreatedAt)
+	default:
+		a.Error = sendErr.Error()
+		next := a.CreatedAt.Add(outbox.Backoff(a.Attempt, w.cfg.BaseBackoff, w.cfg.MaxBackoff))
+		return w.store.RecordAttempt(ctx, d, a, ports.DeliveryPending, next)
+	}
+}
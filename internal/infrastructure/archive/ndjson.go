@@ -0,0 +1,117 @@
+package archive
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"inbox-service/internal/application/ports"
+)
+
+// NDJSONArchive writes archived outbox rows to gzip-compressed NDJSON files in
+// a local directory, one file per batch:
+//
+//	<dir>/outbox-<status>-<UTC timestamp>.ndjson.gz
+//
+// A file is written under a temporary name, synced and renamed, so a file
+// that exists is complete. If the deleting transaction fails afterwards the
+// rows are archived again on the next run; readers should dedupe by id.
+type NDJSONArchive struct {
+	dir string
+	now func() time.Time
+}
+
+type archiveLine struct {
+	ID         string            `json:"id"`
+	TenantID   string            `json:"tenant_id"`
+	EventType  string            `json:"event_type"`
+	Key        string            `json:"partition_key"`
+	Seq        int64             `json:"seq"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	Status     string            `json:"status"`
+	Attempts   int               `json:"attempts"`
+	LastError  string            `json:"last_error,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+	ArchivedAt time.Time         `json:"archived_at"`
+	Payload    json.RawMessage   `json:"payload"`
+}
+
+// NewNDJSONArchive creates dir if needed.
+func NewNDJSONArchive(dir string) (*NDJSONArchive, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create archive dir: %w", err)
+	}
+	return &NDJSONArchive{dir: dir, now: func() time.Time { return time.Now().UTC() }}, nil
+}
+
+// ArchiveOutbox ignores tx: the file is durable before the rows are deleted.
+func (a *NDJSONArchive) ArchiveOutbox(ctx context.Context, tx ports.Tx, rows []ports.OutboxRow) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	now := a.now()
+	name := fmt.Sprintf("outbox-%s-%s.ndjson.gz", strings.ToLower(rows[0].Status), now.Format("20060102T150405.000000000Z"))
+	path := filepath.Join(a.dir, name)
+
+	tmp, err := os.CreateTemp(a.dir, name+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create archive file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	defer func() { _ = tmp.Close() }()
+
+	zw := gzip.NewWriter(tmp)
+	enc := json.NewEncoder(zw)
+	for _, r := range rows {
+		err := enc.Encode(archiveLine{
+			ID:         r.ID,
+			TenantID:   r.TenantID,
+			EventType:  r.EventType,
+			Key:        r.PartitionKey,
+			Seq:        r.Seq,
+			Headers:    r.Headers,
+			Status:     r.Status,
+			Attempts:   r.Attempts,
+			LastError:  r.LastError,
+			CreatedAt:  r.CreatedAt,
+			UpdatedAt:  r.UpdatedAt,
+			ArchivedAt: now,
+			Payload:    json.RawMessage(r.Payload),
+		})
+		if err != nil {
+			return fmt.Errorf("encode outbox row %s: %w", r.ID, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("write archive file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return fmt.Errorf("sync archive file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close archive file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("rename archive file: %w", err)
+	}
+	return syncDir(a.dir)
+}
+
+// syncDir makes the rename durable.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("open archive dir: %w", err)
+	}
+	defer d.Close()
+	if err := d.Sync(); err != nil {
+		return fmt.Errorf("sync archive dir: %w", err)
+	}
+	return nil
+}
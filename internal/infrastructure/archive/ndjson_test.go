@@ -0,0 +1,66 @@
+package archive
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"inbox-service/internal/application/ports"
+)
+
+func TestNDJSONArchive_WritesOneCompressedFilePerBatch(t *testing.T) {
+	dir := t.TempDir()
+	a, err := NewNDJSONArchive(filepath.Join(dir, "outbox"))
+	if err != nil {
+		t.Fatalf("NewNDJSONArchive: %v", err)
+	}
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	a.now = func() time.Time { return now }
+
+	rows := []ports.OutboxRow{
+		{ID: "1", TenantID: "t", EventType: "InboxItemCreated", Status: ports.OutboxSent, Attempts: 1, CreatedAt: now.AddDate(0, 0, -10), Payload: []byte(`{"item_id":"a"}`)},
+		{ID: "2", TenantID: "t", EventType: "InboxItemCreated", Status: ports.OutboxSent, Attempts: 3, LastError: "timeout", CreatedAt: now.AddDate(0, 0, -9), Payload: []byte(`{"item_id":"b"}`)},
+	}
+	if err := a.ArchiveOutbox(context.Background(), nil, rows); err != nil {
+		t.Fatalf("ArchiveOutbox: %v", err)
+	}
+
+	entries, err := os.ReadDir(filepath.Join(dir, "outbox"))
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "outbox-sent-20260301T120000.000000000Z.ndjson.gz" {
+		t.Fatalf("expected one archive file and no leftovers, got %v", entries)
+	}
+
+	f, err := os.Open(filepath.Join(dir, "outbox", entries[0].Name()))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+
+	var got []archiveLine
+	sc := bufio.NewScanner(zr)
+	for sc.Scan() {
+		var l archiveLine
+		if err := json.Unmarshal(sc.Bytes(), &l); err != nil {
+			t.Fatalf("decode %q: %v", sc.Text(), err)
+		}
+		got = append(got, l)
+	}
+	if err := sc.Err(); err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	if len(got) != 2 || got[1].ID != "2" || got[1].LastError != "timeout" || string(got[0].Payload) != `{"item_id":"a"}` || !got[0].ArchivedAt.Equal(now) {
+		t.Fatalf("unexpected archive contents %+v", got)
+	}
+}
@@ -0,0 +1,246 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// KeySet is a JWKS loaded from a file or URL. URL sets are refreshed
+// periodically and, rate limited, when a token names an unknown kid (key
+// rotation).
+type KeySet struct {
+	fetch   func(ctx context.Context) ([]byte, error)
+	refresh time.Duration
+
+	mu          sync.Mutex
+	keys        map[string]crypto.PublicKey
+	loadedAt    time.Time
+	lastAttempt time.Time
+	// reloading is closed when the refetch in flight, if any, is done.
+	reloading chan struct{}
+}
+
+// minRefetch bounds refetches triggered by unknown kids.
+const minRefetch = time.Minute
+
+func NewKeySetFromFile(path string) (*KeySet, error) {
+	ks := &KeySet{fetch: func(context.Context) ([]byte, error) { return os.ReadFile(path) }}
+	if err := ks.load(context.Background()); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+func NewKeySetFromURL(ctx context.Context, url string, refresh time.Duration) (*KeySet, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	ks := &KeySet{
+		refresh: refresh,
+		fetch: func(ctx context.Context) ([]byte, error) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+			if err != nil {
+				return nil, err
+			}
+			res, err := client.Do(req)
+			if err != nil {
+				return nil, err
+			}
+			defer res.Body.Close()
+			if res.StatusCode != http.StatusOK {
+				return nil, fmt.Errorf("jwks %s: status %d", url, res.StatusCode)
+			}
+			return io.ReadAll(io.LimitReader(res.Body, 1<<20))
+		},
+	}
+	if err := ks.load(ctx); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// Key returns the public key for kid. An empty kid matches when the set holds
+// exactly one key. Refetches run in the background, outside the lock: callers
+// whose key is known never wait for one, the others share it.
+func (ks *KeySet) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	ks.mu.Lock()
+	now := time.Now()
+	stale := ks.refresh > 0 && now.Sub(ks.loadedAt) > ks.refresh
+	k, ok := ks.lookup(kid)
+	wait := ks.reloading
+	if (stale || !ok) && wait == nil && now.Sub(ks.lastAttempt) >= minRefetch {
+		wait = make(chan struct{})
+		ks.reloading = wait
+		ks.lastAttempt = now
+		go ks.reload(wait)
+	}
+	ks.mu.Unlock()
+
+	if !ok && wait != nil {
+		select {
+		case <-wait:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		ks.mu.Lock()
+		k, ok = ks.lookup(kid)
+		ks.mu.Unlock()
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return k, nil
+}
+
+// lookup must be called with mu held.
+func (ks *KeySet) lookup(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(ks.keys) == 1 {
+		for _, k := range ks.keys {
+			return k, true
+		}
+	}
+	k, ok := ks.keys[kid]
+	return k, ok
+}
+
+// reload refetches the set for Key and closes done. It isn't tied to a
+// request, since other callers may be waiting for it too; the fetch has its
+// own timeout.
+func (ks *KeySet) reload(done chan struct{}) {
+	keys, err := ks.fetchKeys(context.Background())
+	ks.mu.Lock()
+	if err == nil {
+		ks.keys = keys
+		ks.loadedAt = ks.lastAttempt
+	}
+	ks.reloading = nil
+	ks.mu.Unlock()
+	close(done)
+}
+
+func (ks *KeySet) load(ctx context.Context) error {
+	attempt := time.Now()
+	keys, err := ks.fetchKeys(ctx)
+	if err != nil {
+		return err
+	}
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.keys = keys
+	ks.loadedAt = attempt
+	ks.lastAttempt = attempt
+	return nil
+}
+
+func (ks *KeySet) fetchKeys(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	raw, err := ks.fetch(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("load jwks: %w", err)
+	}
+	return ParseJWKS(raw)
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// ParseJWKS decodes the RSA and P-256 EC signing keys of a JWK set, keyed by
+// kid. Other key types and curves are skipped, so an IdP adding one doesn't
+// break the set; malformed keys of a supported kind are an error.
+func ParseJWKS(raw []byte) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(raw, &set); err != nil {
+		return nil, fmt.Errorf("parse jwks: %w", err)
+	}
+
+	keys := map[string]crypto.PublicKey{}
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		var (
+			pub crypto.PublicKey
+			err error
+		)
+		switch k.Kty {
+		case "RSA":
+			pub, err = rsaKey(k)
+		case "EC":
+			if k.Crv != "P-256" {
+				continue
+			}
+			pub, err = ecKey(k)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("jwks key %q: %w", k.Kid, err)
+		}
+		keys[k.Kid] = pub
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("parse jwks: no usable signing keys")
+	}
+	return keys, nil
+}
+
+func rsaKey(k jwk) (*rsa.PublicKey, error) {
+	n, err := b64int(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("n: %w", err)
+	}
+	e, err := b64int(k.E)
+	if err != nil {
+		return nil, fmt.Errorf("e: %w", err)
+	}
+	if !e.IsInt64() || e.Int64() < 3 {
+		return nil, fmt.Errorf("invalid exponent")
+	}
+	return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+}
+
+func ecKey(k jwk) (*ecdsa.PublicKey, error) {
+	if k.Crv != "P-256" {
+		return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+	}
+	x, err := b64int(k.X)
+	if err != nil {
+		return nil, fmt.Errorf("x: %w", err)
+	}
+	y, err := b64int(k.Y)
+	if err != nil {
+		return nil, fmt.Errorf("y: %w", err)
+	}
+	pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+	if !pub.Curve.IsOnCurve(x, y) {
+		return nil, fmt.Errorf("point not on curve")
+	}
+	return pub, nil
+}
+
+func b64int(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type JWTConfig struct {
+	Issuer   string
+	Audience string
+
+	// HS256Secret enables HS256; Keys enables RS256 and ES256. At least one
+	// must be set.
+	HS256Secret []byte
+	Keys        *KeySet
+
+	// Claim names; nested claims use dots ("app.tenant"). Roles may be an
+	// array or a space separated string.
+	TenantClaim string
+	UserClaim   string
+	RolesClaim  string
+
+	Leeway time.Duration
+}
+
+func withDefaultClaims(cfg JWTConfig) JWTConfig {
+	if cfg.TenantClaim == "" {
+		cfg.TenantClaim = "tenant_id"
+	}
+	if cfg.UserClaim == "" {
+		cfg.UserClaim = "sub"
+	}
+	if cfg.RolesClaim == "" {
+		cfg.RolesClaim = "roles"
+	}
+	return cfg
+}
+
+// JWTVerifier validates bearer tokens and maps their claims to a Principal.
+type JWTVerifier struct {
+	cfg    JWTConfig
+	parser *jwt.Parser
+}
+
+func NewJWTVerifier(cfg JWTConfig) (*JWTVerifier, error) {
+	cfg = withDefaultClaims(cfg)
+
+	var methods []string
+	if len(cfg.HS256Secret) > 0 {
+		methods = append(methods, "HS256")
+	}
+	if cfg.Keys != nil {
+		methods = append(methods, "RS256", "ES256")
+	}
+	if len(methods) == 0 {
+		return nil, fmt.Errorf("jwt: configure an HS256 secret or a JWKS")
+	}
+	if cfg.Issuer == "" || cfg.Audience == "" {
+		return nil, fmt.Errorf("jwt: issuer and audience are required")
+	}
+
+	return &JWTVerifier{
+		cfg: cfg,
+		parser: jwt.NewParser(
+			jwt.WithValidMethods(methods),
+			jwt.WithIssuer(cfg.Issuer),
+			jwt.WithAudience(cfg.Audience),
+			jwt.WithExpirationRequired(),
+			jwt.WithLeeway(cfg.Leeway),
+		),
+	}, nil
+}
+
+func (v *JWTVerifier) Verify(ctx context.Context, token string) (Principal, error) {
+	claims := jwt.MapClaims{}
+	_, err := v.parser.ParseWithClaims(token, claims, func(t *jwt.Token) (any, error) {
+		if t.Method.Alg() == "HS256" {
+			return v.cfg.HS256Secret, nil
+		}
+		kid, _ := t.Header["kid"].(string)
+		return v.cfg.Keys.Key(ctx, kid)
+	})
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: %v", ErrUnauthenticated, err)
+	}
+
+	p := Principal{
+		TenantID: stringClaim(claims, v.cfg.TenantClaim),
+		UserID:   stringClaim(claims, v.cfg.UserClaim),
+		Subject:  stringClaim(claims, "sub"),
+		Roles:    rolesClaim(claims, v.cfg.RolesClaim),
+	}
+	if p.TenantID == "" || p.UserID == "" {
+		return Principal{}, fmt.Errorf("%w: token lacks %s or %s claim", ErrUnauthenticated, v.cfg.TenantClaim, v.cfg.UserClaim)
+	}
+	return p, nil
+}
+
+// claim looks up name, first as a literal key (URL-style claim names contain
+// dots) and then as a dotted path.
+func claim(claims map[string]any, name string) any {
+	if v, ok := claims[name]; ok {
+		return v
+	}
+	var cur any = claims
+	for _, part := range strings.Split(name, ".") {
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil
+		}
+		cur = m[part]
+	}
+	return cur
+}
+
+func stringClaim(claims map[string]any, name string) string {
+	s, _ := claim(claims, name).(string)
+	return s
+}
+
+func rolesClaim(claims map[string]any, name string) []string {
+	switch v := claim(claims, name).(type) {
+	case string:
+		return strings.Fields(v)
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, r := range v {
+			if s, ok := r.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// BearerToken extracts the token from an Authorization header value.
+func BearerToken(header string) (string, error) {
+	scheme, token, ok := strings.Cut(strings.TrimSpace(header), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return "", fmt.Errorf("%w: expected Authorization: Bearer <token>", ErrUnauthenticated)
+	}
+	return strings.TrimSpace(token), nil
+}
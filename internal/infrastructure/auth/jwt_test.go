@@ -0,0 +1,230 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testIssuer   = "https://issuer.example.com"
+	testAudience = "inbox-service"
+)
+
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":       testIssuer,
+		"aud":       testAudience,
+		"sub":       "user-1",
+		"exp":       time.Now().Add(time.Hour).Unix(),
+		"tenant_id": "tenant-1",
+		"roles":     []string{"admin"},
+	}
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, key any, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	tok := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		tok.Header["kid"] = kid
+	}
+	s, err := tok.SignedString(key)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return s
+}
+
+func b64(n *big.Int) string { return base64.RawURLEncoding.EncodeToString(n.Bytes()) }
+
+// writeJWKS writes a JWK set with one RSA ("rsa-1") and one EC ("ec-1") key.
+func writeJWKS(t *testing.T, rsaKey *rsa.PrivateKey, ecKey *ecdsa.PrivateKey) string {
+	t.Helper()
+	set := map[string]any{"keys": []map[string]any{
+		{"kty": "RSA", "kid": "rsa-1", "use": "sig", "n": b64(rsaKey.N), "e": b64(big.NewInt(int64(rsaKey.E)))},
+		{"kty": "EC", "kid": "ec-1", "crv": "P-256", "x": b64(ecKey.X), "y": b64(ecKey.Y)},
+	}}
+	raw, _ := json.Marshal(set)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, raw, 0o600); err != nil {
+		t.Fatalf("write jwks: %v", err)
+	}
+	return path
+}
+
+func TestJWTVerifier_HS256(t *testing.T) {
+	secret := []byte("s3cret")
+	v, err := NewJWTVerifier(JWTConfig{Issuer: testIssuer, Audience: testAudience, HS256Secret: secret})
+	if err != nil {
+		t.Fatalf("NewJWTVerifier: %v", err)
+	}
+
+	p, err := v.Verify(context.Background(), sign(t, jwt.SigningMethodHS256, secret, "", validClaims()))
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if p.TenantID != "tenant-1" || p.UserID != "user-1" || !p.HasRole("admin") {
+		t.Fatalf("unexpected principal %+v", p)
+	}
+
+	if _, err := v.Verify(context.Background(), sign(t, jwt.SigningMethodHS256, []byte("other"), "", validClaims())); !errors.Is(err, ErrUnauthenticated) {
+		t.Fatalf("expected bad signature to fail, got %v", err)
+	}
+}
+
+func TestJWTVerifier_RejectsBadClaims(t *testing.T) {
+	secret := []byte("s3cret")
+	v, _ := NewJWTVerifier(JWTConfig{Issuer: testIssuer, Audience: testAudience, HS256Secret: secret})
+
+	cases := map[string]func(jwt.MapClaims){
+		"wrong issuer":   func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" },
+		"wrong audience": func(c jwt.MapClaims) { c["aud"] = "someone-else" },
+		"expired":        func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() },
+		"no expiry":      func(c jwt.MapClaims) { delete(c, "exp") },
+		"no tenant":      func(c jwt.MapClaims) { delete(c, "tenant_id") },
+	}
+	for name, mutate := range cases {
+		t.Run(name, func(t *testing.T) {
+			c := validClaims()
+			mutate(c)
+			if _, err := v.Verify(context.Background(), sign(t, jwt.SigningMethodHS256, secret, "", c)); !errors.Is(err, ErrUnauthenticated) {
+				t.Fatalf("expected ErrUnauthenticated, got %v", err)
+			}
+		})
+	}
+}
+
+func TestJWTVerifier_JWKS(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ks, err := NewKeySetFromFile(writeJWKS(t, rsaKey, ecKey))
+	if err != nil {
+		t.Fatalf("NewKeySetFromFile: %v", err)
+	}
+	v, err := NewJWTVerifier(JWTConfig{Issuer: testIssuer, Audience: testAudience, Keys: ks})
+	if err != nil {
+		t.Fatalf("NewJWTVerifier: %v", err)
+	}
+
+	if _, err := v.Verify(context.Background(), sign(t, jwt.SigningMethodRS256, rsaKey, "rsa-1", validClaims())); err != nil {
+		t.Fatalf("RS256: %v", err)
+	}
+	if _, err := v.Verify(context.Background(), sign(t, jwt.SigningMethodES256, ecKey, "ec-1", validClaims())); err != nil {
+		t.Fatalf("ES256: %v", err)
+	}
+	if _, err := v.Verify(context.Background(), sign(t, jwt.SigningMethodRS256, rsaKey, "unknown", validClaims())); !errors.Is(err, ErrUnauthenticated) {
+		t.Fatalf("expected unknown kid to fail, got %v", err)
+	}
+	// HS256 is not enabled without a secret, so the public key can't be abused as one.
+	if _, err := v.Verify(context.Background(), sign(t, jwt.SigningMethodHS256, []byte("x"), "rsa-1", validClaims())); !errors.Is(err, ErrUnauthenticated) {
+		t.Fatalf("expected HS256 to be rejected, got %v", err)
+	}
+}
+
+func TestJWTVerifier_CustomClaims(t *testing.T) {
+	secret := []byte("s3cret")
+	v, _ := NewJWTVerifier(JWTConfig{
+		Issuer:      testIssuer,
+		Audience:    testAudience,
+		HS256Secret: secret,
+		TenantClaim: "app.tenant",
+		UserClaim:   "https://example.com/user",
+		RolesClaim:  "scope",
+	})
+
+	c := validClaims()
+	c["app"] = map[string]any{"tenant": "tenant-2"}
+	c["https://example.com/user"] = "user-2"
+	c["scope"] = "inbox ingest"
+
+	p, err := v.Verify(context.Background(), sign(t, jwt.SigningMethodHS256, secret, "", c))
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if p.TenantID != "tenant-2" || p.UserID != "user-2" || p.Subject != "user-1" || !p.HasRole("ingest") {
+		t.Fatalf("unexpected principal %+v", p)
+	}
+}
+
+func TestKeySet_RefetchDoesNotBlockKnownKeys(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	raw, err := os.ReadFile(writeJWKS(t, rsaKey, ecKey))
+	if err != nil {
+		t.Fatalf("read jwks: %v", err)
+	}
+	keys, _ := ParseJWKS(raw)
+	delete(keys, "ec-1")
+
+	// A slow IdP that now also serves ec-1.
+	release := make(chan struct{})
+	fetches := 0
+	ks := &KeySet{
+		refresh: time.Minute,
+		keys:    keys,
+		fetch: func(context.Context) ([]byte, error) {
+			fetches++
+			<-release
+			return raw, nil
+		},
+	}
+
+	got := make(chan error, 2)
+	for range 2 {
+		go func() {
+			_, err := ks.Key(context.Background(), "ec-1")
+			got <- err
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	// Known keys are served while the fetch hangs.
+	if _, err := ks.Key(context.Background(), "rsa-1"); err != nil {
+		t.Fatalf("rsa-1: %v", err)
+	}
+	close(release)
+	for range 2 {
+		if err := <-got; err != nil {
+			t.Fatalf("ec-1: %v", err)
+		}
+	}
+	if fetches != 1 {
+		t.Fatalf("expected one shared fetch, got %d", fetches)
+	}
+}
+
+func TestParseJWKS_SkipsUnsupportedCurves(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	p384, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	raw, _ := json.Marshal(map[string]any{"keys": []map[string]any{
+		{"kty": "EC", "kid": "ec-1", "crv": "P-256", "x": b64(ecKey.X), "y": b64(ecKey.Y)},
+		{"kty": "EC", "kid": "ec-2", "crv": "P-384", "x": b64(p384.X), "y": b64(p384.Y)},
+	}})
+	keys, err := ParseJWKS(raw)
+	if err != nil {
+		t.Fatalf("ParseJWKS: %v", err)
+	}
+	if _, ok := keys["ec-1"]; !ok || len(keys) != 1 {
+		t.Fatalf("expected only ec-1, got %v", keys)
+	}
+
+	// A supported curve with a bad point still fails the set.
+	raw, _ = json.Marshal(map[string]any{"keys": []map[string]any{
+		{"kty": "EC", "kid": "ec-1", "crv": "P-256", "x": b64(p384.X), "y": b64(p384.Y)},
+	}})
+	if _, err := ParseJWKS(raw); err == nil {
+		t.Fatalf("expected an error for a point off the curve")
+	}
+}
@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"slices"
+)
+
+// ErrUnauthenticated wraps every credential failure; the message says why.
+var ErrUnauthenticated = errors.New("unauthenticated")
+
+// Principal is the authenticated caller.
+type Principal struct {
+	TenantID string
+	UserID   string
+	Subject  string
+	Roles    []string
+}
+
+func (p Principal) HasRole(role string) bool {
+	return slices.Contains(p.Roles, role)
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+func PrincipalFrom(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
@@ -0,0 +1,95 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"inbox-service/internal/application/ports"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type APIKeyStorePG struct {
+	pool *pgxpool.Pool
+}
+
+func NewAPIKeyStorePG(pool *pgxpool.Pool) *APIKeyStorePG {
+	return &APIKeyStorePG{pool: pool}
+}
+
+const apiKeyColumns = `id, name, key_prefix, tenant_ids::text[], event_types, created_at, revoked_at`
+
+func scanAPIKey(row pgx.Row) (ports.APIKey, error) {
+	var k ports.APIKey
+	err := row.Scan(&k.ID, &k.Name, &k.Prefix, &k.TenantIDs, &k.EventTypes, &k.CreatedAt, &k.RevokedAt)
+	return k, err
+}
+
+func (s *APIKeyStorePG) CreateAPIKey(ctx context.Context, tx ports.Tx, k ports.APIKey, hash []byte) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO api_keys (id, name, key_hash, key_prefix, tenant_ids, event_types, created_at)
+		VALUES ($1, $2, $3, $4, $5::uuid[], $6, $7)
+	`, k.ID, k.Name, hash, k.Prefix, k.TenantIDs, k.EventTypes, k.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("insert api key: %w", err)
+	}
+	return nil
+}
+
+func (s *APIKeyStorePG) ListAPIKeys(ctx context.Context, tenantID string) ([]ports.APIKey, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT `+apiKeyColumns+`
+		FROM api_keys
+		WHERE tenant_ids = ARRAY[$1::uuid]
+		ORDER BY created_at DESC, id DESC
+	`, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("list api keys: %w", err)
+	}
+	defer rows.Close()
+
+	var out []ports.APIKey
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan api key: %w", err)
+		}
+		out = append(out, k)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("api key rows err: %w", err)
+	}
+	return out, nil
+}
+
+func (s *APIKeyStorePG) FindAPIKey(ctx context.Context, hash []byte) (ports.APIKey, error) {
+	k, err := scanAPIKey(s.pool.QueryRow(ctx, `
+		SELECT `+apiKeyColumns+`
+		FROM api_keys
+		WHERE key_hash = $1 AND revoked_at IS NULL
+	`, hash))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ports.APIKey{}, ports.ErrNotFound
+	}
+	if err != nil {
+		return ports.APIKey{}, fmt.Errorf("find api key: %w", err)
+	}
+	return k, nil
+}
+
+func (s *APIKeyStorePG) RevokeAPIKey(ctx context.Context, tx ports.Tx, tenantID, id string, at time.Time) error {
+	tag, err := tx.Exec(ctx, `
+		UPDATE api_keys SET revoked_at = $3
+		WHERE id = $2 AND tenant_ids = ARRAY[$1::uuid] AND revoked_at IS NULL
+	`, tenantID, id, at)
+	if err != nil {
+		return fmt.Errorf("revoke api key: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ports.ErrNotFound
+	}
+	return nil
+}
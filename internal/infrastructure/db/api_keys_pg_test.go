@@ -0,0 +1,59 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"inbox-service/internal/application/apikeys"
+	"inbox-service/internal/application/ports"
+)
+
+func TestAPIKeys_CreateFindRevoke(t *testing.T) {
+	pool := newTestPool(t)
+	truncateAll(t, pool)
+
+	ctx := context.Background()
+	keys := apikeys.NewKeys(NewTxManagerPG(pool), NewAPIKeyStorePG(pool), NewAuditLogPG())
+
+	tenant := "aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa"
+	created, plain, err := keys.Create(ctx, "ops", tenant, apikeys.Input{Name: "tasks", TenantIDs: []string{tenant}, EventTypes: []string{"TaskAssignedToUser"}})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	got, err := keys.Authenticate(ctx, plain)
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if got.ID != created.ID || got.Prefix != created.Prefix || len(got.TenantIDs) != 1 || got.TenantIDs[0] != tenant || got.EventTypes[0] != "TaskAssignedToUser" {
+		t.Fatalf("unexpected key %+v", got)
+	}
+
+	other := "cccccccc-cccc-cccc-cccc-cccccccccccc"
+	if err := keys.Revoke(ctx, "ops", other, created.ID); !errors.Is(err, ports.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound revoking another tenant's key, got %v", err)
+	}
+	if err := keys.Revoke(ctx, "ops", tenant, created.ID); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	if _, err := keys.Authenticate(ctx, plain); !errors.Is(err, apikeys.ErrInvalidKey) {
+		t.Fatalf("expected a revoked key to be rejected, got %v", err)
+	}
+	if err := keys.Revoke(ctx, "ops", tenant, created.ID); !errors.Is(err, ports.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound revoking twice, got %v", err)
+	}
+
+	list, err := keys.List(ctx, tenant)
+	if err != nil || len(list) != 1 || list[0].RevokedAt == nil {
+		t.Fatalf("expected the revoked key listed: %v %+v", err, list)
+	}
+	if list, err := keys.List(ctx, other); err != nil || len(list) != 0 {
+		t.Fatalf("expected no keys for another tenant: %v %+v", err, list)
+	}
+
+	var audited int
+	if err := pool.QueryRow(ctx, `SELECT count(*) FROM admin_audit_log WHERE action LIKE 'apikey.%'`).Scan(&audited); err != nil || audited != 2 {
+		t.Fatalf("expected 2 audit entries: %v (%d)", err, audited)
+	}
+}
@@ -0,0 +1,29 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"inbox-service/internal/application/ports"
+)
+
+type AuditLogPG struct{}
+
+func NewAuditLogPG() *AuditLogPG { return &AuditLogPG{} }
+
+func (a *AuditLogPG) Record(ctx context.Context, tx ports.Tx, e ports.AuditEntry) error {
+	params, err := json.Marshal(e.Params)
+	if err != nil {
+		return fmt.Errorf("marshal audit params: %w", err)
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO admin_audit_log (actor, action, params, affected, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, e.Actor, e.Action, params, e.Affected, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("insert audit log: %w", err)
+	}
+	return nil
+}
@@ -24,29 +24,36 @@ func (r *FeedReaderPG) GetFeed(ctx context.Context, tenantID, userID string, f p
 		limit = 50
 	}
 
-	args := []any{tenantID, userID}
-	where := "WHERE tenant_id = $1 AND user_id = $2"
+	// Snoozed items stay hidden until they are due.
+	args := []any{tenantID, userID, time.Now().UTC()}
+	where := "WHERE tenant_id = $1 AND user_id = $2 AND (snooze_until IS NULL OR snooze_until <= $3)"
 
-	argN := 3
+	argN := 4
 	if f.Status != "" {
 		where += fmt.Sprintf(" AND status = $%d", argN)
 		args = append(args, f.Status)
 		argN++
 	}
 
+	if f.Type != "" {
+		where += fmt.Sprintf(" AND type = $%d", argN)
+		args = append(args, f.Type)
+		argN++
+	}
+
 	if f.Cursor != nil {
-		where += fmt.Sprintf(" AND (created_at, id) < ($%d, $%d)", argN, argN+1)
-		args = append(args, f.Cursor.CreatedAt, f.Cursor.ID)
+		where += fmt.Sprintf(" AND (sort_at, id) < ($%d, $%d)", argN, argN+1)
+		args = append(args, f.Cursor.SortAt, f.Cursor.ID)
 		argN += 2
 	}
 
 	args = append(args, limit)
 
 	q := fmt.Sprintf(`
-		SELECT id, type, status, title, body, action_url, created_at
+		SELECT id, type, status, title, body, action_url, version, created_at, sort_at
 		FROM inbox_items
 		%s
-		ORDER BY created_at DESC, id DESC
+		ORDER BY sort_at DESC, id DESC
 		LIMIT $%d
 	`, where, argN)
 
@@ -57,16 +64,15 @@ func (r *FeedReaderPG) GetFeed(ctx context.Context, tenantID, userID string, f p
 	defer rows.Close()
 
 	items := make([]ports.FeedItem, 0, limit)
-	var lastCreatedAt time.Time
+	var lastSortAt time.Time
 	var lastID string
 
 	for rows.Next() {
 		var it ports.FeedItem
-		if err := rows.Scan(&it.ID, &it.Type, &it.Status, &it.Title, &it.Body, &it.ActionURL, &it.CreatedAt); err != nil {
+		if err := rows.Scan(&it.ID, &it.Type, &it.Status, &it.Title, &it.Body, &it.ActionURL, &it.Version, &it.CreatedAt, &lastSortAt); err != nil {
 			return ports.FeedPage{}, fmt.Errorf("scan feed row: %w", err)
 		}
 		items = append(items, it)
-		lastCreatedAt = it.CreatedAt
 		lastID = it.ID
 	}
 
@@ -76,7 +82,7 @@ func (r *FeedReaderPG) GetFeed(ctx context.Context, tenantID, userID string, f p
 
 	var next *ports.FeedCursor
 	if len(items) == limit {
-		next = &ports.FeedCursor{CreatedAt: lastCreatedAt, ID: lastID}
+		next = &ports.FeedCursor{SortAt: lastSortAt, ID: lastID}
 	}
 
 	return ports.FeedPage{Items: items, NextCursor: next}, nil
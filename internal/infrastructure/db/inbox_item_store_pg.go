@@ -0,0 +1,155 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"inbox-service/internal/application/ports"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type InboxItemStorePG struct{}
+
+func NewInboxItemStorePG() *InboxItemStorePG { return &InboxItemStorePG{} }
+
+const inboxItemStateColumns = "id, tenant_id, user_id, type, status, snooze_until, version, updated_at"
+
+func inboxItemStateDest(it *ports.InboxItemState) []any {
+	return []any{&it.ID, &it.TenantID, &it.UserID, &it.Type, &it.Status, &it.SnoozeUntil, &it.Version, &it.UpdatedAt}
+}
+
+func (s *InboxItemStorePG) GetItem(ctx context.Context, tx ports.Tx, tenantID, userID, itemID string) (ports.InboxItemState, error) {
+	var it ports.InboxItemState
+	err := tx.QueryRow(ctx, `
+		SELECT `+inboxItemStateColumns+`
+		FROM inbox_items
+		WHERE tenant_id = $1 AND user_id = $2 AND id = $3
+	`, tenantID, userID, itemID).Scan(inboxItemStateDest(&it)...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ports.InboxItemState{}, fmt.Errorf("inbox item %s: %w", itemID, ports.ErrNotFound)
+	}
+	if err != nil {
+		return ports.InboxItemState{}, fmt.Errorf("get inbox item: %w", err)
+	}
+	return it, nil
+}
+
+func (s *InboxItemStorePG) UpdateStatus(ctx context.Context, tx ports.Tx, tenantID, itemID, status string, fromVersion int) (ports.InboxItemState, error) {
+	var it ports.InboxItemState
+	err := tx.QueryRow(ctx, `
+		UPDATE inbox_items
+		SET status = $3, version = version + 1, updated_at = $5
+		WHERE tenant_id = $1 AND id = $2 AND version = $4
+		RETURNING `+inboxItemStateColumns+`
+	`, tenantID, itemID, status, fromVersion, time.Now().UTC()).Scan(inboxItemStateDest(&it)...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ports.InboxItemState{}, fmt.Errorf("inbox item %s: %w", itemID, ports.ErrVersionConflict)
+	}
+	if err != nil {
+		return ports.InboxItemState{}, fmt.Errorf("update inbox item status: %w", err)
+	}
+	return it, nil
+}
+
+func (s *InboxItemStorePG) MarkReadBatch(ctx context.Context, tx ports.Tx, tenantID, userID string, f ports.BulkReadFilter, limit int) (map[string]int, error) {
+	args := []any{tenantID, userID, f.Before.SortAt, f.Before.ID, limit, time.Now().UTC()}
+	typeFilter := ""
+	if f.Type != "" {
+		typeFilter = " AND type = $7"
+		args = append(args, f.Type)
+	}
+
+	rows, err := tx.Query(ctx, fmt.Sprintf(`
+		WITH marked AS (
+			UPDATE inbox_items i
+			SET status = 'READ', version = i.version + 1, updated_at = $6
+			FROM (
+				SELECT id
+				FROM inbox_items
+				WHERE tenant_id = $1 AND user_id = $2 AND status = 'UNREAD'
+				  AND snooze_until IS NULL
+				  AND (sort_at, id) <= ($3, $4)%s
+				ORDER BY sort_at DESC, id DESC
+				LIMIT $5
+				FOR UPDATE
+			) batch
+			WHERE i.id = batch.id
+			RETURNING i.type
+		)
+		SELECT type, COUNT(*) FROM marked GROUP BY type
+	`, typeFilter), args...)
+	if err != nil {
+		return nil, fmt.Errorf("mark read batch: %w", err)
+	}
+	defer rows.Close()
+
+	out := map[string]int{}
+	for rows.Next() {
+		var typ string
+		var n int
+		if err := rows.Scan(&typ, &n); err != nil {
+			return nil, fmt.Errorf("scan mark read batch: %w", err)
+		}
+		out[typ] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("mark read batch rows err: %w", err)
+	}
+	return out, nil
+}
+
+func (s *InboxItemStorePG) SetSnooze(ctx context.Context, tx ports.Tx, tenantID, itemID string, until *time.Time, fromVersion int) (ports.InboxItemState, error) {
+	var it ports.InboxItemState
+	err := tx.QueryRow(ctx, `
+		UPDATE inbox_items
+		SET snooze_until = $3, version = version + 1, updated_at = $5
+		WHERE tenant_id = $1 AND id = $2 AND version = $4
+		RETURNING `+inboxItemStateColumns+`
+	`, tenantID, itemID, until, fromVersion, time.Now().UTC()).Scan(inboxItemStateDest(&it)...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ports.InboxItemState{}, fmt.Errorf("inbox item %s: %w", itemID, ports.ErrVersionConflict)
+	}
+	if err != nil {
+		return ports.InboxItemState{}, fmt.Errorf("set snooze: %w", err)
+	}
+	return it, nil
+}
+
+// WakeDue clears snooze_until on up to limit due items and moves them to the
+// top of the feed. SKIP LOCKED keeps concurrent wakers off each other's rows.
+func (s *InboxItemStorePG) WakeDue(ctx context.Context, tx ports.Tx, now time.Time, limit int) ([]ports.WokenItem, error) {
+	rows, err := tx.Query(ctx, `
+		UPDATE inbox_items i
+		SET snooze_until = NULL, sort_at = $1, version = i.version + 1, updated_at = $1
+		FROM (
+			SELECT id, snooze_until
+			FROM inbox_items
+			WHERE snooze_until IS NOT NULL AND snooze_until <= $1
+			ORDER BY snooze_until
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		) due
+		WHERE i.id = due.id
+		RETURNING i.id, i.tenant_id, i.user_id, i.type, i.status, i.snooze_until, i.version, i.updated_at, due.snooze_until
+	`, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("wake snoozed items: %w", err)
+	}
+	defer rows.Close()
+
+	var out []ports.WokenItem
+	for rows.Next() {
+		var w ports.WokenItem
+		if err := rows.Scan(append(inboxItemStateDest(&w.Item), &w.SnoozedUntil)...); err != nil {
+			return nil, fmt.Errorf("scan woken item: %w", err)
+		}
+		out = append(out, w)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("woken rows err: %w", err)
+	}
+	return out, nil
+}
@@ -19,7 +19,7 @@ func TestIngest_TaskAssigned_WritesAllTables(t *testing.T) {
 	deduper := NewEventDeduperPG()
 	outboxWriter := NewOutboxWriterPG()
 
-	h := ingest.NewHandler(txMgr, inboxWriter, deduper, outboxWriter)
+	h := ingest.NewHandler(txMgr, inboxWriter, deduper, outboxWriter, NewUnreadCounterPG(pool), NewStreamStorePG(pool), ingest.DefaultRegistry())
 
 	evt := ingest.TaskAssignedToUser{
 		EventID:        "99999999-9999-9999-9999-999999999999",
@@ -100,7 +100,7 @@ func TestIngest_TaskAssigned_IsIdempotent(t *testing.T) {
 	deduper := NewEventDeduperPG()
 	outboxWriter := NewOutboxWriterPG()
 
-	h := ingest.NewHandler(txMgr, inboxWriter, deduper, outboxWriter)
+	h := ingest.NewHandler(txMgr, inboxWriter, deduper, outboxWriter, NewUnreadCounterPG(pool), NewStreamStorePG(pool), ingest.DefaultRegistry())
 
 	evt := ingest.TaskAssignedToUser{
 		EventID:        "aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee",
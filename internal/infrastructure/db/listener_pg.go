@@ -0,0 +1,72 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ListenerPG holds one pooled connection in LISTEN mode and hands every
+// notification payload to a callback. It reconnects on errors; notifications
+// sent while disconnected are lost, so consumers must tolerate gaps.
+type ListenerPG struct {
+	pool    *pgxpool.Pool
+	channel string
+
+	// OnListening, if set, is called with true once LISTEN is active and with
+	// false when that connection is lost.
+	OnListening func(listening bool)
+
+	retryDelay time.Duration
+}
+
+func NewListenerPG(pool *pgxpool.Pool, channel string) *ListenerPG {
+	return &ListenerPG{pool: pool, channel: channel, retryDelay: time.Second}
+}
+
+// Run blocks until ctx is cancelled.
+func (l *ListenerPG) Run(ctx context.Context, onNotify func(payload string)) error {
+	for {
+		err := l.listen(ctx, onNotify)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		log.Printf("listen %s: %v; reconnecting", l.channel, err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(l.retryDelay):
+		}
+	}
+}
+
+func (l *ListenerPG) listen(ctx context.Context, onNotify func(payload string)) error {
+	pc, err := l.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire: %w", err)
+	}
+	// The connection carries LISTEN state, so take it out of the pool for good.
+	conn := pc.Hijack()
+	defer func() { _ = conn.Close(context.Background()) }()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{l.channel}.Sanitize()); err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+	if l.OnListening != nil {
+		l.OnListening(true)
+		defer l.OnListening(false)
+	}
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		onNotify(n.Payload)
+	}
+}
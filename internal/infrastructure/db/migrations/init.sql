@@ -10,42 +10,95 @@ CREATE TABLE IF NOT EXISTS inbox_items (
   body TEXT NOT NULL,
   action_url TEXT NOT NULL,
 
-  source_event_id UUID NOT NULL,
+  source_event_id TEXT NOT NULL,
   dedupe_key TEXT NOT NULL,
 
   snooze_until TIMESTAMPTZ NULL,
 
   created_at TIMESTAMPTZ NOT NULL,
   updated_at TIMESTAMPTZ NOT NULL,
+  -- feed position; starts at created_at, bumped when a snoozed item wakes up
+  sort_at TIMESTAMPTZ NOT NULL,
 
   version INT NOT NULL DEFAULT 1
 );
 
+-- Event ids used to be UUIDs; a no-op once the column is text.
+ALTER TABLE inbox_items ALTER COLUMN source_event_id TYPE TEXT;
+
+-- Items created before snooze have never been moved in the feed.
+ALTER TABLE inbox_items ADD COLUMN IF NOT EXISTS sort_at TIMESTAMPTZ;
+UPDATE inbox_items SET sort_at = created_at WHERE sort_at IS NULL;
+ALTER TABLE inbox_items ALTER COLUMN sort_at SET NOT NULL;
+
 CREATE UNIQUE INDEX IF NOT EXISTS ux_inbox_items_tenant_dedupe
   ON inbox_items (tenant_id, dedupe_key);
 
-    CREATE INDEX IF NOT EXISTS ix_inbox_items_feed_status
-  ON inbox_items (tenant_id, user_id, status, created_at DESC, id DESC);
+-- The feed indexes used to be on created_at.
+DROP INDEX IF EXISTS ix_inbox_items_feed_status;
+DROP INDEX IF EXISTS ix_inbox_items_feed_all;
+
+CREATE INDEX IF NOT EXISTS ix_inbox_items_feed_status_sort
+  ON inbox_items (tenant_id, user_id, status, sort_at DESC, id DESC);
 
-CREATE INDEX IF NOT EXISTS ix_inbox_items_feed_all
-  ON inbox_items (tenant_id, user_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS ix_inbox_items_feed_all_sort
+  ON inbox_items (tenant_id, user_id, sort_at DESC, id DESC);
+
+CREATE INDEX IF NOT EXISTS ix_inbox_items_snoozed
+  ON inbox_items (snooze_until)
+  WHERE snooze_until IS NOT NULL;
+
+-- Unread badge counts: items that are UNREAD and not snoozed.
+-- Maintained transactionally by writers, repaired by a reconcile job.
+CREATE TABLE IF NOT EXISTS inbox_unread_counters (
+  tenant_id UUID NOT NULL,
+  user_id UUID NOT NULL,
+  type TEXT NOT NULL,
+  unread_count INT NOT NULL DEFAULT 0,
+  updated_at TIMESTAMPTZ NOT NULL,
+  PRIMARY KEY (tenant_id, user_id, type)
+);
+
+-- Per-user change log for live (SSE) clients; seq doubles as the SSE event id.
+-- Writers take a per-user advisory lock before drawing seq, so within a user
+-- seq order is commit order.
+CREATE TABLE IF NOT EXISTS inbox_stream_events (
+  seq BIGSERIAL PRIMARY KEY,
+  tenant_id UUID NOT NULL,
+  user_id UUID NOT NULL,
+  kind TEXT NOT NULL,
+  data JSONB NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS ix_inbox_stream_events_user
+  ON inbox_stream_events (tenant_id, user_id, seq);
 
 CREATE TABLE IF NOT EXISTS processed_events (
   tenant_id UUID NOT NULL,
-  event_id UUID NOT NULL,
+  event_id TEXT NOT NULL,
   processed_at TIMESTAMPTZ NOT NULL,
   PRIMARY KEY (tenant_id, event_id)
 );
 
+ALTER TABLE processed_events ALTER COLUMN event_id TYPE TEXT;
+
 CREATE TABLE IF NOT EXISTS outbox (
   id UUID PRIMARY KEY,
   tenant_id UUID NOT NULL,
   event_type TEXT NOT NULL,
   payload_json JSONB NOT NULL,
+  -- Transport headers: correlation-id, causation-id, traceparent.
+  headers JSONB NOT NULL DEFAULT '{}',
 
-  status TEXT NOT NULL, -- PENDING | PROCESSING | SENT | FAILED
+  -- Rows with the same key are published in seq order (see outbox_keys).
+  partition_key TEXT NOT NULL,
+  seq BIGINT NOT NULL,
+
+  status TEXT NOT NULL, -- PENDING | PROCESSING | SENT | FAILED | SKIPPED
   attempts INT NOT NULL DEFAULT 0,
-  next_run_at TIMESTAMPTZ NOT NULL,
+  next_run_at TIMESTAMPTZ NOT NULL, -- PROCESSING: lease expiry
+  last_error TEXT NULL,
 
   created_at TIMESTAMPTZ NOT NULL,
   updated_at TIMESTAMPTZ NOT NULL,
@@ -53,5 +106,163 @@ CREATE TABLE IF NOT EXISTS outbox (
   version INT NOT NULL DEFAULT 1
 );
 
+-- Outboxes created before headers, per-key ordering or error reporting:
+-- older rows are keyed by tenant and numbered in creation order.
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS headers JSONB NOT NULL DEFAULT '{}';
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS partition_key TEXT;
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS seq BIGINT;
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS last_error TEXT;
+UPDATE outbox SET partition_key = tenant_id::text WHERE partition_key IS NULL;
+UPDATE outbox o
+SET seq = n.seq
+FROM (
+  SELECT id, row_number() OVER (PARTITION BY partition_key ORDER BY created_at, id) AS seq
+  FROM outbox
+) n
+WHERE o.id = n.id AND o.seq IS NULL;
+ALTER TABLE outbox ALTER COLUMN partition_key SET NOT NULL;
+ALTER TABLE outbox ALTER COLUMN seq SET NOT NULL;
+
 CREATE INDEX IF NOT EXISTS ix_outbox_pending
   ON outbox (status, next_run_at, created_at);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_outbox_partition_seq
+  ON outbox (partition_key, seq);
+
+-- Last sequence number handed out per partition key. Writers lock the row
+-- until commit, which keeps commit order equal to seq order within a key.
+CREATE TABLE IF NOT EXISTS outbox_keys (
+  partition_key TEXT PRIMARY KEY,
+  last_seq BIGINT NOT NULL
+);
+
+-- Continue existing keys after their highest seq.
+INSERT INTO outbox_keys (partition_key, last_seq)
+SELECT partition_key, max(seq) FROM outbox GROUP BY partition_key
+ON CONFLICT (partition_key) DO UPDATE
+SET last_seq = GREATEST(outbox_keys.last_seq, EXCLUDED.last_seq);
+
+-- One row per publish attempt, for the admin API.
+CREATE TABLE IF NOT EXISTS outbox_attempts (
+  id BIGSERIAL PRIMARY KEY,
+  outbox_id UUID NOT NULL REFERENCES outbox(id) ON DELETE CASCADE,
+  attempt INT NOT NULL,
+  outcome TEXT NOT NULL, -- SENT | RETRY | FAILED
+  error TEXT NULL,
+  created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS ix_outbox_attempts_outbox
+  ON outbox_attempts (outbox_id, id);
+
+CREATE INDEX IF NOT EXISTS ix_outbox_admin
+  ON outbox (created_at DESC, id DESC);
+
+-- Retention picks finished rows by age.
+CREATE INDEX IF NOT EXISTS ix_outbox_retention
+  ON outbox (status, updated_at)
+  WHERE status IN ('SENT', 'FAILED', 'SKIPPED');
+
+-- Rows moved out of the outbox by the retention job. Monthly partitions
+-- (outbox_archive_YYYY_MM) are created by the job as needed.
+CREATE TABLE IF NOT EXISTS outbox_archive (
+  id UUID NOT NULL,
+  tenant_id UUID NOT NULL,
+  event_type TEXT NOT NULL,
+  payload_json JSONB NOT NULL,
+  headers JSONB NOT NULL DEFAULT '{}',
+  partition_key TEXT NOT NULL,
+  seq BIGINT NOT NULL,
+  status TEXT NOT NULL,
+  attempts INT NOT NULL,
+  last_error TEXT NULL,
+  created_at TIMESTAMPTZ NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL,
+  archived_at TIMESTAMPTZ NOT NULL,
+  PRIMARY KEY (id, created_at)
+) PARTITION BY RANGE (created_at);
+
+CREATE TABLE IF NOT EXISTS admin_audit_log (
+  id BIGSERIAL PRIMARY KEY,
+  actor TEXT NOT NULL,
+  action TEXT NOT NULL,
+  params JSONB NOT NULL,
+  affected INT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL
+);
+
+-- Tenant-registered webhook endpoints. An empty event_types matches all.
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+  id UUID PRIMARY KEY,
+  tenant_id UUID NOT NULL,
+  url TEXT NOT NULL,
+  event_types TEXT[] NOT NULL DEFAULT '{}',
+  secret TEXT NOT NULL,
+  -- Still signs deliveries until it expires, after a rotation.
+  previous_secret TEXT NULL,
+  previous_secret_expires_at TIMESTAMPTZ NULL,
+  enabled BOOLEAN NOT NULL DEFAULT true,
+  created_at TIMESTAMPTZ NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS ix_webhook_subscriptions_tenant
+  ON webhook_subscriptions (tenant_id, created_at);
+
+-- One row per outbox event and matching subscription, with its own retries.
+-- The event is copied, since the outbox row may be archived first.
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+  id UUID PRIMARY KEY,
+  subscription_id UUID NOT NULL REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+  tenant_id UUID NOT NULL,
+  outbox_id UUID NOT NULL,
+  event_type TEXT NOT NULL,
+  message_key TEXT NOT NULL,
+  headers JSONB NOT NULL DEFAULT '{}',
+  payload_json JSONB NOT NULL,
+
+  status TEXT NOT NULL, -- PENDING | PROCESSING | SENT | FAILED
+  attempts INT NOT NULL DEFAULT 0,
+  next_run_at TIMESTAMPTZ NOT NULL, -- PROCESSING: lease expiry
+  last_error TEXT NULL,
+  last_status_code INT NULL,
+
+  created_at TIMESTAMPTZ NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL,
+  version INT NOT NULL DEFAULT 1,
+
+  UNIQUE (subscription_id, outbox_id)
+);
+
+CREATE INDEX IF NOT EXISTS ix_webhook_deliveries_due
+  ON webhook_deliveries (next_run_at)
+  WHERE status IN ('PENDING', 'PROCESSING');
+
+CREATE INDEX IF NOT EXISTS ix_webhook_deliveries_subscription
+  ON webhook_deliveries (subscription_id, created_at DESC, id DESC);
+
+CREATE TABLE IF NOT EXISTS webhook_attempts (
+  id BIGSERIAL PRIMARY KEY,
+  delivery_id UUID NOT NULL REFERENCES webhook_deliveries(id) ON DELETE CASCADE,
+  attempt INT NOT NULL,
+  status_code INT NULL,
+  error TEXT NULL,
+  duration_ms INT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS ix_webhook_attempts_delivery
+  ON webhook_attempts (delivery_id, id);
+
+-- Service credentials for the ingest endpoints. Only the SHA-256 of the key
+-- is kept; key_prefix lets operators tell keys apart.
+CREATE TABLE IF NOT EXISTS api_keys (
+  id UUID PRIMARY KEY,
+  name TEXT NOT NULL,
+  key_hash BYTEA NOT NULL UNIQUE,
+  key_prefix TEXT NOT NULL,
+  tenant_ids UUID[] NOT NULL,
+  event_types TEXT[] NOT NULL DEFAULT '{}', -- empty: all types
+  created_at TIMESTAMPTZ NOT NULL,
+  revoked_at TIMESTAMPTZ NULL
+);
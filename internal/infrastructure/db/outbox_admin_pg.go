@@ -0,0 +1,206 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"inbox-service/internal/application/ports"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type OutboxAdminStorePG struct {
+	pool *pgxpool.Pool
+}
+
+func NewOutboxAdminStorePG(pool *pgxpool.Pool) *OutboxAdminStorePG {
+	return &OutboxAdminStorePG{pool: pool}
+}
+
+const outboxAdminColumns = `id, tenant_id, event_type, partition_key, seq, headers, status, attempts, next_run_at, COALESCE(last_error, ''), created_at, updated_at, version`
+
+func (s *OutboxAdminStorePG) ListOutbox(ctx context.Context, f ports.OutboxFilter, after *ports.OutboxPosition, limit int) ([]ports.OutboxRow, error) {
+	where, args := outboxWhere(f)
+	if after != nil {
+		args = append(args, after.CreatedAt, after.ID)
+		where = append(where, fmt.Sprintf("(created_at, id) < ($%d, $%d)", len(args)-1, len(args)))
+	}
+	args = append(args, limit)
+
+	rows, err := s.pool.Query(ctx, `
+		SELECT `+outboxAdminColumns+`
+		FROM outbox
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY created_at DESC, id DESC
+		LIMIT $`+fmt.Sprint(len(args)), args...)
+	if err != nil {
+		return nil, fmt.Errorf("list outbox: %w", err)
+	}
+	defer rows.Close()
+
+	var out []ports.OutboxRow
+	for rows.Next() {
+		var r ports.OutboxRow
+		if err := rows.Scan(&r.ID, &r.TenantID, &r.EventType, &r.PartitionKey, &r.Seq, &r.Headers, &r.Status, &r.Attempts, &r.NextRunAt, &r.LastError, &r.CreatedAt, &r.UpdatedAt, &r.Version); err != nil {
+			return nil, fmt.Errorf("scan outbox row: %w", err)
+		}
+		out = append(out, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("outbox rows err: %w", err)
+	}
+	return out, nil
+}
+
+func (s *OutboxAdminStorePG) GetOutbox(ctx context.Context, id string) (ports.OutboxRow, []ports.OutboxAttempt, error) {
+	var r ports.OutboxRow
+	err := s.pool.QueryRow(ctx, `
+		SELECT `+outboxAdminColumns+`, payload_json
+		FROM outbox
+		WHERE id = $1
+	`, id).Scan(&r.ID, &r.TenantID, &r.EventType, &r.PartitionKey, &r.Seq, &r.Headers, &r.Status, &r.Attempts, &r.NextRunAt, &r.LastError, &r.CreatedAt, &r.UpdatedAt, &r.Version, &r.Payload)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ports.OutboxRow{}, nil, ports.ErrNotFound
+	}
+	if err != nil {
+		return ports.OutboxRow{}, nil, fmt.Errorf("get outbox: %w", err)
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		SELECT attempt, outcome, COALESCE(error, ''), created_at
+		FROM outbox_attempts
+		WHERE outbox_id = $1
+		ORDER BY id
+	`, r.ID)
+	if err != nil {
+		return ports.OutboxRow{}, nil, fmt.Errorf("list outbox attempts: %w", err)
+	}
+	defer rows.Close()
+
+	var attempts []ports.OutboxAttempt
+	for rows.Next() {
+		var a ports.OutboxAttempt
+		if err := rows.Scan(&a.Attempt, &a.Outcome, &a.Error, &a.CreatedAt); err != nil {
+			return ports.OutboxRow{}, nil, fmt.Errorf("scan outbox attempt: %w", err)
+		}
+		attempts = append(attempts, a)
+	}
+	if err := rows.Err(); err != nil {
+		return ports.OutboxRow{}, nil, fmt.Errorf("outbox attempts err: %w", err)
+	}
+	return r, attempts, nil
+}
+
+// ListBlockedKeys finds each key's oldest FAILED row; ClaimBatch doesn't
+// claim past it.
+func (s *OutboxAdminStorePG) ListBlockedKeys(ctx context.Context, limit int) ([]ports.BlockedKey, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT f.partition_key, f.tenant_id, f.id, f.seq, f.updated_at, COALESCE(f.last_error, ''),
+		       (SELECT count(*) FROM outbox w
+		        WHERE w.partition_key = f.partition_key AND w.seq > f.seq
+		          AND w.status IN ('PENDING', 'PROCESSING'))
+		FROM outbox f
+		WHERE f.status = 'FAILED'
+		  AND NOT EXISTS (
+			SELECT 1 FROM outbox p
+			WHERE p.partition_key = f.partition_key AND p.seq < f.seq AND p.status = 'FAILED'
+		  )
+		ORDER BY f.updated_at, f.partition_key
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list blocked keys: %w", err)
+	}
+	defer rows.Close()
+
+	var out []ports.BlockedKey
+	for rows.Next() {
+		var k ports.BlockedKey
+		if err := rows.Scan(&k.PartitionKey, &k.TenantID, &k.FailedID, &k.FailedSeq, &k.FailedAt, &k.LastError, &k.Waiting); err != nil {
+			return nil, fmt.Errorf("scan blocked key: %w", err)
+		}
+		out = append(out, k)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("blocked keys err: %w", err)
+	}
+	return out, nil
+}
+
+func (s *OutboxAdminStorePG) RequeueOutbox(ctx context.Context, tx ports.Tx, f ports.OutboxFilter, now time.Time) (int, error) {
+	f.Status = ports.OutboxFailed
+	where, args := outboxWhere(f)
+	args = append(args, now)
+	tag, err := tx.Exec(ctx, `
+		UPDATE outbox
+		SET status = 'PENDING', attempts = 0, next_run_at = $`+fmt.Sprint(len(args))+`, last_error = NULL,
+		    updated_at = $`+fmt.Sprint(len(args))+`, version = version + 1
+		WHERE `+strings.Join(where, " AND "), args...)
+	if err != nil {
+		return 0, fmt.Errorf("requeue outbox: %w", err)
+	}
+	if tag.RowsAffected() > 0 {
+		// Let a listening relay pick them up right away.
+		if _, err := tx.Exec(ctx, `SELECT pg_notify($1, '')`, OutboxChannel); err != nil {
+			return 0, fmt.Errorf("notify outbox: %w", err)
+		}
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+func (s *OutboxAdminStorePG) SkipOutbox(ctx context.Context, tx ports.Tx, f ports.OutboxFilter, now time.Time) (int, error) {
+	where, args := outboxWhere(f)
+	if f.Status == "" {
+		where = append(where, "status IN ('PENDING', 'FAILED')")
+	}
+	args = append(args, now)
+	tag, err := tx.Exec(ctx, `
+		UPDATE outbox
+		SET status = 'SKIPPED', updated_at = $`+fmt.Sprint(len(args))+`, version = version + 1
+		WHERE `+strings.Join(where, " AND "), args...)
+	if err != nil {
+		return 0, fmt.Errorf("skip outbox: %w", err)
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+func (s *OutboxAdminStorePG) PurgeSentOutbox(ctx context.Context, tx ports.Tx, olderThan time.Time) (int, error) {
+	tag, err := tx.Exec(ctx, `DELETE FROM outbox WHERE status = 'SENT' AND updated_at < $1`, olderThan)
+	if err != nil {
+		return 0, fmt.Errorf("purge outbox: %w", err)
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+// outboxWhere turns a filter into AND-ed conditions and their arguments.
+func outboxWhere(f ports.OutboxFilter) ([]string, []any) {
+	where := []string{"TRUE"}
+	var args []any
+	add := func(cond string, v any) {
+		args = append(args, v)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+	if len(f.IDs) > 0 {
+		add("id = ANY($%d::uuid[])", f.IDs)
+	}
+	if f.Status != "" {
+		add("status = $%d", f.Status)
+	}
+	if f.TenantID != "" {
+		add("tenant_id = $%d", f.TenantID)
+	}
+	if f.EventType != "" {
+		add("event_type = $%d", f.EventType)
+	}
+	if f.CreatedBefore != nil {
+		add("created_at < $%d", *f.CreatedBefore)
+	}
+	if f.CreatedAfter != nil {
+		add("created_at >= $%d", *f.CreatedAfter)
+	}
+	return where, args
+}
@@ -0,0 +1,112 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"inbox-service/internal/application/admin"
+	"inbox-service/internal/application/ports"
+)
+
+func TestOutboxAdmin_RequeueSkipPurgeWithHistoryAndAudit(t *testing.T) {
+	pool := newTestPool(t)
+	truncateAll(t, pool)
+
+	ctx := context.Background()
+	s := NewOutboxStorePG(pool)
+	a := admin.NewOutboxAdmin(NewTxManagerPG(pool), NewOutboxAdminStorePG(pool), NewAuditLogPG())
+
+	failed := "44444444-4444-4444-4444-444444444444"
+	pending := "55555555-5555-5555-5555-555555555555"
+	insertOutbox(t, pool, failed)
+
+	now := time.Now().UTC().Add(time.Second)
+	recs, err := s.ClaimBatch(ctx, now, now.Add(time.Minute), 10)
+	if err != nil || len(recs) != 1 {
+		t.Fatalf("ClaimBatch: %v (%d rows)", err, len(recs))
+	}
+	if err := s.Reschedule(ctx, recs[0], 1, now, "timeout"); err != nil {
+		t.Fatalf("Reschedule: %v", err)
+	}
+	recs, err = s.ClaimBatch(ctx, now, now.Add(time.Minute), 10)
+	if err != nil || len(recs) != 1 {
+		t.Fatalf("ClaimBatch: %v (%d rows)", err, len(recs))
+	}
+	if err := s.MarkFailed(ctx, recs[0], 2, "broker down"); err != nil {
+		t.Fatalf("MarkFailed: %v", err)
+	}
+	insertOutbox(t, pool, pending)
+
+	// The pending row shares the failed row's key, which is parked.
+	keys, err := a.BlockedKeys(ctx, 10)
+	if err != nil {
+		t.Fatalf("BlockedKeys: %v", err)
+	}
+	if len(keys) != 1 || keys[0].FailedID != failed || keys[0].Waiting != 1 || keys[0].LastError != "broker down" {
+		t.Fatalf("expected the key parked behind %s with 1 waiting, got %+v", failed, keys)
+	}
+
+	page, err := a.List(ctx, admin.ListOutboxQuery{Filter: ports.OutboxFilter{Status: ports.OutboxFailed}})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(page.Rows) != 1 || page.Rows[0].ID != failed || page.Rows[0].LastError != "broker down" {
+		t.Fatalf("expected the failed row, got %+v", page.Rows)
+	}
+
+	row, attempts, err := a.Get(ctx, failed)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if len(row.Payload) == 0 {
+		t.Fatalf("expected the payload")
+	}
+	if len(attempts) != 2 || attempts[0].Outcome != "RETRY" || attempts[1].Outcome != "FAILED" || attempts[1].Error != "broker down" {
+		t.Fatalf("unexpected attempt history %+v", attempts)
+	}
+	if _, _, err := a.Get(ctx, "66666666-6666-6666-6666-666666666666"); !errors.Is(err, ports.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	n, err := a.Requeue(ctx, "ops", ports.OutboxFilter{})
+	if err != nil || n != 1 {
+		t.Fatalf("Requeue: %d / %v", n, err)
+	}
+	if status, attempts := outboxStatus(t, pool, failed); status != "PENDING" || attempts != 0 {
+		t.Fatalf("expected PENDING/0 after requeue, got %s/%d", status, attempts)
+	}
+
+	n, err = a.Skip(ctx, "ops", ports.OutboxFilter{IDs: []string{pending}})
+	if err != nil || n != 1 {
+		t.Fatalf("Skip: %d / %v", n, err)
+	}
+	if status, _ := outboxStatus(t, pool, pending); status != "SKIPPED" {
+		t.Fatalf("expected SKIPPED, got %s", status)
+	}
+
+	// Publish the requeued row and age it past the purge cutoff.
+	recs, err = s.ClaimBatch(ctx, now, now.Add(time.Minute), 10)
+	if err != nil || len(recs) != 1 {
+		t.Fatalf("ClaimBatch: %v (%d rows)", err, len(recs))
+	}
+	if err := s.MarkSent(ctx, recs[0]); err != nil {
+		t.Fatalf("MarkSent: %v", err)
+	}
+	if _, err := pool.Exec(ctx, `UPDATE outbox SET updated_at = now() - interval '10 days' WHERE id = $1`, failed); err != nil {
+		t.Fatalf("age row: %v", err)
+	}
+	n, err = a.PurgeSent(ctx, "ops", 7)
+	if err != nil || n != 1 {
+		t.Fatalf("PurgeSent: %d / %v", n, err)
+	}
+
+	var audited int
+	if err := pool.QueryRow(ctx, `SELECT COUNT(*) FROM admin_audit_log WHERE actor = 'ops'`).Scan(&audited); err != nil {
+		t.Fatalf("count audit: %v", err)
+	}
+	if audited != 3 {
+		t.Fatalf("expected 3 audit entries, got %d", audited)
+	}
+}
@@ -0,0 +1,482 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"inbox-service/internal/application/outbox"
+	"inbox-service/internal/application/ports"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgproto3"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type CDCConfig struct {
+	// Slot and Publication are created on first start if missing.
+	Slot        string
+	Publication string
+	// StatusInterval is how often progress is reported to the server while
+	// idle; it must stay below the server's wal_sender_timeout.
+	StatusInterval time.Duration
+	// Publish failures block the stream (to keep commit order) and are
+	// retried with backoff.
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+}
+
+// OutboxCDCRelayPG publishes outbox rows by streaming inserts from a logical
+// replication slot (pgoutput) instead of polling the table. Rows are published
+// in commit order, one transaction at a time, and the slot position is
+// confirmed only after the publish succeeded and the rows were marked SENT;
+// after a crash the server resends everything past the confirmed position, so
+// delivery is at-least-once.
+//
+// Updates that leave a row PENDING (an admin requeue) publish it again. When
+// the slot is created, rows that were already PENDING are published from the
+// table first, since the slot only sees later changes.
+//
+// The slot retains WAL until confirmed: a relay that is down for long makes
+// the server keep WAL. Requires wal_level=logical.
+type OutboxCDCRelayPG struct {
+	pool    *pgxpool.Pool
+	connStr string
+	pub     ports.EventPublisher
+	cfg     CDCConfig
+}
+
+// cdcBackfillBatch is how many rows a backfill publishes at a time.
+const cdcBackfillBatch = 500
+
+func NewOutboxCDCRelayPG(pool *pgxpool.Pool, connStr string, pub ports.EventPublisher, cfg CDCConfig) *OutboxCDCRelayPG {
+	if cfg.Slot == "" {
+		cfg.Slot = "inbox_outbox"
+	}
+	if cfg.Publication == "" {
+		cfg.Publication = "inbox_outbox"
+	}
+	if cfg.StatusInterval <= 0 {
+		cfg.StatusInterval = 10 * time.Second
+	}
+	if cfg.BaseBackoff <= 0 {
+		cfg.BaseBackoff = time.Second
+	}
+	if cfg.MaxBackoff < cfg.BaseBackoff {
+		cfg.MaxBackoff = time.Minute
+	}
+	return &OutboxCDCRelayPG{pool: pool, connStr: connStr, pub: pub, cfg: cfg}
+}
+
+// Run streams until ctx is cancelled, reconnecting on errors.
+func (r *OutboxCDCRelayPG) Run(ctx context.Context) error {
+	for attempt := 1; ; attempt++ {
+		err := r.stream(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		log.Printf("outbox cdc: %v; reconnecting", err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(outbox.Backoff(attempt, r.cfg.BaseBackoff, r.cfg.MaxBackoff)):
+		}
+	}
+}
+
+// ensureSlot creates the publication and the slot if they don't exist yet. A
+// new slot is backfilled with the rows that are already PENDING; if that
+// fails the slot is dropped again, so the next start backfills from scratch.
+func (r *OutboxCDCRelayPG) ensureSlot(ctx context.Context) error {
+	pub := pgx.Identifier{r.cfg.Publication}.Sanitize()
+	var exists bool
+	if err := r.pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM pg_publication WHERE pubname = $1)`, r.cfg.Publication).Scan(&exists); err != nil {
+		return fmt.Errorf("check publication: %w", err)
+	}
+	if !exists {
+		if _, err := r.pool.Exec(ctx, `CREATE PUBLICATION `+pub+` FOR TABLE outbox WITH (publish = 'insert, update')`); err != nil {
+			return fmt.Errorf("create publication: %w", err)
+		}
+	} else if _, err := r.pool.Exec(ctx, `ALTER PUBLICATION `+pub+` SET (publish = 'insert, update')`); err != nil {
+		// Publications created before updates were streamed only had inserts.
+		return fmt.Errorf("alter publication: %w", err)
+	}
+
+	if err := r.pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM pg_replication_slots WHERE slot_name = $1)`, r.cfg.Slot).Scan(&exists); err != nil {
+		return fmt.Errorf("check slot: %w", err)
+	}
+	if exists {
+		return nil
+	}
+	if _, err := r.pool.Exec(ctx, `SELECT pg_create_logical_replication_slot($1, 'pgoutput')`, r.cfg.Slot); err != nil {
+		return fmt.Errorf("create slot: %w", err)
+	}
+	log.Printf("outbox cdc: created slot %s", r.cfg.Slot)
+
+	if err := r.backfill(ctx); err != nil {
+		if _, dropErr := r.pool.Exec(context.Background(), `SELECT pg_drop_replication_slot($1)`, r.cfg.Slot); dropErr != nil {
+			log.Printf("outbox cdc: drop slot %s after failed backfill: %v", r.cfg.Slot, dropErr)
+		}
+		return fmt.Errorf("backfill: %w", err)
+	}
+	return nil
+}
+
+// backfill publishes the rows that were PENDING when the slot was created, in
+// key order. Rows committed after that are in the stream as well, so one may
+// be published twice; consumers already dedupe on event_id.
+func (r *OutboxCDCRelayPG) backfill(ctx context.Context) error {
+	rows, err := r.pool.Query(ctx, `SELECT id FROM outbox WHERE status = 'PENDING' ORDER BY partition_key, seq`)
+	if err != nil {
+		return fmt.Errorf("list pending: %w", err)
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return fmt.Errorf("list pending: %w", err)
+	}
+
+	for len(ids) > 0 {
+		n := min(len(ids), cdcBackfillBatch)
+		recs, err := r.loadPending(ctx, ids[:n])
+		if err != nil {
+			return err
+		}
+		if len(recs) > 0 {
+			if err := r.publish(ctx, recs, nil); err != nil {
+				return err
+			}
+		}
+		ids = ids[n:]
+	}
+	return nil
+}
+
+// loadPending reads the rows among ids that are still PENDING, in key order.
+func (r *OutboxCDCRelayPG) loadPending(ctx context.Context, ids []string) ([]ports.OutboxRecord, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, tenant_id, event_type, partition_key, seq, headers, payload_json, attempts, version, created_at
+		FROM outbox
+		WHERE id = ANY($1::uuid[]) AND status = 'PENDING'
+		ORDER BY partition_key, seq
+	`, ids)
+	if err != nil {
+		return nil, fmt.Errorf("load outbox rows: %w", err)
+	}
+	defer rows.Close()
+
+	var out []ports.OutboxRecord
+	for rows.Next() {
+		var rec ports.OutboxRecord
+		if err := rows.Scan(&rec.ID, &rec.TenantID, &rec.EventType, &rec.PartitionKey, &rec.Seq, &rec.Headers, &rec.Payload, &rec.Attempts, &rec.Version, &rec.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan outbox row: %w", err)
+		}
+		out = append(out, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("outbox rows err: %w", err)
+	}
+	return out, nil
+}
+
+// stream runs one replication connection until it fails or ctx ends.
+func (r *OutboxCDCRelayPG) stream(ctx context.Context) error {
+	if err := r.ensureSlot(ctx); err != nil {
+		return err
+	}
+
+	cfg, err := pgconn.ParseConfig(r.connStr)
+	if err != nil {
+		return fmt.Errorf("parse db url: %w", err)
+	}
+	cfg.RuntimeParams["replication"] = "database"
+	conn, err := pgconn.ConnectConfig(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("replication connect: %w", err)
+	}
+	defer func() { _ = conn.Close(context.Background()) }()
+
+	// 0/0 resumes from the slot's confirmed position.
+	conn.Frontend().Send(&pgproto3.Query{String: startReplicationSQL(r.cfg.Slot, r.cfg.Publication)})
+	if err := conn.Frontend().Flush(); err != nil {
+		return fmt.Errorf("start replication: %w", err)
+	}
+	for started := false; !started; {
+		msg, err := conn.ReceiveMessage(ctx)
+		if err != nil {
+			return fmt.Errorf("start replication: %w", err)
+		}
+		switch m := msg.(type) {
+		case *pgproto3.CopyBothResponse:
+			started = true
+		case *pgproto3.ErrorResponse:
+			return fmt.Errorf("start replication: %w", pgconn.ErrorResponseToPgError(m))
+		}
+	}
+	log.Printf("outbox cdc: streaming slot %s", r.cfg.Slot)
+
+	s := &cdcSession{relay: r, conn: conn, types: pgtype.NewMap(), relations: map[uint32]relationMessage{}}
+	return s.run(ctx)
+}
+
+// cdcSession is the state of one replication connection.
+type cdcSession struct {
+	relay *OutboxCDCRelayPG
+	conn  *pgconn.PgConn
+	types *pgtype.Map
+
+	relations map[uint32]relationMessage
+	inTx      bool
+	pending   []ports.OutboxRecord
+	// requeued are rows an update in this transaction left PENDING; they are
+	// read back from the table on commit, since the stream may leave out
+	// unchanged TOASTed columns such as the payload.
+	requeued  []string
+	confirmed LSN
+}
+
+func (s *cdcSession) run(ctx context.Context) error {
+	nextStatus := time.Now().Add(s.relay.cfg.StatusInterval)
+	for {
+		if time.Now().After(nextStatus) {
+			if err := s.sendStatus(); err != nil {
+				return err
+			}
+			nextStatus = time.Now().Add(s.relay.cfg.StatusInterval)
+		}
+
+		recvCtx, cancel := context.WithDeadline(ctx, nextStatus)
+		msg, err := s.conn.ReceiveMessage(recvCtx)
+		cancel()
+		if err != nil {
+			if pgconn.Timeout(err) && ctx.Err() == nil {
+				continue
+			}
+			return err
+		}
+
+		switch m := msg.(type) {
+		case *pgproto3.CopyData:
+			if len(m.Data) == 0 {
+				continue
+			}
+			switch m.Data[0] {
+			case 'k':
+				ka, err := parsePrimaryKeepalive(m.Data[1:])
+				if err != nil {
+					return err
+				}
+				// Everything before a keepalive has been received and, outside
+				// a transaction, handled; it is safe to let the slot move on.
+				if !s.inTx && ka.ServerWALEnd > s.confirmed {
+					s.confirmed = ka.ServerWALEnd
+				}
+				if ka.ReplyRequested {
+					nextStatus = time.Time{}
+				}
+			case 'w':
+				x, err := parseXLogData(m.Data[1:])
+				if err != nil {
+					return err
+				}
+				if err := s.handle(ctx, x.WALData); err != nil {
+					return err
+				}
+			}
+		case *pgproto3.ErrorResponse:
+			return pgconn.ErrorResponseToPgError(m)
+		case *pgproto3.CopyDone:
+			return errors.New("server ended replication")
+		}
+	}
+}
+
+func (s *cdcSession) handle(ctx context.Context, data []byte) error {
+	msg, err := parsePgoutput(data)
+	if err != nil {
+		return err
+	}
+	switch m := msg.(type) {
+	case relationMessage:
+		s.relations[m.ID] = m
+	case beginMessage:
+		s.inTx = true
+		s.pending = s.pending[:0]
+		s.requeued = s.requeued[:0]
+	case insertMessage:
+		rel, ok := s.relations[m.RelationID]
+		if !ok {
+			return fmt.Errorf("insert for unknown relation %d", m.RelationID)
+		}
+		if rel.Name != "outbox" {
+			return nil
+		}
+		rec, err := outboxRecordFromTuple(s.types, rel, m.Values)
+		if err != nil {
+			return err
+		}
+		s.pending = append(s.pending, rec)
+	case updateMessage:
+		rel, ok := s.relations[m.RelationID]
+		if !ok {
+			return fmt.Errorf("update for unknown relation %d", m.RelationID)
+		}
+		if rel.Name != "outbox" {
+			return nil
+		}
+		// Marking rows SENT (our own) or FAILED/SKIPPED streams as well; only
+		// rows put back to PENDING are due again.
+		if id, status := tupleColumn(rel, m.Values, "id"), tupleColumn(rel, m.Values, "status"); status == "PENDING" && id != "" {
+			s.requeued = append(s.requeued, id)
+		}
+	case commitMessage:
+		if len(s.requeued) > 0 {
+			recs, err := s.relay.loadPending(ctx, s.requeued)
+			if err != nil {
+				return err
+			}
+			s.pending = append(s.pending, recs...)
+			sort.SliceStable(s.pending, func(i, j int) bool {
+				if s.pending[i].PartitionKey != s.pending[j].PartitionKey {
+					return s.pending[i].PartitionKey < s.pending[j].PartitionKey
+				}
+				return s.pending[i].Seq < s.pending[j].Seq
+			})
+		}
+		if len(s.pending) > 0 {
+			if err := s.relay.publish(ctx, s.pending, s.sendStatus); err != nil {
+				return err
+			}
+		}
+		s.inTx = false
+		s.pending = s.pending[:0]
+		s.requeued = s.requeued[:0]
+		s.confirmed = m.EndLSN
+		return s.sendStatus()
+	}
+	return nil
+}
+
+// publish delivers recs, retrying until it works or ctx ends, then marks them
+// SENT so the table agrees with the poll relay. idle, if set, runs between
+// attempts to keep the replication connection alive.
+func (r *OutboxCDCRelayPG) publish(ctx context.Context, recs []ports.OutboxRecord, idle func() error) error {
+	msgs := make([]ports.Message, len(recs))
+	ids := make([]string, len(recs))
+	for i, rec := range recs {
+		msgs[i] = outbox.MessageFromRecord(rec)
+		ids[i] = rec.ID
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := r.pub.PublishBatch(ctx, msgs)
+		if err == nil {
+			break
+		}
+		log.Printf("outbox cdc: publish %d rows: attempt %d: %v", len(msgs), attempt, err)
+		// The position doesn't move while we wait.
+		if idle != nil {
+			if err := idle(); err != nil {
+				return err
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(outbox.Backoff(attempt, r.cfg.BaseBackoff, r.cfg.MaxBackoff)):
+		}
+	}
+
+	_, err := r.pool.Exec(ctx, `
+		WITH sent AS (
+			UPDATE outbox
+			SET status = 'SENT', updated_at = $2, version = version + 1
+			WHERE id = ANY($1::uuid[]) AND status = 'PENDING'
+			RETURNING id, attempts
+		)
+		INSERT INTO outbox_attempts (outbox_id, attempt, outcome, created_at)
+		SELECT id, attempts + 1, 'SENT', $2 FROM sent
+	`, ids, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("mark sent: %w", err)
+	}
+	return nil
+}
+
+func (s *cdcSession) sendStatus() error {
+	s.conn.Frontend().Send(&pgproto3.CopyData{Data: standbyStatusUpdate(s.confirmed, time.Now())})
+	if err := s.conn.Frontend().Flush(); err != nil {
+		return fmt.Errorf("standby status: %w", err)
+	}
+	return nil
+}
+
+// tupleColumn returns the text value of col in a tuple of rel, or "".
+func tupleColumn(rel relationMessage, values [][]byte, col string) string {
+	for i, c := range rel.Columns {
+		if c == col && i < len(values) {
+			return string(values[i])
+		}
+	}
+	return ""
+}
+
+// outboxRecordFromTuple decodes an inserted outbox row. Values are in the
+// server's text output format, decoded with the same codecs pgx uses for
+// queries; a value that doesn't decode is an error rather than a zero field.
+func outboxRecordFromTuple(types *pgtype.Map, rel relationMessage, values [][]byte) (ports.OutboxRecord, error) {
+	var rec ports.OutboxRecord
+	for i, col := range rel.Columns {
+		if i >= len(values) {
+			break
+		}
+		v := values[i]
+		switch col {
+		case "id":
+			rec.ID = string(v)
+		case "tenant_id":
+			rec.TenantID = string(v)
+		case "event_type":
+			rec.EventType = string(v)
+		case "partition_key":
+			rec.PartitionKey = string(v)
+		case "seq":
+			if v == nil {
+				continue
+			}
+			if err := types.Scan(pgtype.Int8OID, pgtype.TextFormatCode, v, &rec.Seq); err != nil {
+				return rec, fmt.Errorf("outbox insert: seq %q: %w", v, err)
+			}
+		case "headers":
+			// Headers are best effort; a bad value must not stall the slot.
+			_ = json.Unmarshal(v, &rec.Headers)
+		case "payload_json":
+			rec.Payload = v
+		case "created_at":
+			if err := types.Scan(pgtype.TimestamptzOID, pgtype.TextFormatCode, v, &rec.CreatedAt); err != nil {
+				return rec, fmt.Errorf("outbox insert: created_at %q: %w", v, err)
+			}
+		}
+	}
+	if rec.ID == "" || rec.EventType == "" || rec.Payload == nil {
+		return rec, fmt.Errorf("outbox insert is missing id, event_type or payload_json")
+	}
+	return rec, nil
+}
+
+// startReplicationSQL quotes both names. publication_names is a string
+// literal holding an identifier list, so the quoted publication name has its
+// single quotes doubled as well.
+func startReplicationSQL(slot, publication string) string {
+	pub := strings.ReplaceAll(pgx.Identifier{publication}.Sanitize(), "'", "''")
+	return fmt.Sprintf(
+		`START_REPLICATION SLOT %s LOGICAL 0/0 (proto_version '1', publication_names '%s')`,
+		pgx.Identifier{slot}.Sanitize(), pub,
+	)
+}
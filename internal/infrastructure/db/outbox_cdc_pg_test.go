@@ -0,0 +1,92 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"inbox-service/internal/infrastructure/publisher"
+)
+
+func TestOutboxCDCRelayPG_PublishesInsertsAndConfirms(t *testing.T) {
+	pool := newTestPool(t)
+	var walLevel string
+	if err := pool.QueryRow(context.Background(), `SHOW wal_level`).Scan(&walLevel); err != nil {
+		t.Fatalf("wal_level: %v", err)
+	}
+	if walLevel != "logical" {
+		t.Skip("needs wal_level=logical (see docker-compose.yml)")
+	}
+	truncateAll(t, pool)
+
+	const slot = "inbox_outbox_test"
+	t.Cleanup(func() {
+		_, _ = pool.Exec(context.Background(), `SELECT pg_drop_replication_slot($1) FROM pg_replication_slots WHERE slot_name = $1`, slot)
+	})
+
+	pub := publisher.NewMemoryPublisher()
+	run := func(want int) {
+		t.Helper()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		relay := NewOutboxCDCRelayPG(pool, testDBURL(), pub, CDCConfig{Slot: slot, StatusInterval: 100 * time.Millisecond})
+		done := make(chan error, 1)
+		go func() { done <- relay.Run(ctx) }()
+		for {
+			var exists bool
+			_ = pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM pg_replication_slots WHERE slot_name = $1 AND active)`, slot).Scan(&exists)
+			if exists || ctx.Err() != nil {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		for len(pub.Messages()) < want {
+			if ctx.Err() != nil {
+				t.Fatalf("expected %d published, got %d", want, len(pub.Messages()))
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		// Let the confirm go out before stopping.
+		time.Sleep(300 * time.Millisecond)
+		cancel()
+		<-done
+	}
+
+	// The first start creates the slot and backfills rows that were already
+	// pending; later rows come from the stream.
+	insertOutbox(t, pool, "00000000-0000-0000-0000-000000000001")
+	run(1)
+	insertOutbox(t, pool, "11111111-1111-1111-1111-111111111111")
+	run(2)
+
+	if got := pub.Messages()[0]; got.ID != "00000000-0000-0000-0000-000000000001" {
+		t.Fatalf("expected the backfilled row first, got %+v", got)
+	}
+	if got := pub.Messages()[1]; got.ID != "11111111-1111-1111-1111-111111111111" || got.EventType != "InboxItemCreated" {
+		t.Fatalf("unexpected message %+v", got)
+	}
+	for _, id := range []string{"00000000-0000-0000-0000-000000000001", "11111111-1111-1111-1111-111111111111"} {
+		if status, _ := outboxStatus(t, pool, id); status != "SENT" {
+			t.Fatalf("%s: expected SENT, got %s", id, status)
+		}
+	}
+
+	// A restart resumes after the confirmed position: no republish.
+	insertOutbox(t, pool, "22222222-2222-2222-2222-222222222222")
+	run(3)
+	if n := len(pub.Messages()); n != 3 {
+		t.Fatalf("expected 3 messages after restart, got %d", n)
+	}
+
+	// Putting a row back to PENDING (an admin requeue) publishes it again.
+	if _, err := pool.Exec(context.Background(), `UPDATE outbox SET status = 'PENDING' WHERE id = $1`, "11111111-1111-1111-1111-111111111111"); err != nil {
+		t.Fatalf("requeue: %v", err)
+	}
+	run(4)
+	if got := pub.Messages()[3]; got.ID != "11111111-1111-1111-1111-111111111111" {
+		t.Fatalf("expected the requeued row, got %+v", got)
+	}
+	if status, _ := outboxStatus(t, pool, "11111111-1111-1111-1111-111111111111"); status != "SENT" {
+		t.Fatalf("expected SENT after the requeue, got %s", status)
+	}
+}
@@ -0,0 +1,125 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"inbox-service/internal/application/ports"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// retentionLockKey serialises outbox retention runs across replicas.
+const retentionLockKey = 7_400_002
+
+type OutboxRetentionPG struct {
+	pool *pgxpool.Pool
+}
+
+func NewOutboxRetentionPG(pool *pgxpool.Pool) *OutboxRetentionPG {
+	return &OutboxRetentionPG{pool: pool}
+}
+
+// WithRetentionLock holds a session-level advisory lock on a dedicated
+// connection, since a run spans many transactions.
+func (s *OutboxRetentionPG) WithRetentionLock(ctx context.Context, fn func(ctx context.Context) error) (bool, error) {
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return false, fmt.Errorf("acquire conn: %w", err)
+	}
+	defer conn.Release()
+
+	var locked bool
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock($1)`, retentionLockKey).Scan(&locked); err != nil {
+		return false, fmt.Errorf("retention lock: %w", err)
+	}
+	if !locked {
+		return false, nil
+	}
+	defer func() {
+		if _, err := conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, retentionLockKey); err != nil {
+			// Closing the session is the only other way to drop the lock.
+			_ = conn.Conn().Close(context.Background())
+		}
+	}()
+	return true, fn(ctx)
+}
+
+func (s *OutboxRetentionPG) ExpiredOutbox(ctx context.Context, tx ports.Tx, status string, olderThan time.Time, limit int) ([]ports.OutboxRow, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT `+outboxAdminColumns+`, payload_json
+		FROM outbox
+		WHERE status = $1 AND updated_at < $2
+		ORDER BY updated_at
+		LIMIT $3
+		FOR UPDATE SKIP LOCKED
+	`, status, olderThan, limit)
+	if err != nil {
+		return nil, fmt.Errorf("select expired outbox: %w", err)
+	}
+	defer rows.Close()
+
+	var out []ports.OutboxRow
+	for rows.Next() {
+		var r ports.OutboxRow
+		if err := rows.Scan(&r.ID, &r.TenantID, &r.EventType, &r.PartitionKey, &r.Seq, &r.Headers, &r.Status, &r.Attempts, &r.NextRunAt, &r.LastError, &r.CreatedAt, &r.UpdatedAt, &r.Version, &r.Payload); err != nil {
+			return nil, fmt.Errorf("scan outbox row: %w", err)
+		}
+		out = append(out, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("outbox rows err: %w", err)
+	}
+	return out, nil
+}
+
+func (s *OutboxRetentionPG) DeleteOutbox(ctx context.Context, tx ports.Tx, ids []string) (int, error) {
+	tag, err := tx.Exec(ctx, `DELETE FROM outbox WHERE id = ANY($1::uuid[])`, ids)
+	if err != nil {
+		return 0, fmt.Errorf("delete outbox: %w", err)
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+// OutboxArchivePG copies rows into outbox_archive, which is partitioned by
+// month of created_at. Partitions are created on demand, so old months can
+// be detached or dropped as a whole.
+type OutboxArchivePG struct{}
+
+func NewOutboxArchivePG() *OutboxArchivePG { return &OutboxArchivePG{} }
+
+func (a *OutboxArchivePG) ArchiveOutbox(ctx context.Context, tx ports.Tx, rows []ports.OutboxRow) error {
+	months := map[time.Time]bool{}
+	ids := make([]string, len(rows))
+	for i, r := range rows {
+		c := r.CreatedAt.UTC()
+		months[time.Date(c.Year(), c.Month(), 1, 0, 0, 0, 0, time.UTC)] = true
+		ids[i] = r.ID
+	}
+	for m := range months {
+		name := pgx.Identifier{fmt.Sprintf("outbox_archive_%04d_%02d", m.Year(), int(m.Month()))}.Sanitize()
+		_, err := tx.Exec(ctx, fmt.Sprintf(
+			`CREATE TABLE IF NOT EXISTS %s PARTITION OF outbox_archive FOR VALUES FROM ('%s') TO ('%s')`,
+			name, m.Format(time.RFC3339), m.AddDate(0, 1, 0).Format(time.RFC3339),
+		))
+		if err != nil {
+			return fmt.Errorf("create archive partition %s: %w", name, err)
+		}
+	}
+
+	// The rows are locked by the caller's transaction, so copying them from
+	// the table is the same as inserting what we were handed.
+	_, err := tx.Exec(ctx, `
+		INSERT INTO outbox_archive (id, tenant_id, event_type, payload_json, headers, partition_key, seq, status, attempts, last_error, created_at, updated_at, archived_at)
+		SELECT id, tenant_id, event_type, payload_json, headers, partition_key, seq, status, attempts, last_error, created_at, updated_at, $2
+		FROM outbox
+		WHERE id = ANY($1::uuid[])
+		ON CONFLICT DO NOTHING
+	`, ids, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("insert outbox archive: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"inbox-service/internal/application/outbox"
+)
+
+func TestOutboxRetention_ArchivesToPartitionsAndDeletes(t *testing.T) {
+	pool := newTestPool(t)
+	truncateAll(t, pool)
+	ctx := context.Background()
+
+	sent := "77777777-7777-7777-7777-777777777777"
+	failed := "88888888-8888-8888-8888-888888888888"
+	fresh := "99999999-9999-9999-9999-999999999999"
+	for _, id := range []string{sent, failed, fresh} {
+		insertOutbox(t, pool, id)
+	}
+	_, err := pool.Exec(ctx, `
+		UPDATE outbox SET
+		  status = CASE WHEN id = $2 THEN 'FAILED' ELSE 'SENT' END,
+		  created_at = now() - interval '40 days',
+		  updated_at = CASE WHEN id = $3 THEN now() ELSE now() - interval '40 days' END
+		WHERE id = ANY(ARRAY[$1, $2, $3]::uuid[])
+	`, sent, failed, fresh)
+	if err != nil {
+		t.Fatalf("age rows: %v", err)
+	}
+
+	store := NewOutboxRetentionPG(pool)
+	r := outbox.NewRetention(NewTxManagerPG(pool), store, NewOutboxArchivePG(), outbox.RetentionConfig{
+		SentAge:   7 * 24 * time.Hour,
+		FailedAge: 30 * 24 * time.Hour,
+		BatchSize: 1,
+	})
+
+	// Another replica holding the lock makes this run a no-op.
+	ran, err := store.WithRetentionLock(ctx, func(ctx context.Context) error {
+		n, err := r.Run(ctx)
+		if err != nil || n != 0 {
+			t.Errorf("expected a locked-out run to do nothing, got %d / %v", n, err)
+		}
+		return nil
+	})
+	if err != nil || !ran {
+		t.Fatalf("WithRetentionLock: %v / %v", ran, err)
+	}
+
+	n, err := r.Run(ctx)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if n != 2 {
+		t.Fatalf("expected 2 rows archived, got %d", n)
+	}
+
+	var left, archived, partitions int
+	if err := pool.QueryRow(ctx, `SELECT COUNT(*) FROM outbox`).Scan(&left); err != nil {
+		t.Fatalf("count outbox: %v", err)
+	}
+	if err := pool.QueryRow(ctx, `SELECT COUNT(*) FROM outbox_archive WHERE id = ANY(ARRAY[$1, $2]::uuid[])`, sent, failed).Scan(&archived); err != nil {
+		t.Fatalf("count archive: %v", err)
+	}
+	err = pool.QueryRow(ctx, `
+		SELECT COUNT(*) FROM pg_inherits WHERE inhparent = 'outbox_archive'::regclass
+	`).Scan(&partitions)
+	if err != nil {
+		t.Fatalf("count partitions: %v", err)
+	}
+	if left != 1 || archived != 2 || partitions < 1 {
+		t.Fatalf("expected 1 row left, 2 archived in >=1 partition; got %d / %d / %d", left, archived, partitions)
+	}
+}
@@ -0,0 +1,181 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"inbox-service/internal/application/ports"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type OutboxStorePG struct {
+	pool *pgxpool.Pool
+}
+
+func NewOutboxStorePG(pool *pgxpool.Pool) *OutboxStorePG {
+	return &OutboxStorePG{pool: pool}
+}
+
+// ClaimBatch leases due rows with FOR UPDATE SKIP LOCKED so concurrent
+// dispatchers never claim the same row. For PROCESSING rows next_run_at is the
+// lease expiry; re-claiming one counts as a (crashed) attempt.
+//
+// Ordering is kept per partition key by only claiming through a key's head,
+// its oldest unfinished row: the head is locked (or skipped if another
+// dispatcher has it), and the run of due rows right behind it comes along.
+// While any of them is leased the key has no due head, so nobody else can
+// claim its later rows.
+func (s *OutboxStorePG) ClaimBatch(ctx context.Context, now, leaseUntil time.Time, limit int) ([]ports.OutboxRecord, error) {
+	rows, err := s.pool.Query(ctx, `
+		UPDATE outbox o
+		SET status = 'PROCESSING',
+		    attempts = o.attempts + CASE WHEN o.status = 'PROCESSING' THEN 1 ELSE 0 END,
+		    next_run_at = $2,
+		    updated_at = $1,
+		    version = o.version + 1
+		FROM (
+			SELECT run.id
+			FROM (
+				SELECT h.partition_key, h.seq, h.next_run_at, h.created_at
+				FROM outbox h
+				WHERE h.status IN ('PENDING', 'PROCESSING') AND h.next_run_at <= $1
+				  AND NOT EXISTS (
+					SELECT 1 FROM outbox p
+					WHERE p.partition_key = h.partition_key AND p.seq < h.seq
+					  AND p.status IN ('PENDING', 'PROCESSING', 'FAILED')
+				  )
+				ORDER BY h.next_run_at, h.created_at
+				LIMIT $3
+				FOR UPDATE SKIP LOCKED
+			) head
+			CROSS JOIN LATERAL (
+				-- The unbroken stretch of due rows starting at the head.
+				SELECT n.id, n.seq
+				FROM (
+					SELECT id, seq,
+					       bool_and(status <> 'FAILED' AND next_run_at <= $1) OVER (ORDER BY seq) AS unbroken
+					FROM outbox
+					WHERE partition_key = head.partition_key AND seq >= head.seq
+					  AND status IN ('PENDING', 'PROCESSING', 'FAILED')
+					ORDER BY seq
+					LIMIT $3
+				) n
+				WHERE n.unbroken
+			) run
+			ORDER BY head.next_run_at, head.created_at, run.seq
+			LIMIT $3
+		) due
+		WHERE o.id = due.id
+		RETURNING o.id, o.tenant_id, o.event_type, o.partition_key, o.seq, o.headers, o.payload_json, o.attempts, o.version, o.created_at
+	`, now, leaseUntil, limit)
+	if err != nil {
+		return nil, fmt.Errorf("claim outbox: %w", err)
+	}
+	defer rows.Close()
+
+	var out []ports.OutboxRecord
+	for rows.Next() {
+		var r ports.OutboxRecord
+		if err := rows.Scan(&r.ID, &r.TenantID, &r.EventType, &r.PartitionKey, &r.Seq, &r.Headers, &r.Payload, &r.Attempts, &r.Version, &r.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan outbox row: %w", err)
+		}
+		out = append(out, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("outbox rows err: %w", err)
+	}
+	// RETURNING has no defined order.
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].PartitionKey != out[j].PartitionKey {
+			return out[i].PartitionKey < out[j].PartitionKey
+		}
+		return out[i].Seq < out[j].Seq
+	})
+	return out, nil
+}
+
+func (s *OutboxStorePG) MarkSent(ctx context.Context, r ports.OutboxRecord) error {
+	return s.finish(ctx, r, attempt{r.Attempts + 1, "SENT", ""}, `
+		UPDATE outbox
+		SET status = 'SENT', last_error = NULL, updated_at = $3, version = version + 1
+		WHERE id = $1 AND version = $2 AND status = 'PROCESSING'
+	`, time.Now().UTC())
+}
+
+func (s *OutboxStorePG) Reschedule(ctx context.Context, r ports.OutboxRecord, attempts int, nextRunAt time.Time, lastErr string) error {
+	return s.finish(ctx, r, attempt{attempts, "RETRY", lastErr}, `
+		UPDATE outbox
+		SET status = 'PENDING', attempts = $4, next_run_at = $5, last_error = $6,
+		    updated_at = $3, version = version + 1
+		WHERE id = $1 AND version = $2 AND status = 'PROCESSING'
+	`, time.Now().UTC(), attempts, nextRunAt, lastErr)
+}
+
+func (s *OutboxStorePG) MarkFailed(ctx context.Context, r ports.OutboxRecord, attempts int, lastErr string) error {
+	return s.finish(ctx, r, attempt{attempts, "FAILED", lastErr}, `
+		UPDATE outbox
+		SET status = 'FAILED', attempts = $4, last_error = $5,
+		    updated_at = $3, version = version + 1
+		WHERE id = $1 AND version = $2 AND status = 'PROCESSING'
+	`, time.Now().UTC(), attempts, lastErr)
+}
+
+// Release puts a claimed row back to PENDING, due right away, without
+// recording an attempt. Its key's head still has to go first.
+func (s *OutboxStorePG) Release(ctx context.Context, r ports.OutboxRecord) error {
+	return s.Postpone(ctx, r, time.Now().UTC())
+}
+
+// Postpone puts a claimed row back to PENDING, due at nextRunAt, without
+// recording an attempt.
+func (s *OutboxStorePG) Postpone(ctx context.Context, r ports.OutboxRecord, nextRunAt time.Time) error {
+	tag, err := s.pool.Exec(ctx, `
+		UPDATE outbox
+		SET status = 'PENDING', next_run_at = $3, updated_at = $4, version = version + 1
+		WHERE id = $1 AND version = $2 AND status = 'PROCESSING'
+	`, r.ID, r.Version, nextRunAt, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("release outbox: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ports.ErrLeaseLost
+	}
+	return nil
+}
+
+// attempt is the outbox_attempts row written with an outcome.
+type attempt struct {
+	n       int
+	outcome string
+	err     string
+}
+
+// finish applies a state change fenced on the version we claimed and records
+// the attempt; if the row moved on (lease expired and someone else claimed it)
+// nothing is written.
+func (s *OutboxStorePG) finish(ctx context.Context, r ports.OutboxRecord, a attempt, sql string, now time.Time, args ...any) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	tag, err := tx.Exec(ctx, sql, append([]any{r.ID, r.Version, now}, args...)...)
+	if err != nil {
+		return fmt.Errorf("update outbox: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ports.ErrLeaseLost
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO outbox_attempts (outbox_id, attempt, outcome, error, created_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5)
+	`, r.ID, a.n, a.outcome, a.err, now)
+	if err != nil {
+		return fmt.Errorf("insert outbox attempt: %w", err)
+	}
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,263 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"inbox-service/internal/application/outbox"
+	"inbox-service/internal/application/ports"
+	"inbox-service/internal/infrastructure/publisher"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func insertOutbox(t *testing.T, pool *pgxpool.Pool, id string) {
+	t.Helper()
+	insertKeyedOutbox(t, pool, id, "")
+}
+
+func insertKeyedOutbox(t *testing.T, pool *pgxpool.Pool, id, key string) {
+	t.Helper()
+
+	err := NewTxManagerPG(pool).WithTx(context.Background(), func(ctx context.Context, tx ports.Tx) error {
+		return NewOutboxWriterPG().InsertOutbox(ctx, tx, ports.OutboxEvent{
+			ID:           id,
+			TenantID:     "aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa",
+			EventType:    "InboxItemCreated",
+			PartitionKey: key,
+			Payload:      []byte(`{"k":"v"}`),
+		})
+	})
+	if err != nil {
+		t.Fatalf("insertOutbox: %v", err)
+	}
+}
+
+func outboxStatus(t *testing.T, pool *pgxpool.Pool, id string) (string, int) {
+	t.Helper()
+
+	var status string
+	var attempts int
+	err := pool.QueryRow(context.Background(), `SELECT status, attempts FROM outbox WHERE id = $1`, id).Scan(&status, &attempts)
+	if err != nil {
+		t.Fatalf("select outbox: %v", err)
+	}
+	return status, attempts
+}
+
+func TestOutboxStorePG_ClaimIsExclusiveAndLeased(t *testing.T) {
+	pool := newTestPool(t)
+	truncateAll(t, pool)
+
+	s := NewOutboxStorePG(pool)
+	ctx := context.Background()
+
+	insertOutbox(t, pool, "11111111-1111-1111-1111-111111111111")
+	insertOutbox(t, pool, "22222222-2222-2222-2222-222222222222")
+
+	now := time.Now().UTC().Add(time.Second)
+	first, err := s.ClaimBatch(ctx, now, now.Add(time.Minute), 10)
+	if err != nil {
+		t.Fatalf("ClaimBatch: %v", err)
+	}
+	if len(first) != 2 {
+		t.Fatalf("expected 2 claimed rows, got %d", len(first))
+	}
+
+	// Leased rows are invisible to other dispatchers...
+	second, err := s.ClaimBatch(ctx, now, now.Add(time.Minute), 10)
+	if err != nil {
+		t.Fatalf("ClaimBatch: %v", err)
+	}
+	if len(second) != 0 {
+		t.Fatalf("expected 0 claimed rows while leased, got %d", len(second))
+	}
+
+	// ...until the lease expires.
+	later := now.Add(2 * time.Minute)
+	recovered, err := s.ClaimBatch(ctx, later, later.Add(time.Minute), 10)
+	if err != nil {
+		t.Fatalf("ClaimBatch: %v", err)
+	}
+	if len(recovered) != 2 {
+		t.Fatalf("expected 2 recovered rows, got %d", len(recovered))
+	}
+	if recovered[0].Attempts != 1 {
+		t.Fatalf("expected recovered row to count an attempt, got %d", recovered[0].Attempts)
+	}
+
+	// The original claim is fenced off.
+	if err := s.MarkSent(ctx, first[0]); !errors.Is(err, ports.ErrLeaseLost) {
+		t.Fatalf("expected ErrLeaseLost for stale claim, got %v", err)
+	}
+	if err := s.MarkSent(ctx, recovered[0]); err != nil {
+		t.Fatalf("MarkSent: %v", err)
+	}
+	if status, _ := outboxStatus(t, pool, recovered[0].ID); status != "SENT" {
+		t.Fatalf("expected SENT, got %q", status)
+	}
+}
+
+func TestOutboxStorePG_RescheduleAndFail(t *testing.T) {
+	pool := newTestPool(t)
+	truncateAll(t, pool)
+
+	s := NewOutboxStorePG(pool)
+	ctx := context.Background()
+
+	insertOutbox(t, pool, "33333333-3333-3333-3333-333333333333")
+
+	now := time.Now().UTC().Add(time.Second)
+	recs, err := s.ClaimBatch(ctx, now, now.Add(time.Minute), 10)
+	if err != nil || len(recs) != 1 {
+		t.Fatalf("ClaimBatch: %v (%d rows)", err, len(recs))
+	}
+
+	next := now.Add(time.Hour)
+	if err := s.Reschedule(ctx, recs[0], 1, next, "boom"); err != nil {
+		t.Fatalf("Reschedule: %v", err)
+	}
+	if status, attempts := outboxStatus(t, pool, recs[0].ID); status != "PENDING" || attempts != 1 {
+		t.Fatalf("expected PENDING/1, got %s/%d", status, attempts)
+	}
+
+	// Not due yet.
+	recs, err = s.ClaimBatch(ctx, now, now.Add(time.Minute), 10)
+	if err != nil || len(recs) != 0 {
+		t.Fatalf("expected nothing due, got %d rows (err %v)", len(recs), err)
+	}
+
+	recs, err = s.ClaimBatch(ctx, next, next.Add(time.Minute), 10)
+	if err != nil || len(recs) != 1 {
+		t.Fatalf("ClaimBatch: %v (%d rows)", err, len(recs))
+	}
+	if err := s.MarkFailed(ctx, recs[0], 2, "boom again"); err != nil {
+		t.Fatalf("MarkFailed: %v", err)
+	}
+	if status, attempts := outboxStatus(t, pool, recs[0].ID); status != "FAILED" || attempts != 2 {
+		t.Fatalf("expected FAILED/2, got %s/%d", status, attempts)
+	}
+}
+
+func TestOutboxStorePG_StuckKeyBlocksOnlyItself(t *testing.T) {
+	pool := newTestPool(t)
+	truncateAll(t, pool)
+
+	s := NewOutboxStorePG(pool)
+	ctx := context.Background()
+
+	a1, a2 := "a1a1a1a1-0000-0000-0000-000000000001", "a1a1a1a1-0000-0000-0000-000000000002"
+	b1, b2 := "b1b1b1b1-0000-0000-0000-000000000001", "b1b1b1b1-0000-0000-0000-000000000002"
+	insertKeyedOutbox(t, pool, a1, "t/alice")
+	insertKeyedOutbox(t, pool, b1, "t/bob")
+	insertKeyedOutbox(t, pool, a2, "t/alice")
+
+	now := time.Now().UTC().Add(time.Second)
+	recs, err := s.ClaimBatch(ctx, now, now.Add(time.Minute), 10)
+	if err != nil || len(recs) != 3 {
+		t.Fatalf("ClaimBatch: %v (%d rows)", err, len(recs))
+	}
+	if recs[0].ID != a1 || recs[0].Seq != 1 || recs[1].ID != a2 || recs[1].Seq != 2 || recs[2].ID != b1 {
+		t.Fatalf("expected rows ordered by key and seq, got %+v", recs)
+	}
+
+	// alice's head fails and backs off; the rest of her run goes back.
+	if err := s.Reschedule(ctx, recs[0], 1, now.Add(time.Hour), "boom"); err != nil {
+		t.Fatalf("Reschedule: %v", err)
+	}
+	if err := s.Release(ctx, recs[1]); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if err := s.MarkSent(ctx, recs[2]); err != nil {
+		t.Fatalf("MarkSent: %v", err)
+	}
+	insertKeyedOutbox(t, pool, b2, "t/bob")
+
+	// bob keeps flowing; alice's second event waits behind her first.
+	recs, err = s.ClaimBatch(ctx, now, now.Add(time.Minute), 10)
+	if err != nil || len(recs) != 1 || recs[0].ID != b2 || recs[0].Seq != 2 {
+		t.Fatalf("expected only %s, got %+v (err %v)", b2, recs, err)
+	}
+	if status, attempts := outboxStatus(t, pool, a2); status != "PENDING" || attempts != 0 {
+		t.Fatalf("expected the released row PENDING/0, got %s/%d", status, attempts)
+	}
+
+	later := now.Add(2 * time.Hour)
+	recs, err = s.ClaimBatch(ctx, later, later.Add(time.Minute), 10)
+	if err != nil {
+		t.Fatalf("ClaimBatch: %v", err)
+	}
+	// b2 comes back too: its lease expired.
+	var ids []string
+	for _, r := range recs {
+		ids = append(ids, r.ID)
+	}
+	if len(ids) != 3 || ids[0] != a1 || ids[1] != a2 || ids[2] != b2 {
+		t.Fatalf("expected alice's run in order plus b2, got %v", ids)
+	}
+}
+
+func TestOutboxStorePG_HeadersRoundTrip(t *testing.T) {
+	pool := newTestPool(t)
+	truncateAll(t, pool)
+	ctx := context.Background()
+
+	headers := map[string]string{ports.HeaderCorrelationID: "corr-1", ports.HeaderCausationID: "evt-1"}
+	err := NewTxManagerPG(pool).WithTx(ctx, func(ctx context.Context, tx ports.Tx) error {
+		return NewOutboxWriterPG().InsertOutbox(ctx, tx, ports.OutboxEvent{
+			ID:        "44444444-4444-4444-4444-444444444444",
+			TenantID:  "aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa",
+			EventType: "InboxItemCreated",
+			Headers:   headers,
+			Payload:   []byte(`{}`),
+		})
+	})
+	if err != nil {
+		t.Fatalf("InsertOutbox: %v", err)
+	}
+
+	now := time.Now().UTC().Add(time.Second)
+	recs, err := NewOutboxStorePG(pool).ClaimBatch(ctx, now, now.Add(time.Minute), 10)
+	if err != nil || len(recs) != 1 {
+		t.Fatalf("ClaimBatch: %d rows, %v", len(recs), err)
+	}
+	if recs[0].Headers[ports.HeaderCorrelationID] != "corr-1" || recs[0].Headers[ports.HeaderCausationID] != "evt-1" {
+		t.Fatalf("expected stored headers, got %v", recs[0].Headers)
+	}
+}
+
+func TestOutboxWriterPG_NotifyWakesDispatcher(t *testing.T) {
+	pool := newTestPool(t)
+	truncateAll(t, pool)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pub := publisher.NewMemoryPublisher()
+	d := outbox.NewDispatcher(NewOutboxStorePG(pool), pub, outbox.Config{PollInterval: time.Hour, ListenPollInterval: time.Hour})
+	listening := make(chan bool, 1)
+	listener := NewListenerPG(pool, OutboxChannel)
+	listener.OnListening = func(on bool) {
+		d.SetListening(on)
+		if on {
+			listening <- true
+		}
+	}
+	go func() { _ = listener.Run(ctx, func(string) { d.Wake() }) }()
+	go func() { _ = d.Run(ctx) }()
+	<-listening
+
+	start := time.Now()
+	insertOutbox(t, pool, "11111111-1111-1111-1111-111111111111")
+	for len(pub.Messages()) == 0 {
+		if ctx.Err() != nil {
+			t.Fatalf("outbox row was not published")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if took := time.Since(start); took > time.Second {
+		t.Fatalf("expected publish right after commit, took %s", took)
+	}
+}
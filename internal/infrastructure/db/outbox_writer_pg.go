@@ -8,25 +8,52 @@ import (
 	"inbox-service/internal/application/ports"
 )
 
+// OutboxChannel is notified in every transaction that writes to the outbox, so
+// relays can LISTEN instead of polling. Postgres folds identical notifications
+// within a transaction into one, delivered at commit.
+const OutboxChannel = "outbox_pending"
+
 type OutboxWriterPG struct{}
 
 func NewOutboxWriterPG() *OutboxWriterPG { return &OutboxWriterPG{} }
 
+// InsertOutbox takes the next sequence number of the event's partition key.
+// The key's counter row stays locked until commit, so writers of the same key
+// commit in sequence order and the relay never sees a gap fill in later.
 func (w *OutboxWriterPG) InsertOutbox(ctx context.Context, tx ports.Tx, e ports.OutboxEvent) error {
+	key := e.PartitionKey
+	if key == "" {
+		key = e.TenantID
+	}
+	headers := e.Headers
+	if headers == nil {
+		headers = map[string]string{}
+	}
 	now := time.Now().UTC()
 	_, err := tx.Exec(ctx, `
+		WITH s AS (
+			INSERT INTO outbox_keys (partition_key, last_seq) VALUES ($5, 1)
+			ON CONFLICT (partition_key) DO UPDATE SET last_seq = outbox_keys.last_seq + 1
+			RETURNING last_seq
+		)
 		INSERT INTO outbox (
-			id, tenant_id, event_type, payload_json,
+			id, tenant_id, event_type, payload_json, headers,
+			partition_key, seq,
 			status, attempts, next_run_at,
 			created_at, updated_at, version
-		) VALUES (
-			$1,$2,$3,$4,
-			'PENDING', 0, $5,
-			$6,$7,1
 		)
-	`, e.ID, e.TenantID, e.EventType, e.Payload, now, now, now)
+		SELECT
+			$1::uuid, $2::uuid, $3::text, $4::jsonb, $7::jsonb,
+			$5::text, s.last_seq,
+			'PENDING', 0, $6::timestamptz,
+			$6::timestamptz, $6::timestamptz, 1
+		FROM s
+	`, e.ID, e.TenantID, e.EventType, e.Payload, key, now, headers)
 	if err != nil {
 		return fmt.Errorf("insert outbox: %w", err)
 	}
+	if _, err := tx.Exec(ctx, `SELECT pg_notify($1, '')`, OutboxChannel); err != nil {
+		return fmt.Errorf("notify outbox: %w", err)
+	}
 	return nil
 }
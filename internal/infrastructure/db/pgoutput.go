@@ -0,0 +1,239 @@
+package db
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Minimal decoding of the streaming replication protocol and pgoutput
+// (protocol version 1), covering what the outbox CDC relay needs: relations,
+// inserts, updates and transaction boundaries. See "Streaming Replication Protocol" and
+// "Logical Replication Message Formats" in the Postgres docs.
+
+// LSN is a WAL position.
+type LSN uint64
+
+func (l LSN) String() string {
+	return fmt.Sprintf("%X/%X", uint32(l>>32), uint32(l))
+}
+
+var errShortMessage = errors.New("pgoutput: message too short")
+
+// Postgres timestamps count microseconds from 2000-01-01.
+var pgEpoch = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// CopyData payloads sent by the walsender.
+type primaryKeepalive struct {
+	ServerWALEnd   LSN
+	ReplyRequested bool
+}
+
+type xLogData struct {
+	WALStart LSN
+	WALData  []byte
+}
+
+func parsePrimaryKeepalive(b []byte) (primaryKeepalive, error) {
+	if len(b) < 17 {
+		return primaryKeepalive{}, errShortMessage
+	}
+	return primaryKeepalive{
+		ServerWALEnd:   LSN(binary.BigEndian.Uint64(b)),
+		ReplyRequested: b[16] != 0,
+	}, nil
+}
+
+func parseXLogData(b []byte) (xLogData, error) {
+	if len(b) < 24 {
+		return xLogData{}, errShortMessage
+	}
+	return xLogData{WALStart: LSN(binary.BigEndian.Uint64(b)), WALData: b[24:]}, nil
+}
+
+// standbyStatusUpdate builds the 'r' message that reports (and, for a logical
+// slot, confirms) how far the client has processed the WAL.
+func standbyStatusUpdate(lsn LSN, now time.Time) []byte {
+	b := make([]byte, 34)
+	b[0] = 'r'
+	binary.BigEndian.PutUint64(b[1:], uint64(lsn))  // written
+	binary.BigEndian.PutUint64(b[9:], uint64(lsn))  // flushed
+	binary.BigEndian.PutUint64(b[17:], uint64(lsn)) // applied
+	binary.BigEndian.PutUint64(b[25:], uint64(now.Sub(pgEpoch).Microseconds()))
+	return b
+}
+
+// pgoutput messages.
+type relationMessage struct {
+	ID        uint32
+	Namespace string
+	Name      string
+	Columns   []string
+}
+
+type insertMessage struct {
+	RelationID uint32
+	// Values are in text format; nil is SQL NULL (or an unchanged TOAST
+	// value, which can't occur on insert).
+	Values [][]byte
+}
+
+type updateMessage struct {
+	RelationID uint32
+	// Values is the new tuple, in text format; nil is SQL NULL or a TOASTed
+	// value the update didn't change.
+	Values [][]byte
+}
+
+type beginMessage struct{}
+
+type commitMessage struct {
+	CommitLSN LSN
+	EndLSN    LSN
+}
+
+// parsePgoutput decodes one pgoutput message. Message types the relay does not
+// care about are returned as nil without error.
+func parsePgoutput(b []byte) (any, error) {
+	if len(b) == 0 {
+		return nil, errShortMessage
+	}
+	r := &reader{b: b[1:]}
+	switch b[0] {
+	case 'B':
+		return beginMessage{}, nil
+	case 'C':
+		r.skip(1) // flags
+		m := commitMessage{CommitLSN: LSN(r.uint64()), EndLSN: LSN(r.uint64())}
+		return m, r.err
+	case 'R':
+		m := relationMessage{ID: r.uint32(), Namespace: r.string(), Name: r.string()}
+		r.skip(1) // replica identity
+		n := int(r.uint16())
+		for i := 0; i < n && r.err == nil; i++ {
+			r.skip(1) // flags
+			m.Columns = append(m.Columns, r.string())
+			r.skip(8) // type oid, type modifier
+		}
+		return m, r.err
+	case 'I':
+		m := insertMessage{RelationID: r.uint32()}
+		if kind := r.byte(); r.err == nil && kind != 'N' {
+			return nil, fmt.Errorf("pgoutput: unexpected insert tuple kind %q", kind)
+		}
+		values, err := r.tuple()
+		m.Values = values
+		return m, err
+	case 'U':
+		m := updateMessage{RelationID: r.uint32()}
+		kind := r.byte()
+		if kind == 'K' || kind == 'O' {
+			// The old key or row, sent with REPLICA IDENTITY; not needed.
+			if _, err := r.tuple(); err != nil {
+				return nil, err
+			}
+			kind = r.byte()
+		}
+		if r.err == nil && kind != 'N' {
+			return nil, fmt.Errorf("pgoutput: unexpected update tuple kind %q", kind)
+		}
+		values, err := r.tuple()
+		m.Values = values
+		return m, err
+	default:
+		return nil, nil
+	}
+}
+
+// tuple reads TupleData: a column count, then each column's kind and value.
+func (r *reader) tuple() ([][]byte, error) {
+	var values [][]byte
+	n := int(r.uint16())
+	for i := 0; i < n && r.err == nil; i++ {
+		switch kind := r.byte(); kind {
+		case 'n', 'u':
+			values = append(values, nil)
+		case 't':
+			values = append(values, r.bytes(int(r.uint32())))
+		default:
+			if r.err == nil {
+				return nil, fmt.Errorf("pgoutput: unexpected column kind %q", kind)
+			}
+		}
+	}
+	return values, r.err
+}
+
+// reader consumes big-endian fields and remembers the first error.
+type reader struct {
+	b   []byte
+	err error
+}
+
+func (r *reader) take(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || len(r.b) < n {
+		r.err = errShortMessage
+		return nil
+	}
+	out := r.b[:n]
+	r.b = r.b[n:]
+	return out
+}
+
+func (r *reader) skip(n int) { r.take(n) }
+
+func (r *reader) byte() byte {
+	if b := r.take(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *reader) uint16() uint16 {
+	if b := r.take(2); b != nil {
+		return binary.BigEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (r *reader) uint32() uint32 {
+	if b := r.take(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (r *reader) uint64() uint64 {
+	if b := r.take(8); b != nil {
+		return binary.BigEndian.Uint64(b)
+	}
+	return 0
+}
+
+func (r *reader) bytes(n int) []byte {
+	b := r.take(n)
+	if b == nil {
+		return nil
+	}
+	return append([]byte{}, b...)
+}
+
+// string reads a NUL-terminated string.
+func (r *reader) string() string {
+	if r.err != nil {
+		return ""
+	}
+	for i, c := range r.b {
+		if c == 0 {
+			s := string(r.b[:i])
+			r.b = r.b[i+1:]
+			return s
+		}
+	}
+	r.err = errShortMessage
+	return ""
+}
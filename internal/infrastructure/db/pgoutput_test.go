@@ -0,0 +1,133 @@
+package db
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// pgoutput test messages, built the way the server encodes them.
+type msgBuilder []byte
+
+func (b msgBuilder) byte(c byte) msgBuilder  { return append(b, c) }
+func (b msgBuilder) u16(v uint16) msgBuilder { return binary.BigEndian.AppendUint16(b, v) }
+func (b msgBuilder) u32(v uint32) msgBuilder { return binary.BigEndian.AppendUint32(b, v) }
+func (b msgBuilder) u64(v uint64) msgBuilder { return binary.BigEndian.AppendUint64(b, v) }
+func (b msgBuilder) str(s string) msgBuilder { return append(append(b, s...), 0) }
+func (b msgBuilder) text(s string) msgBuilder {
+	return append(b.byte('t').u32(uint32(len(s))), s...)
+}
+
+func TestParsePgoutput_RelationInsertCommit(t *testing.T) {
+	rel := msgBuilder{'R'}.u32(16384).str("public").str("outbox").byte('d').u16(3)
+	for _, col := range []string{"id", "event_type", "payload_json"} {
+		rel = rel.byte(1).str(col).u32(25).u32(0xffffffff)
+	}
+	msg, err := parsePgoutput(rel)
+	if err != nil {
+		t.Fatalf("relation: %v", err)
+	}
+	r, ok := msg.(relationMessage)
+	if !ok || r.ID != 16384 || r.Name != "outbox" || len(r.Columns) != 3 || r.Columns[2] != "payload_json" {
+		t.Fatalf("unexpected relation %+v", msg)
+	}
+
+	ins := msgBuilder{'I'}.u32(16384).byte('N').u16(3).
+		text("11111111-1111-1111-1111-111111111111").
+		text("InboxItemCreated").
+		text(`{"a": 1}`)
+	msg, err = parsePgoutput(ins)
+	if err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	rec, err := outboxRecordFromTuple(pgtype.NewMap(), r, msg.(insertMessage).Values)
+	if err != nil {
+		t.Fatalf("outboxRecordFromTuple: %v", err)
+	}
+	if rec.ID != "11111111-1111-1111-1111-111111111111" || rec.EventType != "InboxItemCreated" || string(rec.Payload) != `{"a": 1}` {
+		t.Fatalf("unexpected record %+v", rec)
+	}
+
+	commit := msgBuilder{'C'}.byte(0).u64(0x10).u64(0x20).u64(0)
+	msg, err = parsePgoutput(commit)
+	if err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	if c := msg.(commitMessage); c.CommitLSN != 0x10 || c.EndLSN != 0x20 {
+		t.Fatalf("unexpected commit %+v", c)
+	}
+}
+
+func TestParsePgoutput_Update(t *testing.T) {
+	// With REPLICA IDENTITY FULL the old row comes first; only the new one is kept.
+	upd := msgBuilder{'U'}.u32(7).byte('O').u16(2).text("1").text("SENT").
+		byte('N').u16(2).text("1").byte('u')
+	msg, err := parsePgoutput(upd)
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	m, ok := msg.(updateMessage)
+	if !ok || m.RelationID != 7 || len(m.Values) != 2 || string(m.Values[0]) != "1" || m.Values[1] != nil {
+		t.Fatalf("unexpected update %+v", msg)
+	}
+
+	rel := relationMessage{Name: "outbox", Columns: []string{"id", "status"}}
+	if got := tupleColumn(rel, nil, "status"); got != "" {
+		t.Fatalf("expected no value in an empty tuple, got %q", got)
+	}
+	if got := tupleColumn(rel, [][]byte{[]byte("x"), []byte("PENDING")}, "status"); got != "PENDING" {
+		t.Fatalf("unexpected status %q", got)
+	}
+}
+
+func TestOutboxRecordFromTuple_DecodesTypedColumns(t *testing.T) {
+	rel := relationMessage{Name: "outbox", Columns: []string{"id", "event_type", "payload_json", "seq", "created_at"}}
+	values := [][]byte{[]byte("1"), []byte("E"), []byte(`{}`), []byte("42"), []byte("2026-01-22 10:00:00.5+01")}
+	rec, err := outboxRecordFromTuple(pgtype.NewMap(), rel, values)
+	if err != nil {
+		t.Fatalf("outboxRecordFromTuple: %v", err)
+	}
+	want := time.Date(2026, 1, 22, 9, 0, 0, 500_000_000, time.UTC)
+	if rec.Seq != 42 || !rec.CreatedAt.Equal(want) {
+		t.Fatalf("unexpected seq %d / created_at %v", rec.Seq, rec.CreatedAt)
+	}
+
+	values[4] = []byte("yesterday-ish")
+	if _, err := outboxRecordFromTuple(pgtype.NewMap(), rel, values); err == nil {
+		t.Fatalf("expected an error for an undecodable created_at")
+	}
+}
+
+func TestParsePgoutput_EdgeCases(t *testing.T) {
+	ins := msgBuilder{'I'}.u32(1).byte('N').u16(2).byte('n').text("")
+	msg, err := parsePgoutput(ins)
+	if err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	if v := msg.(insertMessage).Values; v[0] != nil || v[1] == nil || len(v[1]) != 0 {
+		t.Fatalf("expected NULL then empty string, got %q", v)
+	}
+
+	if msg, err := parsePgoutput(msgBuilder{'Y'}.u32(1)); msg != nil || err != nil {
+		t.Fatalf("expected other messages to be skipped, got %v %v", msg, err)
+	}
+	if _, err := parsePgoutput(msgBuilder{'C'}.byte(0).u32(1)); err == nil {
+		t.Fatalf("expected error for a truncated commit")
+	}
+}
+
+func TestLSNString(t *testing.T) {
+	if got := LSN(0x16B3748).String(); got != "0/16B3748" {
+		t.Fatalf("unexpected LSN %s", got)
+	}
+}
+
+func TestStartReplicationSQL_QuotesNames(t *testing.T) {
+	got := startReplicationSQL("inbox_outbox", `pub', publication_names 'x"`)
+	want := `START_REPLICATION SLOT "inbox_outbox" LOGICAL 0/0 (proto_version '1', publication_names '"pub'', publication_names ''x"""')`
+	if got != want {
+		t.Fatalf("expected\n%s\ngot\n%s", want, got)
+	}
+}
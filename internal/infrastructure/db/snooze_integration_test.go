@@ -0,0 +1,79 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"inbox-service/internal/application/commands"
+	"inbox-service/internal/application/ports"
+)
+
+func TestSnooze_HiddenFromFeedUntilWoken(t *testing.T) {
+	pool := newTestPool(t)
+	truncateAll(t, pool)
+
+	tenant := "aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa"
+	user := "bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbbbbb"
+	t0 := time.Now().UTC().Add(-time.Hour)
+
+	insertInboxItem(t, pool, "11111111-1111-1111-1111-111111111111", tenant, user,
+		"TASK_ASSIGNED", "UNREAD", "Snoozed", "Body", "https://x/1",
+		"22222222-2222-2222-2222-222222222222", "dedupe-1", t0)
+	insertInboxItem(t, pool, "33333333-3333-3333-3333-333333333333", tenant, user,
+		"TASK_ASSIGNED", "UNREAD", "Newer", "Body", "https://x/2",
+		"44444444-4444-4444-4444-444444444444", "dedupe-2", t0.Add(time.Minute))
+
+	items := NewInboxItemStorePG()
+	txMgr := NewTxManagerPG(pool)
+	h := commands.NewSnoozeHandler(txMgr, items, items, NewOutboxWriterPG(), NewUnreadCounterPG(pool), NewStreamStorePG(pool))
+
+	until := time.Now().UTC().Add(time.Hour)
+	if _, err := h.Snooze(context.Background(), commands.SnoozeCommand{
+		TenantID: tenant, UserID: user, ItemID: "11111111-1111-1111-1111-111111111111", Until: &until,
+	}); err != nil {
+		t.Fatalf("Snooze: %v", err)
+	}
+
+	r := NewFeedReaderPG(pool)
+	page, err := r.GetFeed(context.Background(), tenant, user, ports.FeedFilter{Limit: 50})
+	if err != nil {
+		t.Fatalf("GetFeed: %v", err)
+	}
+	if len(page.Items) != 1 || page.Items[0].Title != "Newer" {
+		t.Fatalf("expected only 'Newer' while snoozed, got %+v", page.Items)
+	}
+
+	// Pretend the snooze is due and wake it.
+	if _, err := pool.Exec(context.Background(), `
+		UPDATE inbox_items SET snooze_until = $1 WHERE id = '11111111-1111-1111-1111-111111111111'
+	`, time.Now().UTC().Add(-time.Second)); err != nil {
+		t.Fatalf("expire snooze: %v", err)
+	}
+	n, err := commands.NewSnoozeWaker(txMgr, items, NewOutboxWriterPG(), NewUnreadCounterPG(pool), NewStreamStorePG(pool)).WakeDue(context.Background())
+	if err != nil {
+		t.Fatalf("WakeDue: %v", err)
+	}
+	if n != 1 {
+		t.Fatalf("expected 1 woken item, got %d", n)
+	}
+
+	page, err = r.GetFeed(context.Background(), tenant, user, ports.FeedFilter{Limit: 50})
+	if err != nil {
+		t.Fatalf("GetFeed: %v", err)
+	}
+	if len(page.Items) != 2 || page.Items[0].Title != "Snoozed" {
+		t.Fatalf("expected woken item on top, got %+v", page.Items)
+	}
+
+	var events int
+	err = pool.QueryRow(context.Background(), `
+		SELECT COUNT(*) FROM outbox WHERE tenant_id = $1 AND event_type = 'InboxItemUnsnoozed'
+	`, tenant).Scan(&events)
+	if err != nil {
+		t.Fatalf("count outbox: %v", err)
+	}
+	if events != 1 {
+		t.Fatalf("expected 1 InboxItemUnsnoozed event, got %d", events)
+	}
+}
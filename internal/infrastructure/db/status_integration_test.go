@@ -0,0 +1,135 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"inbox-service/internal/application/commands"
+	"inbox-service/internal/application/ports"
+)
+
+func TestStatus_MarkReadWritesVersionAndOutbox(t *testing.T) {
+	pool := newTestPool(t)
+	truncateAll(t, pool)
+
+	tenant := "aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa"
+	user := "bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbbbbb"
+	itemID := "11111111-1111-1111-1111-111111111111"
+
+	insertInboxItem(t, pool,
+		itemID,
+		tenant, user,
+		"TASK_ASSIGNED", "UNREAD",
+		"Title", "Body", "https://x/1",
+		"22222222-2222-2222-2222-222222222222",
+		"dedupe-1",
+		time.Date(2026, 1, 22, 10, 0, 0, 0, time.UTC),
+	)
+
+	h := commands.NewStatusHandler(NewTxManagerPG(pool), NewInboxItemStorePG(), NewOutboxWriterPG(), NewUnreadCounterPG(pool), NewStreamStorePG(pool))
+
+	v := 1
+	it, err := h.MarkRead(context.Background(), tenant, user, itemID, &v)
+	if err != nil {
+		t.Fatalf("MarkRead: %v", err)
+	}
+	if it.Version != 2 {
+		t.Fatalf("expected version 2, got %d", it.Version)
+	}
+
+	var status string
+	var version int
+	err = pool.QueryRow(context.Background(), `SELECT status, version FROM inbox_items WHERE id = $1`, itemID).Scan(&status, &version)
+	if err != nil {
+		t.Fatalf("select inbox_items: %v", err)
+	}
+	if status != "READ" || version != 2 {
+		t.Fatalf("expected READ/2, got %s/%d", status, version)
+	}
+
+	var cnt int
+	err = pool.QueryRow(context.Background(), `
+		SELECT COUNT(*) FROM outbox
+		WHERE tenant_id = $1 AND event_type = 'InboxItemStatusChanged'
+	`, tenant).Scan(&cnt)
+	if err != nil {
+		t.Fatalf("count outbox: %v", err)
+	}
+	if cnt != 1 {
+		t.Fatalf("expected 1 outbox row, got %d", cnt)
+	}
+
+	// Reusing the old version must fail and leave nothing behind.
+	_, err = h.Archive(context.Background(), tenant, user, itemID, &v)
+	if !errors.Is(err, commands.ErrPreconditionFailed) {
+		t.Fatalf("expected ErrPreconditionFailed, got %v", err)
+	}
+
+	_, err = h.MarkRead(context.Background(), tenant, "cccccccc-cccc-cccc-cccc-cccccccccccc", itemID, nil)
+	if !errors.Is(err, ports.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for another user, got %v", err)
+	}
+}
+
+func TestStatus_MarkAllReadRespectsCursorAndType(t *testing.T) {
+	pool := newTestPool(t)
+	truncateAll(t, pool)
+
+	tenant := "aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa"
+	user := "bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbbbbb"
+	t0 := time.Date(2026, 1, 22, 10, 0, 0, 0, time.UTC)
+
+	insertInboxItem(t, pool, "11111111-1111-1111-1111-111111111111", tenant, user,
+		"TASK_ASSIGNED", "UNREAD", "Old", "Body", "https://x/1",
+		"22222222-2222-2222-2222-222222222222", "dedupe-1", t0)
+	insertInboxItem(t, pool, "33333333-3333-3333-3333-333333333333", tenant, user,
+		"TASK_ASSIGNED", "UNREAD", "Old too", "Body", "https://x/2",
+		"44444444-4444-4444-4444-444444444444", "dedupe-2", t0.Add(time.Minute))
+	insertInboxItem(t, pool, "55555555-5555-5555-5555-555555555555", tenant, user,
+		"COMMENT", "UNREAD", "Other type", "Body", "https://x/3",
+		"66666666-6666-6666-6666-666666666666", "dedupe-3", t0)
+	// Arrived after the click.
+	insertInboxItem(t, pool, "77777777-7777-7777-7777-777777777777", tenant, user,
+		"TASK_ASSIGNED", "UNREAD", "New", "Body", "https://x/4",
+		"88888888-8888-8888-8888-888888888888", "dedupe-4", t0.Add(time.Hour))
+
+	h := commands.NewMarkAllReadHandler(NewTxManagerPG(pool), NewInboxItemStorePG(), NewOutboxWriterPG(), NewUnreadCounterPG(pool), NewStreamStorePG(pool))
+	h.ChunkSize = 1
+
+	res, err := h.Handle(context.Background(), commands.MarkAllReadCommand{
+		TenantID: tenant,
+		UserID:   user,
+		Type:     "TASK_ASSIGNED",
+		Before:   &ports.FeedCursor{SortAt: t0.Add(30 * time.Minute)},
+	})
+	if err != nil {
+		t.Fatalf("MarkAllRead: %v", err)
+	}
+	if res.Updated != 2 {
+		t.Fatalf("expected 2 updated, got %d", res.Updated)
+	}
+
+	var unread int
+	err = pool.QueryRow(context.Background(), `
+		SELECT COUNT(*) FROM inbox_items WHERE tenant_id = $1 AND user_id = $2 AND status = 'UNREAD'
+	`, tenant, user).Scan(&unread)
+	if err != nil {
+		t.Fatalf("count unread: %v", err)
+	}
+	if unread != 2 {
+		t.Fatalf("expected 2 items left unread, got %d", unread)
+	}
+
+	var events int
+	err = pool.QueryRow(context.Background(), `
+		SELECT COUNT(*) FROM outbox WHERE tenant_id = $1 AND event_type = 'InboxItemsMarkedRead'
+	`, tenant).Scan(&events)
+	if err != nil {
+		t.Fatalf("count outbox: %v", err)
+	}
+	if events != 1 {
+		t.Fatalf("expected 1 summary event, got %d", events)
+	}
+}
@@ -0,0 +1,111 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"inbox-service/internal/application/ports"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// StreamChannel is the NOTIFY channel that announces new stream events.
+const StreamChannel = "inbox_stream"
+
+// StreamNotification is the NOTIFY payload; clients read the event itself from
+// inbox_stream_events so payload size never matters.
+type StreamNotification struct {
+	Seq      int64  `json:"seq"`
+	TenantID string `json:"tenant_id"`
+	UserID   string `json:"user_id"`
+}
+
+type StreamStorePG struct {
+	pool *pgxpool.Pool
+}
+
+func NewStreamStorePG(pool *pgxpool.Pool) *StreamStorePG {
+	return &StreamStorePG{pool: pool}
+}
+
+// Append writes the event and queues a NOTIFY; Postgres delivers it only if
+// the surrounding transaction commits.
+//
+// A user's appends are serialised until commit, so their seqs become visible
+// in order; otherwise a reader could pass a seq whose transaction commits
+// later and skip that event for good.
+func (s *StreamStorePG) Append(ctx context.Context, tx ports.Tx, e ports.StreamEvent) error {
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtextextended($1::text || '/' || $2::text, 0))`, e.TenantID, e.UserID); err != nil {
+		return fmt.Errorf("lock stream: %w", err)
+	}
+	var seq int64
+	err := tx.QueryRow(ctx, `
+		INSERT INTO inbox_stream_events (tenant_id, user_id, kind, data, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING seq
+	`, e.TenantID, e.UserID, e.Kind, e.Data, time.Now().UTC()).Scan(&seq)
+	if err != nil {
+		return fmt.Errorf("insert stream event: %w", err)
+	}
+
+	payload, err := json.Marshal(StreamNotification{Seq: seq, TenantID: e.TenantID, UserID: e.UserID})
+	if err != nil {
+		return fmt.Errorf("marshal stream notification: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `SELECT pg_notify($1, $2)`, StreamChannel, string(payload)); err != nil {
+		return fmt.Errorf("notify stream: %w", err)
+	}
+	return nil
+}
+
+func (s *StreamStorePG) EventsSince(ctx context.Context, tenantID, userID string, afterSeq int64, limit int) ([]ports.StoredStreamEvent, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT seq, kind, data, created_at
+		FROM inbox_stream_events
+		WHERE tenant_id = $1 AND user_id = $2 AND seq > $3
+		ORDER BY seq
+		LIMIT $4
+	`, tenantID, userID, afterSeq, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query stream events: %w", err)
+	}
+	defer rows.Close()
+
+	var out []ports.StoredStreamEvent
+	for rows.Next() {
+		var e ports.StoredStreamEvent
+		if err := rows.Scan(&e.Seq, &e.Kind, &e.Data, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan stream event: %w", err)
+		}
+		out = append(out, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("stream events rows err: %w", err)
+	}
+	return out, nil
+}
+
+func (s *StreamStorePG) LatestSeq(ctx context.Context, tenantID, userID string) (int64, error) {
+	var seq int64
+	err := s.pool.QueryRow(ctx, `
+		SELECT COALESCE(MAX(seq), 0)
+		FROM inbox_stream_events
+		WHERE tenant_id = $1 AND user_id = $2
+	`, tenantID, userID).Scan(&seq)
+	if err != nil {
+		return 0, fmt.Errorf("latest stream seq: %w", err)
+	}
+	return seq, nil
+}
+
+// Prune deletes events older than the cutoff; clients that were away longer
+// resume from "now".
+func (s *StreamStorePG) Prune(ctx context.Context, olderThan time.Time) (int64, error) {
+	tag, err := s.pool.Exec(ctx, `DELETE FROM inbox_stream_events WHERE created_at < $1`, olderThan)
+	if err != nil {
+		return 0, fmt.Errorf("prune stream events: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,90 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"inbox-service/internal/application/ingest"
+	"inbox-service/internal/application/ports"
+)
+
+func TestStreamStorePG_NotifiesAfterCommitAndReplays(t *testing.T) {
+	pool := newTestPool(t)
+	truncateAll(t, pool)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tenant := "aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa"
+	user := "bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbbbbb"
+
+	notes := make(chan StreamNotification, 10)
+	listenCtx, stopListening := context.WithCancel(ctx)
+	defer stopListening()
+	go func() {
+		_ = NewListenerPG(pool, StreamChannel).Run(listenCtx, func(payload string) {
+			var n StreamNotification
+			if err := json.Unmarshal([]byte(payload), &n); err == nil {
+				notes <- n
+			}
+		})
+	}()
+	// LISTEN is issued asynchronously; give it a moment.
+	time.Sleep(200 * time.Millisecond)
+
+	store := NewStreamStorePG(pool)
+	txMgr := NewTxManagerPG(pool)
+
+	// A rolled back write must not notify or leave an event behind.
+	_ = txMgr.WithTx(ctx, func(ctx context.Context, tx ports.Tx) error {
+		if err := store.Append(ctx, tx, ports.StreamEvent{TenantID: tenant, UserID: user, Kind: "x", Data: []byte(`{}`)}); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+		return context.Canceled
+	})
+
+	h := ingest.NewHandler(txMgr, NewInboxWriterPG(), NewEventDeduperPG(), NewOutboxWriterPG(), NewUnreadCounterPG(pool), store, ingest.DefaultRegistry())
+	itemID, err := h.HandleTaskAssigned(ctx, ingest.TaskAssignedToUser{
+		EventID:        "11111111-1111-1111-1111-111111111111",
+		OccurredAt:     time.Now().UTC(),
+		TenantID:       tenant,
+		TaskID:         "42",
+		AssigneeUserID: user,
+		TaskTitle:      "Task",
+		TaskURL:        "https://app.example.com/tasks/42",
+	})
+	if err != nil {
+		t.Fatalf("HandleTaskAssigned: %v", err)
+	}
+
+	select {
+	case n := <-notes:
+		if n.TenantID != tenant || n.UserID != user {
+			t.Fatalf("unexpected notification %+v", n)
+		}
+	case <-ctx.Done():
+		t.Fatalf("no notification received")
+	}
+
+	events, err := store.EventsSince(ctx, tenant, user, 0, 10)
+	if err != nil {
+		t.Fatalf("EventsSince: %v", err)
+	}
+	if len(events) != 1 || events[0].Kind != ports.StreamItemCreated {
+		t.Fatalf("expected one item.created event, got %+v", events)
+	}
+	var data map[string]any
+	if err := json.Unmarshal(events[0].Data, &data); err != nil || data["inbox_item_id"] != itemID {
+		t.Fatalf("expected event for item %s, got %s", itemID, events[0].Data)
+	}
+
+	latest, err := store.LatestSeq(ctx, tenant, user)
+	if err != nil || latest != events[0].Seq {
+		t.Fatalf("expected latest seq %d, got %d (%v)", events[0].Seq, latest, err)
+	}
+	if more, _ := store.EventsSince(ctx, tenant, user, latest, 10); len(more) != 0 {
+		t.Fatalf("expected nothing after latest seq, got %d", len(more))
+	}
+}
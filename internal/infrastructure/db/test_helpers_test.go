@@ -37,7 +37,7 @@ func truncateAll(t *testing.T, pool *pgxpool.Pool) {
 	defer cancel()
 
 	// Keep this list in sync with your schema
-	_, err := pool.Exec(ctx, `TRUNCATE TABLE inbox_items, processed_events, outbox`)
+	_, err := pool.Exec(ctx, `TRUNCATE TABLE inbox_items, inbox_unread_counters, inbox_stream_events, processed_events, outbox, outbox_keys, outbox_attempts, outbox_archive, admin_audit_log, webhook_subscriptions, webhook_deliveries, webhook_attempts, api_keys`)
 	if err != nil {
 		t.Fatalf("truncate: %v", err)
 	}
@@ -59,14 +59,14 @@ func insertInboxItem(
 			id, tenant_id, user_id, type, status,
 			title, body, action_url,
 			source_event_id, dedupe_key,
-			created_at, updated_at, version
+			created_at, updated_at, sort_at, version
 		) VALUES (
 			$1,$2,$3,$4,$5,
 			$6,$7,$8,
 			$9,$10,
-			$11,$12,1
+			$11,$11,$11,1
 		)
-	`, id, tenantID, userID, typ, status, title, body, url, sourceEventID, dedupeKey, createdAt, createdAt)
+	`, id, tenantID, userID, typ, status, title, body, url, sourceEventID, dedupeKey, createdAt)
 	if err != nil {
 		t.Fatalf("insertInboxItem: %v", fmt.Errorf("%w", err))
 	}
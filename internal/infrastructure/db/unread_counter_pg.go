@@ -0,0 +1,131 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"inbox-service/internal/application/ports"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// reconcileLockKey serialises reconcile runs across replicas.
+const reconcileLockKey = 7_400_001
+
+type UnreadCounterPG struct {
+	pool *pgxpool.Pool
+}
+
+func NewUnreadCounterPG(pool *pgxpool.Pool) *UnreadCounterPG {
+	return &UnreadCounterPG{pool: pool}
+}
+
+func (c *UnreadCounterPG) Adjust(ctx context.Context, tx ports.Tx, tenantID, userID, itemType string, delta int) error {
+	if delta == 0 {
+		return nil
+	}
+	// Clamp at zero; any drift this hides is fixed by Reconcile.
+	_, err := tx.Exec(ctx, `
+		INSERT INTO inbox_unread_counters AS c (tenant_id, user_id, type, unread_count, updated_at)
+		VALUES ($1, $2, $3, GREATEST($4::int, 0), $5)
+		ON CONFLICT (tenant_id, user_id, type) DO UPDATE
+		SET unread_count = GREATEST(c.unread_count + $4::int, 0), updated_at = $5
+	`, tenantID, userID, itemType, delta, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("adjust unread counter: %w", err)
+	}
+	return nil
+}
+
+func (c *UnreadCounterPG) GetUnreadCount(ctx context.Context, tenantID, userID string) (ports.UnreadCount, error) {
+	rows, err := c.pool.Query(ctx, `
+		SELECT type, unread_count
+		FROM inbox_unread_counters
+		WHERE tenant_id = $1 AND user_id = $2 AND unread_count > 0
+	`, tenantID, userID)
+	if err != nil {
+		return ports.UnreadCount{}, fmt.Errorf("query unread count: %w", err)
+	}
+	defer rows.Close()
+
+	out := ports.UnreadCount{ByType: map[string]int{}}
+	for rows.Next() {
+		var typ string
+		var n int
+		if err := rows.Scan(&typ, &n); err != nil {
+			return ports.UnreadCount{}, fmt.Errorf("scan unread count: %w", err)
+		}
+		out.ByType[typ] = n
+		out.Total += n
+	}
+	if err := rows.Err(); err != nil {
+		return ports.UnreadCount{}, fmt.Errorf("unread count rows err: %w", err)
+	}
+	return out, nil
+}
+
+// Reconcile recomputes every counter from inbox_items and fixes the ones that
+// drifted, without overwriting concurrent adjustments. It returns the number
+// of counters repaired. Only one replica runs
+// it at a time; the others return 0 immediately.
+func (c *UnreadCounterPG) Reconcile(ctx context.Context) (int, error) {
+	tx, err := c.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var locked bool
+	if err := tx.QueryRow(ctx, `SELECT pg_try_advisory_xact_lock($1)`, reconcileLockKey).Scan(&locked); err != nil {
+		return 0, fmt.Errorf("reconcile lock: %w", err)
+	}
+	if !locked {
+		return 0, nil
+	}
+
+	// The counters are read in the same snapshot as the items, and a counter
+	// is only written if no Adjust has touched it since (its xmin is
+	// unchanged); otherwise the snapshot's count would undo that Adjust. Such
+	// counters are left for the next run.
+	var repaired int
+	err = tx.QueryRow(ctx, `
+		WITH actual AS (
+			SELECT tenant_id, user_id, type, COUNT(*)::int AS cnt
+			FROM inbox_items
+			WHERE status = 'UNREAD' AND snooze_until IS NULL
+			GROUP BY tenant_id, user_id, type
+		), counters AS (
+			SELECT tenant_id, user_id, type, unread_count, xmin AS version
+			FROM inbox_unread_counters
+		), inserted AS (
+			INSERT INTO inbox_unread_counters (tenant_id, user_id, type, unread_count, updated_at)
+			SELECT a.tenant_id, a.user_id, a.type, a.cnt, $1
+			FROM actual a
+			WHERE NOT EXISTS (
+				SELECT 1 FROM counters s
+				WHERE s.tenant_id = a.tenant_id AND s.user_id = a.user_id AND s.type = a.type
+			)
+			ON CONFLICT (tenant_id, user_id, type) DO NOTHING
+			RETURNING 1
+		), updated AS (
+			UPDATE inbox_unread_counters c
+			SET unread_count = COALESCE(a.cnt, 0), updated_at = $1
+			FROM counters s
+			LEFT JOIN actual a ON a.tenant_id = s.tenant_id AND a.user_id = s.user_id AND a.type = s.type
+			WHERE c.tenant_id = s.tenant_id AND c.user_id = s.user_id AND c.type = s.type
+			  AND s.unread_count <> COALESCE(a.cnt, 0)
+			  AND c.xmin = s.version
+			RETURNING 1
+		)
+		SELECT (SELECT COUNT(*) FROM inserted) + (SELECT COUNT(*) FROM updated)
+	`, time.Now().UTC()).Scan(&repaired)
+	if err != nil {
+		return 0, fmt.Errorf("reconcile unread counters: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("commit: %w", err)
+	}
+	return repaired, nil
+}
@@ -0,0 +1,76 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"inbox-service/internal/application/commands"
+	"inbox-service/internal/application/ingest"
+)
+
+func TestUnreadCounterPG_MaintainedAndReconciled(t *testing.T) {
+	pool := newTestPool(t)
+	truncateAll(t, pool)
+
+	ctx := context.Background()
+	tenant := "aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa"
+	user := "bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbbbbb"
+
+	txMgr := NewTxManagerPG(pool)
+	counter := NewUnreadCounterPG(pool)
+	h := ingest.NewHandler(txMgr, NewInboxWriterPG(), NewEventDeduperPG(), NewOutboxWriterPG(), counter, NewStreamStorePG(pool), ingest.DefaultRegistry())
+
+	var firstID string
+	for i, eventID := range []string{
+		"11111111-1111-1111-1111-111111111111",
+		"22222222-2222-2222-2222-222222222222",
+	} {
+		id, err := h.HandleTaskAssigned(ctx, ingest.TaskAssignedToUser{
+			EventID:        eventID,
+			OccurredAt:     time.Now().UTC(),
+			TenantID:       tenant,
+			TaskID:         []string{"41", "42"}[i],
+			AssigneeUserID: user,
+			TaskTitle:      "Task",
+			TaskURL:        "https://app.example.com/tasks/x",
+		})
+		if err != nil {
+			t.Fatalf("HandleTaskAssigned: %v", err)
+		}
+		if i == 0 {
+			firstID = id
+		}
+	}
+
+	cnt, err := counter.GetUnreadCount(ctx, tenant, user)
+	if err != nil {
+		t.Fatalf("GetUnreadCount: %v", err)
+	}
+	if cnt.Total != 2 || cnt.ByType["TASK_ASSIGNED"] != 2 {
+		t.Fatalf("expected 2 unread, got %+v", cnt)
+	}
+
+	status := commands.NewStatusHandler(txMgr, NewInboxItemStorePG(), NewOutboxWriterPG(), counter, NewStreamStorePG(pool))
+	if _, err := status.MarkRead(ctx, tenant, user, firstID, nil); err != nil {
+		t.Fatalf("MarkRead: %v", err)
+	}
+	if cnt, _ = counter.GetUnreadCount(ctx, tenant, user); cnt.Total != 1 {
+		t.Fatalf("expected 1 unread after MarkRead, got %+v", cnt)
+	}
+
+	// Corrupt the counter and let reconcile repair it.
+	if _, err := pool.Exec(ctx, `UPDATE inbox_unread_counters SET unread_count = 42`); err != nil {
+		t.Fatalf("corrupt counter: %v", err)
+	}
+	repaired, err := counter.Reconcile(ctx)
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	if repaired != 1 {
+		t.Fatalf("expected 1 repaired counter, got %d", repaired)
+	}
+	if cnt, _ = counter.GetUnreadCount(ctx, tenant, user); cnt.Total != 1 {
+		t.Fatalf("expected 1 unread after reconcile, got %+v", cnt)
+	}
+}
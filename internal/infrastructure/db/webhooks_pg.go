@@ -0,0 +1,332 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"inbox-service/internal/application/ports"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type WebhookSubscriptionStorePG struct {
+	pool *pgxpool.Pool
+}
+
+func NewWebhookSubscriptionStorePG(pool *pgxpool.Pool) *WebhookSubscriptionStorePG {
+	return &WebhookSubscriptionStorePG{pool: pool}
+}
+
+const subscriptionColumns = `id, tenant_id, url, event_types, secret, COALESCE(previous_secret, ''), previous_secret_expires_at, enabled, created_at, updated_at`
+
+func scanSubscription(row pgx.Row) (ports.WebhookSubscription, error) {
+	var s ports.WebhookSubscription
+	err := row.Scan(&s.ID, &s.TenantID, &s.URL, &s.EventTypes, &s.Secret, &s.PreviousSecret, &s.PreviousSecretExpiresAt, &s.Enabled, &s.CreatedAt, &s.UpdatedAt)
+	return s, err
+}
+
+func (s *WebhookSubscriptionStorePG) CreateSubscription(ctx context.Context, sub ports.WebhookSubscription) error {
+	_, err := s.pool.Exec(ctx, `
+		INSERT INTO webhook_subscriptions (id, tenant_id, url, event_types, secret, enabled, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, sub.ID, sub.TenantID, sub.URL, sub.EventTypes, sub.Secret, sub.Enabled, sub.CreatedAt, sub.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("insert webhook subscription: %w", err)
+	}
+	return nil
+}
+
+func (s *WebhookSubscriptionStorePG) ListSubscriptions(ctx context.Context, tenantID string) ([]ports.WebhookSubscription, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT `+subscriptionColumns+`
+		FROM webhook_subscriptions
+		WHERE tenant_id = $1
+		ORDER BY created_at, id
+	`, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("list webhook subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	var out []ports.WebhookSubscription
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan webhook subscription: %w", err)
+		}
+		out = append(out, sub)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("webhook subscription rows err: %w", err)
+	}
+	return out, nil
+}
+
+func (s *WebhookSubscriptionStorePG) GetSubscription(ctx context.Context, tenantID, id string) (ports.WebhookSubscription, error) {
+	sub, err := scanSubscription(s.pool.QueryRow(ctx, `
+		SELECT `+subscriptionColumns+`
+		FROM webhook_subscriptions
+		WHERE tenant_id = $1 AND id = $2
+	`, tenantID, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ports.WebhookSubscription{}, ports.ErrNotFound
+	}
+	if err != nil {
+		return ports.WebhookSubscription{}, fmt.Errorf("get webhook subscription: %w", err)
+	}
+	return sub, nil
+}
+
+func (s *WebhookSubscriptionStorePG) UpdateSubscription(ctx context.Context, sub ports.WebhookSubscription) error {
+	tag, err := s.pool.Exec(ctx, `
+		UPDATE webhook_subscriptions
+		SET url = $3, event_types = $4, secret = $5,
+		    previous_secret = NULLIF($6, ''), previous_secret_expires_at = $7,
+		    enabled = $8, updated_at = $9
+		WHERE tenant_id = $1 AND id = $2
+	`, sub.TenantID, sub.ID, sub.URL, sub.EventTypes, sub.Secret, sub.PreviousSecret, sub.PreviousSecretExpiresAt, sub.Enabled, sub.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("update webhook subscription: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ports.ErrNotFound
+	}
+	return nil
+}
+
+func (s *WebhookSubscriptionStorePG) DeleteSubscription(ctx context.Context, tenantID, id string) error {
+	tag, err := s.pool.Exec(ctx, `DELETE FROM webhook_subscriptions WHERE tenant_id = $1 AND id = $2`, tenantID, id)
+	if err != nil {
+		return fmt.Errorf("delete webhook subscription: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ports.ErrNotFound
+	}
+	return nil
+}
+
+type WebhookDeliveryStorePG struct {
+	pool *pgxpool.Pool
+}
+
+func NewWebhookDeliveryStorePG(pool *pgxpool.Pool) *WebhookDeliveryStorePG {
+	return &WebhookDeliveryStorePG{pool: pool}
+}
+
+const deliveryColumns = `id, subscription_id, tenant_id, outbox_id, event_type, status, attempts, next_run_at, COALESCE(last_error, ''), COALESCE(last_status_code, 0), created_at, updated_at, version`
+
+func scanDelivery(row pgx.Row) (ports.WebhookDelivery, error) {
+	var d ports.WebhookDelivery
+	err := row.Scan(&d.ID, &d.SubscriptionID, &d.TenantID, &d.OutboxID, &d.EventType, &d.Status, &d.Attempts, &d.NextRunAt, &d.LastError, &d.LastStatusCode, &d.CreatedAt, &d.UpdatedAt, &d.Version)
+	return d, err
+}
+
+func (s *WebhookDeliveryStorePG) EnqueueDeliveries(ctx context.Context, m ports.Message) (int, error) {
+	headers := m.Headers
+	if headers == nil {
+		headers = map[string]string{}
+	}
+	tag, err := s.pool.Exec(ctx, `
+		INSERT INTO webhook_deliveries (
+			id, subscription_id, tenant_id, outbox_id, event_type, message_key, headers, payload_json,
+			status, attempts, next_run_at, created_at, updated_at, version
+		)
+		SELECT gen_random_uuid(), s.id, s.tenant_id, $2::uuid, $3::text, $4::text, $5::jsonb, $6::jsonb,
+			'PENDING', 0, $7::timestamptz, $7::timestamptz, $7::timestamptz, 1
+		FROM webhook_subscriptions s
+		WHERE s.tenant_id = $1::uuid AND s.enabled
+		  AND (cardinality(s.event_types) = 0 OR $3::text = ANY (s.event_types))
+		ON CONFLICT (subscription_id, outbox_id) DO NOTHING
+	`, m.TenantID, m.ID, m.EventType, m.Key, headers, m.Payload, time.Now().UTC())
+	if err != nil {
+		return 0, fmt.Errorf("enqueue webhook deliveries: %w", err)
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+// ClaimDeliveries skips deliveries of disabled subscriptions; they stay
+// PENDING until the subscription is enabled again.
+func (s *WebhookDeliveryStorePG) ClaimDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]ports.WebhookTarget, error) {
+	rows, err := s.pool.Query(ctx, `
+		WITH due AS (
+			SELECT d.id
+			FROM webhook_deliveries d
+			JOIN webhook_subscriptions s ON s.id = d.subscription_id AND s.enabled
+			WHERE d.status IN ('PENDING', 'PROCESSING') AND d.next_run_at <= $1
+			ORDER BY d.next_run_at, d.id
+			LIMIT $3
+			FOR UPDATE OF d SKIP LOCKED
+		)
+		UPDATE webhook_deliveries d
+		SET status = 'PROCESSING', next_run_at = $2, updated_at = $1, version = d.version + 1
+		FROM due, webhook_subscriptions s
+		WHERE d.id = due.id AND s.id = d.subscription_id
+		RETURNING d.id, d.subscription_id, d.tenant_id, d.outbox_id, d.event_type, d.status, d.attempts,
+			d.next_run_at, COALESCE(d.last_error, ''), COALESCE(d.last_status_code, 0),
+			d.created_at, d.updated_at, d.version,
+			d.message_key, d.headers, d.payload_json,
+			s.url, s.secret, COALESCE(s.previous_secret, ''), s.previous_secret_expires_at
+	`, now, leaseUntil, limit)
+	if err != nil {
+		return nil, fmt.Errorf("claim webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var out []ports.WebhookTarget
+	for rows.Next() {
+		var t ports.WebhookTarget
+		var secret, prev string
+		var prevUntil *time.Time
+		d := &t.Delivery
+		err := rows.Scan(&d.ID, &d.SubscriptionID, &d.TenantID, &d.OutboxID, &d.EventType, &d.Status, &d.Attempts,
+			&d.NextRunAt, &d.LastError, &d.LastStatusCode, &d.CreatedAt, &d.UpdatedAt, &d.Version,
+			&t.Message.Key, &t.Message.Headers, &t.Message.Payload,
+			&t.URL, &secret, &prev, &prevUntil)
+		if err != nil {
+			return nil, fmt.Errorf("scan webhook delivery: %w", err)
+		}
+		t.Message.ID, t.Message.TenantID, t.Message.EventType = d.OutboxID, d.TenantID, d.EventType
+		t.Secrets = []string{secret}
+		if prev != "" && prevUntil != nil && now.Before(*prevUntil) {
+			t.Secrets = append(t.Secrets, prev)
+		}
+		out = append(out, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("webhook delivery rows err: %w", err)
+	}
+	return out, nil
+}
+
+func (s *WebhookDeliveryStorePG) RecordAttempt(ctx context.Context, d ports.WebhookDelivery, a ports.WebhookAttempt, status string, nextRunAt time.Time) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE webhook_deliveries
+		SET status = $3, attempts = $4, next_run_at = $5,
+		    last_error = NULLIF($6, ''), last_status_code = NULLIF($7, 0),
+		    updated_at = $8, version = version + 1
+		WHERE id = $1 AND version = $2 AND status = 'PROCESSING'
+	`, d.ID, d.Version, status, a.Attempt, nextRunAt, a.Error, a.StatusCode, a.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("update webhook delivery: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ports.ErrLeaseLost
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO webhook_attempts (delivery_id, attempt, status_code, error, duration_ms, created_at)
+		VALUES ($1, $2, NULLIF($3, 0), NULLIF($4, ''), $5, $6)
+	`, d.ID, a.Attempt, a.StatusCode, a.Error, a.Duration.Milliseconds(), a.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("insert webhook attempt: %w", err)
+	}
+	return tx.Commit(ctx)
+}
+
+func (s *WebhookDeliveryStorePG) Postpone(ctx context.Context, d ports.WebhookDelivery, nextRunAt time.Time) error {
+	tag, err := s.pool.Exec(ctx, `
+		UPDATE webhook_deliveries
+		SET status = 'PENDING', next_run_at = $3, updated_at = $4, version = version + 1
+		WHERE id = $1 AND version = $2 AND status = 'PROCESSING'
+	`, d.ID, d.Version, nextRunAt, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("postpone webhook delivery: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ports.ErrLeaseLost
+	}
+	return nil
+}
+
+func (s *WebhookDeliveryStorePG) ListDeliveries(ctx context.Context, tenantID, subscriptionID string, limit int) ([]ports.WebhookDelivery, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT `+deliveryColumns+`
+		FROM webhook_deliveries
+		WHERE tenant_id = $1 AND subscription_id = $2
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3
+	`, tenantID, subscriptionID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var out []ports.WebhookDelivery
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan webhook delivery: %w", err)
+		}
+		out = append(out, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("webhook delivery rows err: %w", err)
+	}
+	return out, nil
+}
+
+func (s *WebhookDeliveryStorePG) GetDelivery(ctx context.Context, tenantID, id string) (ports.WebhookDelivery, []ports.WebhookAttempt, error) {
+	d, err := scanDelivery(s.pool.QueryRow(ctx, `
+		SELECT `+deliveryColumns+`
+		FROM webhook_deliveries
+		WHERE tenant_id = $1 AND id = $2
+	`, tenantID, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ports.WebhookDelivery{}, nil, ports.ErrNotFound
+	}
+	if err != nil {
+		return ports.WebhookDelivery{}, nil, fmt.Errorf("get webhook delivery: %w", err)
+	}
+
+	rows, err := s.pool.Query(ctx, `
+		SELECT attempt, COALESCE(status_code, 0), COALESCE(error, ''), duration_ms, created_at
+		FROM webhook_attempts
+		WHERE delivery_id = $1
+		ORDER BY id
+	`, d.ID)
+	if err != nil {
+		return ports.WebhookDelivery{}, nil, fmt.Errorf("list webhook attempts: %w", err)
+	}
+	defer rows.Close()
+
+	var attempts []ports.WebhookAttempt
+	for rows.Next() {
+		var a ports.WebhookAttempt
+		var ms int64
+		if err := rows.Scan(&a.Attempt, &a.StatusCode, &a.Error, &ms, &a.CreatedAt); err != nil {
+			return ports.WebhookDelivery{}, nil, fmt.Errorf("scan webhook attempt: %w", err)
+		}
+		a.Duration = time.Duration(ms) * time.Millisecond
+		attempts = append(attempts, a)
+	}
+	if err := rows.Err(); err != nil {
+		return ports.WebhookDelivery{}, nil, fmt.Errorf("webhook attempt rows err: %w", err)
+	}
+	return d, attempts, nil
+}
+
+// ReplayDelivery leaves the attempt history alone; a PROCESSING delivery
+// loses its lease, so the worker sending it cannot record over the replay.
+func (s *WebhookDeliveryStorePG) ReplayDelivery(ctx context.Context, tenantID, id string, now time.Time) error {
+	tag, err := s.pool.Exec(ctx, `
+		UPDATE webhook_deliveries
+		SET status = 'PENDING', attempts = 0, next_run_at = $3, last_error = NULL,
+		    updated_at = $3, version = version + 1
+		WHERE tenant_id = $1 AND id = $2
+	`, tenantID, id, now)
+	if err != nil {
+		return fmt.Errorf("replay webhook delivery: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ports.ErrNotFound
+	}
+	return nil
+}
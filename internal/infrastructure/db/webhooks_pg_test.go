@@ -0,0 +1,126 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"inbox-service/internal/application/ports"
+)
+
+func TestWebhookDeliveries_EnqueueClaimRecordReplay(t *testing.T) {
+	pool := newTestPool(t)
+	truncateAll(t, pool)
+
+	ctx := context.Background()
+	subs := NewWebhookSubscriptionStorePG(pool)
+	deliveries := NewWebhookDeliveryStorePG(pool)
+
+	tenant := "aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa"
+	other := "bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbbbbb"
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	expires := now.Add(time.Hour)
+	for _, s := range []ports.WebhookSubscription{
+		{ID: "11111111-1111-1111-1111-111111111111", TenantID: tenant, URL: "https://a.example/hook", Secret: "new", Enabled: true},
+		{ID: "22222222-2222-2222-2222-222222222222", TenantID: tenant, URL: "https://b.example/hook", Secret: "s", EventTypes: []string{"InboxItemRead"}, Enabled: true},
+		{ID: "33333333-3333-3333-3333-333333333333", TenantID: tenant, URL: "https://c.example/hook", Secret: "s", Enabled: false},
+		{ID: "44444444-4444-4444-4444-444444444444", TenantID: other, URL: "https://d.example/hook", Secret: "s", Enabled: true},
+	} {
+		s.CreatedAt, s.UpdatedAt = now, now
+		if err := subs.CreateSubscription(ctx, s); err != nil {
+			t.Fatalf("CreateSubscription: %v", err)
+		}
+	}
+	rotated, err := subs.GetSubscription(ctx, tenant, "11111111-1111-1111-1111-111111111111")
+	if err != nil {
+		t.Fatalf("GetSubscription: %v", err)
+	}
+	rotated.PreviousSecret, rotated.PreviousSecretExpiresAt = "old", &expires
+	if err := subs.UpdateSubscription(ctx, rotated); err != nil {
+		t.Fatalf("UpdateSubscription: %v", err)
+	}
+	if _, err := subs.GetSubscription(ctx, other, rotated.ID); !errors.Is(err, ports.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for another tenant, got %v", err)
+	}
+
+	m := ports.Message{
+		ID:        "55555555-5555-5555-5555-555555555555",
+		TenantID:  tenant,
+		EventType: "InboxItemCreated",
+		Key:       "k",
+		Headers:   map[string]string{ports.HeaderEventType: "InboxItemCreated"},
+		Payload:   []byte(`{"k":"v"}`),
+	}
+	// Only the enabled, unfiltered subscription of the tenant gets a delivery,
+	// and only once.
+	for _, want := range []int{1, 0} {
+		n, err := deliveries.EnqueueDeliveries(ctx, m)
+		if err != nil {
+			t.Fatalf("EnqueueDeliveries: %v", err)
+		}
+		if n != want {
+			t.Fatalf("expected %d deliveries created, got %d", want, n)
+		}
+	}
+
+	claimAt := time.Now().UTC().Add(time.Second)
+	targets, err := deliveries.ClaimDeliveries(ctx, claimAt, claimAt.Add(time.Minute), 10)
+	if err != nil || len(targets) != 1 {
+		t.Fatalf("ClaimDeliveries: %v (%d rows)", err, len(targets))
+	}
+	target := targets[0]
+	if target.URL != "https://a.example/hook" || len(target.Secrets) != 2 || target.Secrets[0] != "new" || target.Secrets[1] != "old" {
+		t.Fatalf("unexpected target %+v", target)
+	}
+	if target.Message.ID != m.ID || string(target.Message.Payload) != `{"k": "v"}` || target.Message.Headers[ports.HeaderEventType] != "InboxItemCreated" {
+		t.Fatalf("unexpected message %+v", target.Message)
+	}
+	if again, _ := deliveries.ClaimDeliveries(ctx, claimAt, claimAt.Add(time.Minute), 10); len(again) != 0 {
+		t.Fatalf("expected the leased delivery not to be claimed again, got %d", len(again))
+	}
+
+	failed := ports.WebhookAttempt{Attempt: 1, StatusCode: 503, Error: "status 503", Duration: 20 * time.Millisecond, CreatedAt: claimAt}
+	if err := deliveries.RecordAttempt(ctx, target.Delivery, failed, ports.DeliveryFailed, claimAt); err != nil {
+		t.Fatalf("RecordAttempt: %v", err)
+	}
+	if err := deliveries.RecordAttempt(ctx, target.Delivery, failed, ports.DeliveryFailed, claimAt); !errors.Is(err, ports.ErrLeaseLost) {
+		t.Fatalf("expected ErrLeaseLost for a stale version, got %v", err)
+	}
+
+	list, err := deliveries.ListDeliveries(ctx, tenant, rotated.ID, 10)
+	if err != nil || len(list) != 1 || list[0].Status != ports.DeliveryFailed || list[0].LastStatusCode != 503 {
+		t.Fatalf("ListDeliveries: %v %+v", err, list)
+	}
+	if _, _, err := deliveries.GetDelivery(ctx, other, target.Delivery.ID); !errors.Is(err, ports.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for another tenant, got %v", err)
+	}
+
+	if err := deliveries.ReplayDelivery(ctx, tenant, target.Delivery.ID, claimAt); err != nil {
+		t.Fatalf("ReplayDelivery: %v", err)
+	}
+	targets, err = deliveries.ClaimDeliveries(ctx, claimAt, claimAt.Add(time.Minute), 10)
+	if err != nil || len(targets) != 1 || targets[0].Delivery.Attempts != 0 {
+		t.Fatalf("expected the replayed delivery to be claimable with a fresh budget: %v %+v", err, targets)
+	}
+	sent := ports.WebhookAttempt{Attempt: 1, StatusCode: 204, Duration: 5 * time.Millisecond, CreatedAt: claimAt}
+	if err := deliveries.RecordAttempt(ctx, targets[0].Delivery, sent, ports.DeliverySent, claimAt); err != nil {
+		t.Fatalf("RecordAttempt: %v", err)
+	}
+
+	d, attempts, err := deliveries.GetDelivery(ctx, tenant, target.Delivery.ID)
+	if err != nil {
+		t.Fatalf("GetDelivery: %v", err)
+	}
+	if d.Status != ports.DeliverySent || len(attempts) != 2 || attempts[0].StatusCode != 503 || attempts[1].StatusCode != 204 {
+		t.Fatalf("unexpected delivery %+v with attempts %+v", d, attempts)
+	}
+
+	// Deleting the subscription takes its deliveries with it.
+	if err := subs.DeleteSubscription(ctx, tenant, rotated.ID); err != nil {
+		t.Fatalf("DeleteSubscription: %v", err)
+	}
+	if _, _, err := deliveries.GetDelivery(ctx, tenant, target.Delivery.ID); !errors.Is(err, ports.ErrNotFound) {
+		t.Fatalf("expected the delivery to be gone, got %v", err)
+	}
+}
@@ -0,0 +1,240 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"inbox-service/internal/application/admin"
+	"inbox-service/internal/application/ports"
+
+	"github.com/labstack/echo/v4"
+)
+
+// outboxFilterBody is the filter accepted by the admin outbox endpoints: in
+// the query string for listing (without ids) and as the JSON body of
+// mutations. Ages are Go durations ("36h") relative to now.
+type outboxFilterBody struct {
+	IDs       []string `json:"ids"`
+	Status    string   `json:"status"`
+	TenantID  string   `json:"tenant_id"`
+	EventType string   `json:"event_type"`
+	OlderThan string   `json:"older_than"`
+	NewerThan string   `json:"newer_than"`
+}
+
+func (b outboxFilterBody) filter(now time.Time) (ports.OutboxFilter, error) {
+	f := ports.OutboxFilter{
+		IDs:       b.IDs,
+		Status:    strings.ToUpper(b.Status),
+		TenantID:  b.TenantID,
+		EventType: b.EventType,
+	}
+	if b.OlderThan != "" {
+		d, err := time.ParseDuration(b.OlderThan)
+		if err != nil {
+			return f, fmt.Errorf("invalid older_than; expected a duration such as 24h")
+		}
+		t := now.Add(-d)
+		f.CreatedBefore = &t
+	}
+	if b.NewerThan != "" {
+		d, err := time.ParseDuration(b.NewerThan)
+		if err != nil {
+			return f, fmt.Errorf("invalid newer_than; expected a duration such as 1h")
+		}
+		t := now.Add(-d)
+		f.CreatedAfter = &t
+	}
+	return f, nil
+}
+
+// ListOutbox lists outbox rows newest first. Page with the returned next
+// before_created_at/before_id.
+func (h *Handlers) ListOutbox(c echo.Context) error {
+	body := outboxFilterBody{
+		Status:    c.QueryParam("status"),
+		TenantID:  c.QueryParam("tenant_id"),
+		EventType: c.QueryParam("event_type"),
+		OlderThan: c.QueryParam("older_than"),
+		NewerThan: c.QueryParam("newer_than"),
+	}
+	f, err := body.filter(time.Now().UTC())
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]any{"error": err.Error()})
+	}
+
+	limit := 0
+	if v := c.QueryParam("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]any{"error": "invalid limit"})
+		}
+	}
+
+	var after *ports.OutboxPosition
+	if bc, bi := c.QueryParam("before_created_at"), c.QueryParam("before_id"); bc != "" || bi != "" {
+		t, err := time.Parse(time.RFC3339Nano, bc)
+		if err != nil || bi == "" {
+			return c.JSON(http.StatusBadRequest, map[string]any{"error": "before_created_at (RFC3339Nano) and before_id go together"})
+		}
+		after = &ports.OutboxPosition{CreatedAt: t, ID: bi}
+	}
+
+	page, err := h.OutboxAdmin.List(c.Request().Context(), admin.ListOutboxQuery{Filter: f, After: after, Limit: limit})
+	if err != nil {
+		return adminError(c, err)
+	}
+
+	rows := make([]map[string]any, 0, len(page.Rows))
+	for _, r := range page.Rows {
+		rows = append(rows, outboxRowJSON(r))
+	}
+	resp := map[string]any{"rows": rows}
+	if page.Next != nil {
+		resp["next"] = map[string]any{
+			"before_created_at": page.Next.CreatedAt.Format(time.RFC3339Nano),
+			"before_id":         page.Next.ID,
+		}
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// ListBlockedOutboxKeys lists the partition keys parked behind a FAILED row,
+// with the number of rows waiting on each.
+func (h *Handlers) ListBlockedOutboxKeys(c echo.Context) error {
+	limit := 0
+	if v := c.QueryParam("limit"); v != "" {
+		var err error
+		if limit, err = strconv.Atoi(v); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]any{"error": "invalid limit"})
+		}
+	}
+	keys, err := h.OutboxAdmin.BlockedKeys(c.Request().Context(), limit)
+	if err != nil {
+		return adminError(c, err)
+	}
+
+	out := make([]map[string]any, 0, len(keys))
+	for _, k := range keys {
+		entry := map[string]any{
+			"partition_key": k.PartitionKey,
+			"tenant_id":     k.TenantID,
+			"failed_id":     k.FailedID,
+			"failed_seq":    k.FailedSeq,
+			"failed_at":     k.FailedAt.Format(time.RFC3339Nano),
+			"waiting":       k.Waiting,
+		}
+		if k.LastError != "" {
+			entry["last_error"] = k.LastError
+		}
+		out = append(out, entry)
+	}
+	return c.JSON(http.StatusOK, map[string]any{"keys": out})
+}
+
+// GetOutbox returns one row with its payload and attempt history.
+func (h *Handlers) GetOutbox(c echo.Context) error {
+	row, attempts, err := h.OutboxAdmin.Get(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return adminError(c, err)
+	}
+
+	resp := outboxRowJSON(row)
+	resp["payload"] = json.RawMessage(row.Payload)
+	history := make([]map[string]any, 0, len(attempts))
+	for _, a := range attempts {
+		entry := map[string]any{
+			"attempt":    a.Attempt,
+			"outcome":    a.Outcome,
+			"created_at": a.CreatedAt.Format(time.RFC3339Nano),
+		}
+		if a.Error != "" {
+			entry["error"] = a.Error
+		}
+		history = append(history, entry)
+	}
+	resp["history"] = history
+	return c.JSON(http.StatusOK, resp)
+}
+
+// RequeueOutbox resets matching FAILED rows to PENDING with a fresh attempt
+// budget. An empty body requeues every FAILED row.
+func (h *Handlers) RequeueOutbox(c echo.Context) error {
+	return h.mutateOutbox(c, h.OutboxAdmin.Requeue)
+}
+
+// SkipOutbox marks matching PENDING or FAILED rows SKIPPED so the relay leaves
+// them alone.
+func (h *Handlers) SkipOutbox(c echo.Context) error {
+	return h.mutateOutbox(c, h.OutboxAdmin.Skip)
+}
+
+// PurgeOutbox deletes SENT rows older than older_than_days.
+func (h *Handlers) PurgeOutbox(c echo.Context) error {
+	var body struct {
+		OlderThanDays int `json:"older_than_days"`
+	}
+	if err := c.Bind(&body); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]any{"error": "invalid json"})
+	}
+	n, err := h.OutboxAdmin.PurgeSent(c.Request().Context(), principal(c).Subject, body.OlderThanDays)
+	if err != nil {
+		return adminError(c, err)
+	}
+	return c.JSON(http.StatusOK, map[string]any{"deleted": n})
+}
+
+func (h *Handlers) mutateOutbox(c echo.Context, fn func(ctx context.Context, actor string, f ports.OutboxFilter) (int, error)) error {
+	var body outboxFilterBody
+	if err := c.Bind(&body); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]any{"error": "invalid json"})
+	}
+	f, err := body.filter(time.Now().UTC())
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]any{"error": err.Error()})
+	}
+	n, err := fn(c.Request().Context(), principal(c).Subject, f)
+	if err != nil {
+		return adminError(c, err)
+	}
+	return c.JSON(http.StatusOK, map[string]any{"updated": n})
+}
+
+func outboxRowJSON(r ports.OutboxRow) map[string]any {
+	resp := map[string]any{
+		"id":            r.ID,
+		"tenant_id":     r.TenantID,
+		"event_type":    r.EventType,
+		"partition_key": r.PartitionKey,
+		"seq":           r.Seq,
+		"status":        r.Status,
+		"attempts":      r.Attempts,
+		"next_run_at":   r.NextRunAt.Format(time.RFC3339Nano),
+		"created_at":    r.CreatedAt.Format(time.RFC3339Nano),
+		"updated_at":    r.UpdatedAt.Format(time.RFC3339Nano),
+		"version":       r.Version,
+	}
+	if r.LastError != "" {
+		resp["last_error"] = r.LastError
+	}
+	if len(r.Headers) > 0 {
+		resp["headers"] = r.Headers
+	}
+	return resp
+}
+
+func adminError(c echo.Context, err error) error {
+	code := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, admin.ErrInvalidRequest):
+		code = http.StatusBadRequest
+	case errors.Is(err, ports.ErrNotFound):
+		code = http.StatusNotFound
+	}
+	return c.JSON(code, map[string]any{"error": err.Error()})
+}
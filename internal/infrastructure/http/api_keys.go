@@ -0,0 +1,87 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"inbox-service/internal/application/apikeys"
+	"inbox-service/internal/application/ports"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ListAPIKeys lists the caller's tenant's ingest API keys, revoked ones
+// included. Keys themselves are never returned after creation.
+func (h *Handlers) ListAPIKeys(c echo.Context) error {
+	keys, err := h.APIKeys.List(c.Request().Context(), principal(c).TenantID)
+	if err != nil {
+		return apiKeyError(c, err)
+	}
+	out := make([]map[string]any, 0, len(keys))
+	for _, k := range keys {
+		out = append(out, apiKeyJSON(k))
+	}
+	return c.JSON(http.StatusOK, map[string]any{"api_keys": out})
+}
+
+// CreateAPIKey issues a key for the caller's tenant, e.g.
+// {"name":"tasks-service","event_types":["TaskAssignedToUser"]}.
+// The response holds the key; store it, it can't be shown again.
+func (h *Handlers) CreateAPIKey(c echo.Context) error {
+	var body struct {
+		Name       string   `json:"name"`
+		TenantIDs  []string `json:"tenant_ids"`
+		EventTypes []string `json:"event_types"`
+	}
+	if err := c.Bind(&body); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]any{"error": "invalid json"})
+	}
+	p := principal(c)
+	k, plain, err := h.APIKeys.Create(c.Request().Context(), p.Subject, p.TenantID, apikeys.Input{
+		Name:       body.Name,
+		TenantIDs:  body.TenantIDs,
+		EventTypes: body.EventTypes,
+	})
+	if err != nil {
+		return apiKeyError(c, err)
+	}
+	resp := apiKeyJSON(k)
+	resp["key"] = plain
+	return c.JSON(http.StatusCreated, resp)
+}
+
+// RevokeAPIKey disables a key; requests using it fail from then on.
+func (h *Handlers) RevokeAPIKey(c echo.Context) error {
+	p := principal(c)
+	if err := h.APIKeys.Revoke(c.Request().Context(), p.Subject, p.TenantID, c.Param("id")); err != nil {
+		return apiKeyError(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func apiKeyJSON(k ports.APIKey) map[string]any {
+	resp := map[string]any{
+		"id":          k.ID,
+		"name":        k.Name,
+		"prefix":      k.Prefix,
+		"tenant_ids":  append([]string{}, k.TenantIDs...),
+		"event_types": append([]string{}, k.EventTypes...),
+		"created_at":  k.CreatedAt.Format(time.RFC3339Nano),
+	}
+	if k.RevokedAt != nil {
+		resp["revoked_at"] = k.RevokedAt.Format(time.RFC3339Nano)
+	}
+	return resp
+}
+
+func apiKeyError(c echo.Context, err error) error {
+	code := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, apikeys.ErrInvalidRequest):
+		code = http.StatusBadRequest
+	case errors.Is(err, ports.ErrNotFound):
+		code = http.StatusNotFound
+	}
+	return c.JSON(code, map[string]any{"error": err.Error()})
+}
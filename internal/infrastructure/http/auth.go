@@ -0,0 +1,160 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"inbox-service/internal/application/apikeys"
+	"inbox-service/internal/application/ingest"
+	"inbox-service/internal/application/ports"
+	"inbox-service/internal/infrastructure/auth"
+
+	"github.com/labstack/echo/v4"
+)
+
+// AuthConfig selects how callers are authenticated.
+type AuthConfig struct {
+	JWT *auth.JWTVerifier
+	// DevHeaders trusts X-Tenant-Id, X-User-Id and X-Roles instead of a token.
+	// Never enable it outside local development.
+	DevHeaders bool
+	// AdminRole is required on the tenant's /v1/admin routes; "admin" when
+	// empty.
+	AdminRole string
+	// OperatorRole is required on /v1/admin/outbox, which spans all tenants;
+	// "operator" when empty. Only platform operators should hold it.
+	OperatorRole string
+	// WebhookRole is required to manage the tenant's webhook subscriptions;
+	// "webhook-admin" when empty.
+	WebhookRole string
+	// IngestRole is required of tokens on the ingest routes; "ingest" when
+	// empty.
+	IngestRole string
+	// APIKeys checks the service keys accepted on the ingest routes; nil
+	// accepts none.
+	APIKeys APIKeyAuthenticator
+}
+
+// HeaderAPIKey carries a service API key.
+const HeaderAPIKey = "X-Api-Key"
+
+// APIKeyAuthenticator is implemented by *apikeys.Keys.
+type APIKeyAuthenticator interface {
+	Authenticate(ctx context.Context, key string) (ports.APIKey, error)
+}
+
+// Authenticate puts an auth.Principal in the request context or answers 401.
+// allowQueryToken also accepts ?access_token=, for EventSource clients that
+// cannot set headers.
+func Authenticate(cfg AuthConfig, allowQueryToken bool) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+
+			var p auth.Principal
+			if cfg.DevHeaders {
+				p = auth.Principal{
+					TenantID: req.Header.Get("X-Tenant-Id"),
+					UserID:   req.Header.Get("X-User-Id"),
+					Subject:  req.Header.Get("X-User-Id"),
+				}
+				if roles := req.Header.Get("X-Roles"); roles != "" {
+					p.Roles = strings.Split(roles, ",")
+				}
+				if p.TenantID == "" || p.UserID == "" {
+					return unauthorized(c, "X-Tenant-Id and X-User-Id are required")
+				}
+			} else {
+				token := c.QueryParam("access_token")
+				if !allowQueryToken || token == "" {
+					t, err := auth.BearerToken(req.Header.Get(echo.HeaderAuthorization))
+					if err != nil {
+						return unauthorized(c, err.Error())
+					}
+					token = t
+				}
+				v, err := cfg.JWT.Verify(req.Context(), token)
+				if err != nil {
+					return unauthorized(c, err.Error())
+				}
+				p = v
+			}
+
+			c.SetRequest(req.WithContext(auth.WithPrincipal(req.Context(), p)))
+			return next(c)
+		}
+	}
+}
+
+// AuthenticateIngest admits producers on the ingest routes: a service API key
+// in X-Api-Key, which may ingest for its tenants and event types, or else a
+// token (e.g. from a client-credentials grant) with role, which may ingest for
+// its own tenant. Events outside that scope are rejected by the pipeline.
+func AuthenticateIngest(cfg AuthConfig, role string) echo.MiddlewareFunc {
+	viaToken := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return Authenticate(cfg, false)(RequireRole(role)(ScopeIngest()(next)))
+	}
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		tokenChain := viaToken(next)
+		return func(c echo.Context) error {
+			req := c.Request()
+			key := req.Header.Get(HeaderAPIKey)
+			if key == "" {
+				return tokenChain(c)
+			}
+			if cfg.APIKeys == nil {
+				return unauthorized(c, "api keys are not accepted")
+			}
+			k, err := cfg.APIKeys.Authenticate(req.Context(), key)
+			if errors.Is(err, apikeys.ErrInvalidKey) {
+				return unauthorized(c, err.Error())
+			}
+			if err != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]any{"error": err.Error()})
+			}
+			ctx := auth.WithPrincipal(req.Context(), auth.Principal{Subject: "apikey:" + k.ID})
+			ctx = ingest.WithScope(ctx, ingest.Scope{TenantIDs: k.TenantIDs, EventTypes: k.EventTypes})
+			c.SetRequest(req.WithContext(ctx))
+			return next(c)
+		}
+	}
+}
+
+// ScopeIngest holds the events a caller pushes to its own tenant. It must run
+// after Authenticate.
+func ScopeIngest() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			scope := ingest.Scope{TenantIDs: []string{principal(c).TenantID}}
+			c.SetRequest(req.WithContext(ingest.WithScope(req.Context(), scope)))
+			return next(c)
+		}
+	}
+}
+
+// RequireRole answers 403 unless the authenticated principal has role. It must
+// run after Authenticate.
+func RequireRole(role string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !principal(c).HasRole(role) {
+				return c.JSON(http.StatusForbidden, map[string]any{"error": "requires role " + role})
+			}
+			return next(c)
+		}
+	}
+}
+
+func unauthorized(c echo.Context, msg string) error {
+	c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer realm="inbox"`)
+	return c.JSON(http.StatusUnauthorized, map[string]any{"error": msg})
+}
+
+// principal is set by Authenticate on every /v1/inbox and /v1/admin route.
+func principal(c echo.Context) auth.Principal {
+	p, _ := auth.PrincipalFrom(c.Request().Context())
+	return p
+}
@@ -0,0 +1,190 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"inbox-service/internal/application/apikeys"
+	"inbox-service/internal/application/ingest"
+	"inbox-service/internal/application/ports"
+	"inbox-service/internal/infrastructure/auth"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+)
+
+func serve(t *testing.T, cfg AuthConfig, allowQueryToken bool, req *http.Request) (*httptest.ResponseRecorder, auth.Principal) {
+	t.Helper()
+	var got auth.Principal
+	e := echo.New()
+	e.GET("/", func(c echo.Context) error {
+		got = principal(c)
+		return c.NoContent(http.StatusNoContent)
+	}, Authenticate(cfg, allowQueryToken))
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec, got
+}
+
+func TestAuthenticate_JWT(t *testing.T) {
+	v, err := auth.NewJWTVerifier(auth.JWTConfig{Issuer: "iss", Audience: "aud", HS256Secret: []byte("k")})
+	if err != nil {
+		t.Fatalf("NewJWTVerifier: %v", err)
+	}
+	cfg := AuthConfig{JWT: v}
+
+	// Headers alone are not enough in JWT mode.
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Tenant-Id", "t")
+	req.Header.Set("X-User-Id", "u")
+	if rec, _ := serve(t, cfg, false, req); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", rec.Code)
+	}
+
+	token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"iss": "iss", "aud": "aud", "sub": "u", "tenant_id": "t",
+		"exp": time.Now().Add(time.Minute).Unix(),
+	}).SignedString([]byte("k"))
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	rec, p := serve(t, cfg, false, req)
+	if rec.Code != http.StatusNoContent || p.TenantID != "t" || p.UserID != "u" {
+		t.Fatalf("expected principal t/u, got %d %+v", rec.Code, p)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/?access_token="+token, nil)
+	if rec, _ := serve(t, cfg, false, req); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected query token to be ignored, got %d", rec.Code)
+	}
+	if rec, _ := serve(t, cfg, true, req); rec.Code != http.StatusNoContent {
+		t.Fatalf("expected query token to be accepted on stream routes, got %d", rec.Code)
+	}
+}
+
+func TestAuthenticate_DevHeaders(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Tenant-Id", "t")
+	req.Header.Set("X-User-Id", "u")
+	req.Header.Set("X-Roles", "admin,ingest")
+
+	rec, p := serve(t, AuthConfig{DevHeaders: true}, false, req)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", rec.Code)
+	}
+	if p.TenantID != "t" || p.UserID != "u" || !p.HasRole("ingest") {
+		t.Fatalf("unexpected principal %+v", p)
+	}
+
+	rec, _ = serve(t, AuthConfig{DevHeaders: true}, false, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without headers, got %d", rec.Code)
+	}
+}
+
+func TestRequireRole(t *testing.T) {
+	e := echo.New()
+	e.GET("/", func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	}, Authenticate(AuthConfig{DevHeaders: true}, false), RequireRole("admin"))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Tenant-Id", "t")
+	req.Header.Set("X-User-Id", "u")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 without the role, got %d", rec.Code)
+	}
+
+	req.Header.Set("X-Roles", "ingest,admin")
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204 with the role, got %d", rec.Code)
+	}
+}
+
+type fakeKeys map[string]ports.APIKey
+
+func (f fakeKeys) Authenticate(ctx context.Context, key string) (ports.APIKey, error) {
+	k, ok := f[key]
+	if !ok {
+		return ports.APIKey{}, apikeys.ErrInvalidKey
+	}
+	return k, nil
+}
+
+func TestAuthenticateIngest(t *testing.T) {
+	cfg := AuthConfig{DevHeaders: true, APIKeys: fakeKeys{
+		"ink_good": {ID: "k1", TenantIDs: []string{"t1", "t2"}, EventTypes: []string{"TaskAssignedToUser"}},
+	}}
+	var scope ingest.Scope
+	var p auth.Principal
+	e := echo.New()
+	e.POST("/", func(c echo.Context) error {
+		scope, _ = ingest.ScopeFrom(c.Request().Context())
+		p = principal(c)
+		return c.NoContent(http.StatusNoContent)
+	}, AuthenticateIngest(cfg, "ingest"))
+	do := func(headers map[string]string) int {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// A key is scoped to its tenants and event types.
+	if code := do(map[string]string{HeaderAPIKey: "ink_good"}); code != http.StatusNoContent {
+		t.Fatalf("expected 204 with a valid key, got %d", code)
+	}
+	if p.Subject != "apikey:k1" || !slices.Equal(scope.TenantIDs, []string{"t1", "t2"}) || !slices.Equal(scope.EventTypes, []string{"TaskAssignedToUser"}) {
+		t.Fatalf("unexpected principal %+v / scope %+v", p, scope)
+	}
+	if code := do(map[string]string{HeaderAPIKey: "ink_bad"}); code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 with an unknown key, got %d", code)
+	}
+
+	// A token needs the ingest role and is scoped to its own tenant.
+	user := map[string]string{"X-Tenant-Id": "t1", "X-User-Id": "svc"}
+	if code := do(user); code != http.StatusForbidden {
+		t.Fatalf("expected 403 without the ingest role, got %d", code)
+	}
+	user["X-Roles"] = "ingest"
+	if code := do(user); code != http.StatusNoContent || !slices.Equal(scope.TenantIDs, []string{"t1"}) || len(scope.EventTypes) != 0 {
+		t.Fatalf("expected 204 scoped to t1, got %d %+v", code, scope)
+	}
+
+	// Without an authenticator keys are refused outright.
+	cfg.APIKeys = nil
+	e = echo.New()
+	e.POST("/", func(c echo.Context) error { return c.NoContent(http.StatusNoContent) }, AuthenticateIngest(cfg, "ingest"))
+	if code := do(map[string]string{HeaderAPIKey: "ink_good"}); code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 when keys are disabled, got %d", code)
+	}
+}
+
+func TestRegisterRoutes_OutboxNeedsOperatorRole(t *testing.T) {
+	e := echo.New()
+	RegisterRoutes(e, &Handlers{}, AuthConfig{DevHeaders: true})
+
+	// A tenant admin can't reach the outbox, which spans all tenants.
+	req := httptest.NewRequest(http.MethodPost, "/v1/admin/outbox:purge", strings.NewReader(`{}`))
+	req.Header.Set("X-Tenant-Id", "t")
+	req.Header.Set("X-User-Id", "u")
+	req.Header.Set("X-Roles", "admin")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for a tenant admin, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,104 @@
+package http
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+
+	"inbox-service/internal/application/ingest"
+
+	"github.com/labstack/echo/v4"
+)
+
+// IngestCloudEvents takes CloudEvents 1.0 in structured
+// (application/cloudevents+json), batched (application/cloudevents-batch+json)
+// or binary mode (ce-* headers, the body is the data). Events are routed by
+// their type to the registered event type.
+func (h *Handlers) IngestCloudEvents(c echo.Context) error {
+	h.limitBody(c)
+	req := c.Request()
+	body, err := io.ReadAll(req.Body)
+	if bodyTooLarge(err) {
+		return c.JSON(http.StatusRequestEntityTooLarge, map[string]any{"error": "body too large"})
+	}
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]any{"error": "cannot read body"})
+	}
+	contentType := req.Header.Get(echo.HeaderContentType)
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	switch {
+	case mediaType == ingest.CloudEventsBatchContentType:
+		raw, err := ingest.DecodeCloudEventBatch(body)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]any{"error": err.Error()})
+		}
+		if err := h.Ingest.CheckBatchSize(len(raw)); err != nil {
+			return batchError(c, err)
+		}
+		// Events that aren't valid CloudEvents are reported at their index;
+		// the rest go through the batch pipeline together.
+		results := make([]ingest.BatchResult, len(raw))
+		var events []ingest.BatchEvent
+		var at []int
+		for i, r := range raw {
+			ce, err := ingest.DecodeCloudEvent(r)
+			if err != nil {
+				results[i] = ingest.BatchResult{Status: ingest.BatchInvalid, Err: err}
+				continue
+			}
+			events = append(events, ingest.BatchEvent{Envelope: ce.Envelope, Meta: ce.Meta})
+			at = append(at, i)
+		}
+		done, err := h.Ingest.IngestBatch(req.Context(), events)
+		if err != nil {
+			return batchError(c, err)
+		}
+		for j, r := range done {
+			results[at[j]] = r
+		}
+		return c.JSON(http.StatusOK, batchResultsJSON(results))
+
+	case mediaType == ingest.CloudEventsContentType:
+		ce, err := ingest.DecodeCloudEvent(body)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]any{"error": err.Error()})
+		}
+		return h.ingestCloudEvent(c, ce)
+
+	case ingest.IsBinaryCloudEvent(req.Header.Get):
+		ce, err := ingest.DecodeBinaryCloudEvent(req.Header.Get, contentType, body)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]any{"error": err.Error()})
+		}
+		return h.ingestCloudEvent(c, ce)
+
+	default:
+		return c.JSON(http.StatusUnsupportedMediaType, map[string]any{
+			"error": "expected " + ingest.CloudEventsContentType + ", " + ingest.CloudEventsBatchContentType + " or ce-* headers",
+		})
+	}
+}
+
+func (h *Handlers) ingestCloudEvent(c echo.Context, ce ingest.CloudEvent) error {
+	res, err := h.Ingest.Ingest(ingest.CloudEventContext(c.Request().Context(), ce), ce.Envelope)
+	if err != nil {
+		return ingestError(c, err)
+	}
+	return c.JSON(http.StatusOK, ingestResultJSON(ce.Envelope.ID, res))
+}
+
+// ingestError maps a single event's failure: 403 outside the caller's scope,
+// 400 for events that can never succeed (see ingest.IsPermanent), which a
+// batch reports as invalid.
+func ingestError(c echo.Context, err error) error {
+	code := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ingest.ErrOutOfScope):
+		code = http.StatusForbidden
+	case ingest.IsPermanent(err):
+		code = http.StatusBadRequest
+	}
+	return c.JSON(code, map[string]any{"error": err.Error()})
+}
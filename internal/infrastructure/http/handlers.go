@@ -1,31 +1,49 @@
 package http
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"inbox-service/internal/application/admin"
+	"inbox-service/internal/application/apikeys"
+	"inbox-service/internal/application/commands"
 	"inbox-service/internal/application/ports"
 	"inbox-service/internal/application/queries"
 	"inbox-service/internal/application/ingest"
+	"inbox-service/internal/application/stream"
+	"inbox-service/internal/application/webhooks"
 
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 )
 
 type Handlers struct {
 	Feed *queries.FeedHandler
 	Ingest *ingest.Handler
+	Status *commands.StatusHandler
+	BulkRead *commands.MarkAllReadHandler
+	Snooze *commands.SnoozeHandler
+	Unread *queries.UnreadCountHandler
+	Stream *stream.Service
+	OutboxAdmin *admin.OutboxAdmin
+	Webhooks *webhooks.Subscriptions
+	APIKeys *apikeys.Keys
+	// MaxBodyBytes caps the body of the ingest requests that read it whole;
+	// 0 means defaultMaxBodyBytes.
+	MaxBodyBytes int64
 }
 
-func NewHandlers(feed *queries.FeedHandler, ingest *ingest.Handler) *Handlers {
-	return &Handlers{Feed: feed, Ingest: ingest}
+func NewHandlers(feed *queries.FeedHandler, ingest *ingest.Handler, status *commands.StatusHandler, markAllRead *commands.MarkAllReadHandler, snooze *commands.SnoozeHandler, unread *queries.UnreadCountHandler, stream *stream.Service, outboxAdmin *admin.OutboxAdmin, webhookSubs *webhooks.Subscriptions, apiKeys *apikeys.Keys) *Handlers {
+	return &Handlers{Feed: feed, Ingest: ingest, Status: status, BulkRead: markAllRead, Snooze: snooze, Unread: unread, Stream: stream, OutboxAdmin: outboxAdmin, Webhooks: webhookSubs, APIKeys: apiKeys}
 }
 
-// For now: tenant_id and user_id come from headers to keep it simple.
-// Later: pull from JWT claims.
+// GetFeed serves the caller's feed; tenant and user come from the principal.
 func (h *Handlers) GetFeed(c echo.Context) error {
-	tenantID := c.Request().Header.Get("X-Tenant-Id")
-	userID := c.Request().Header.Get("X-User-Id")
+	p := principal(c)
 
 	status := c.QueryParam("status")
 	limitStr := c.QueryParam("limit")
@@ -37,8 +55,12 @@ func (h *Handlers) GetFeed(c echo.Context) error {
 		}
 	}
 
-	// Cursor as query params: cursor_created_at + cursor_id
-	var cursor *ports.FeedCursor
+	if c.QueryParam("page_token") != "" && c.QueryParam("cursor_created_at") != "" {
+		return c.JSON(http.StatusBadRequest, map[string]any{"error": "use either page_token or cursor_created_at/cursor_id"})
+	}
+
+	// Deprecated: raw cursor_created_at + cursor_id, superseded by page_token.
+	var legacy *ports.FeedCursor
 	cc := c.QueryParam("cursor_created_at")
 	ci := c.QueryParam("cursor_id")
 	if cc != "" && ci != "" {
@@ -46,30 +68,51 @@ func (h *Handlers) GetFeed(c echo.Context) error {
 		if err != nil {
 			return c.JSON(http.StatusBadRequest, map[string]any{"error": "invalid cursor_created_at; must be RFC3339Nano"})
 		}
-		cursor = &ports.FeedCursor{CreatedAt: t, ID: ci}
+		legacy = &ports.FeedCursor{SortAt: t, ID: ci}
+		c.Response().Header().Set("Deprecation", "true")
 	}
 
 	page, err := h.Feed.Handle(c.Request().Context(), queries.FeedQuery{
-		TenantID: tenantID,
-		UserID:   userID,
-		Status:   status,
-		Limit:    limit,
-		Cursor:   cursor,
+		TenantID:    p.TenantID,
+		UserID:      p.UserID,
+		Status:      status,
+		Type:        c.QueryParam("type"),
+		Limit:       limit,
+		CursorToken: c.QueryParam("page_token"),
+		Cursor:      legacy,
 	})
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]any{"error": err.Error()})
 	}
 
 	resp := map[string]any{"items": page.Items}
-	if page.NextCursor != nil {
+	if page.NextCursor != "" {
+		resp["next_page_token"] = page.NextCursor
+		// Deprecated: the raw position, kept in its old shape for one release
+		// so existing clients can keep paging.
 		resp["next_cursor"] = map[string]any{
-			"created_at": page.NextCursor.CreatedAt.Format(time.RFC3339Nano),
-			"id":         page.NextCursor.ID,
+			"created_at": page.NextPosition.SortAt.Format(time.RFC3339Nano),
+			"id":         page.NextPosition.ID,
 		}
 	}
 	return c.JSON(http.StatusOK, resp)
 }
 
+// GetUnreadCount returns the unread badge; by_type=true adds a per-type breakdown.
+func (h *Handlers) GetUnreadCount(c echo.Context) error {
+	p := principal(c)
+
+	cnt, err := h.Unread.Handle(c.Request().Context(), p.TenantID, p.UserID)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]any{"error": err.Error()})
+	}
+
+	resp := map[string]any{"unread": cnt.Total}
+	if byType, _ := strconv.ParseBool(c.QueryParam("by_type")); byType {
+		resp["by_type"] = cnt.ByType
+	}
+	return c.JSON(http.StatusOK, resp)
+}
 
 func (h *Handlers) DevIngestTaskAssigned(c echo.Context) error {
 	var evt ingest.TaskAssignedToUser
@@ -84,4 +127,214 @@ func (h *Handlers) DevIngestTaskAssigned(c echo.Context) error {
 		"status":        "ok",
 		"inbox_item_id": id,
 	})
-}
\ No newline at end of file
+}
+
+// DevIngest runs any registered event type, e.g.
+// {"id":"...","type":"TaskAssignedToUser","tenant_id":"...","payload":{...}}.
+func (h *Handlers) DevIngest(c echo.Context) error {
+	var env ingest.Envelope
+	if err := c.Bind(&env); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]any{"error": "invalid json"})
+	}
+	res, err := h.Ingest.Ingest(c.Request().Context(), env)
+	if err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, ingest.ErrInvalidEvent) {
+			code = http.StatusBadRequest
+		}
+		return c.JSON(code, map[string]any{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, map[string]any{
+		"status":         "ok",
+		"duplicate":      res.Duplicate,
+		"inbox_item_ids": append([]string{}, res.ItemIDs...),
+	})
+}
+
+// PatchItem changes an item's status. Send the version from the feed (or a
+// previous ETag) in If-Match to guard against lost updates.
+func (h *Handlers) PatchItem(c echo.Context) error {
+	p := principal(c)
+	id, err := itemID(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]any{"error": err.Error()})
+	}
+
+	var body struct {
+		Status string `json:"status"`
+	}
+	if err := c.Bind(&body); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]any{"error": "invalid json"})
+	}
+
+	ifVersion, err := parseIfMatch(c.Request().Header.Get("If-Match"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]any{"error": err.Error()})
+	}
+
+	it, err := h.Status.ChangeStatus(c.Request().Context(), commands.ChangeStatusCommand{
+		TenantID:  p.TenantID,
+		UserID:    p.UserID,
+		ItemID:    id,
+		Status:    strings.ToUpper(body.Status),
+		IfVersion: ifVersion,
+	})
+	if err != nil {
+		return commandError(c, err)
+	}
+	return itemStateJSON(c, it)
+}
+
+// MarkAllRead marks every UNREAD item matching the filter as READ. Items created
+// after created_before (default: now) are left alone, so a click is race-safe
+// against items that arrive while it runs.
+func (h *Handlers) MarkAllRead(c echo.Context) error {
+	p := principal(c)
+
+	var body struct {
+		Status          string `json:"status"`
+		Type            string `json:"type"`
+		CreatedBefore   string `json:"created_before"`
+		CreatedBeforeID string `json:"created_before_id"`
+	}
+	if err := c.Bind(&body); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]any{"error": "invalid json"})
+	}
+
+	var before *ports.FeedCursor
+	if body.CreatedBefore != "" {
+		t, err := time.Parse(time.RFC3339Nano, body.CreatedBefore)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]any{"error": "invalid created_before; must be RFC3339Nano"})
+		}
+		before = &ports.FeedCursor{SortAt: t, ID: body.CreatedBeforeID}
+	}
+
+	res, err := h.BulkRead.Handle(c.Request().Context(), commands.MarkAllReadCommand{
+		TenantID: p.TenantID,
+		UserID:   p.UserID,
+		Status:   strings.ToUpper(body.Status),
+		Type:     body.Type,
+		Before:   before,
+	})
+	if err != nil {
+		return commandError(c, err)
+	}
+	return c.JSON(http.StatusOK, map[string]any{
+		"updated":        res.Updated,
+		"created_before": res.Before.SortAt.Format(time.RFC3339Nano),
+	})
+}
+
+// SnoozeItem hides an item until `until` (RFC3339) or a preset such as
+// "tomorrow", resolved in `timezone` (IANA name, default UTC).
+func (h *Handlers) SnoozeItem(c echo.Context) error {
+	p := principal(c)
+	id, err := itemID(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]any{"error": err.Error()})
+	}
+
+	var body struct {
+		Until    *time.Time `json:"until"`
+		Preset   string     `json:"preset"`
+		TimeZone string     `json:"timezone"`
+	}
+	if err := c.Bind(&body); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]any{"error": "invalid json"})
+	}
+
+	ifVersion, err := parseIfMatch(c.Request().Header.Get("If-Match"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]any{"error": err.Error()})
+	}
+
+	it, err := h.Snooze.Snooze(c.Request().Context(), commands.SnoozeCommand{
+		TenantID:  p.TenantID,
+		UserID:    p.UserID,
+		ItemID:    id,
+		Until:     body.Until,
+		Preset:    body.Preset,
+		TimeZone:  body.TimeZone,
+		IfVersion: ifVersion,
+	})
+	if err != nil {
+		return commandError(c, err)
+	}
+	return itemStateJSON(c, it)
+}
+
+func (h *Handlers) UnsnoozeItem(c echo.Context) error {
+	p := principal(c)
+	id, err := itemID(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]any{"error": err.Error()})
+	}
+
+	ifVersion, err := parseIfMatch(c.Request().Header.Get("If-Match"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]any{"error": err.Error()})
+	}
+
+	it, err := h.Snooze.Unsnooze(c.Request().Context(), p.TenantID, p.UserID, id, ifVersion)
+	if err != nil {
+		return commandError(c, err)
+	}
+	return itemStateJSON(c, it)
+}
+
+// itemID returns the :id path parameter, which must be a UUID.
+func itemID(c echo.Context) (string, error) {
+	id := c.Param("id")
+	if _, err := uuid.Parse(id); err != nil {
+		return "", fmt.Errorf("invalid item id %q", id)
+	}
+	return id, nil
+}
+
+func itemStateJSON(c echo.Context, it ports.InboxItemState) error {
+	resp := map[string]any{
+		"id":         it.ID,
+		"status":     it.Status,
+		"version":    it.Version,
+		"updated_at": it.UpdatedAt.Format(time.RFC3339Nano),
+	}
+	if it.SnoozeUntil != nil {
+		resp["snooze_until"] = it.SnoozeUntil.Format(time.RFC3339Nano)
+	}
+	c.Response().Header().Set("ETag", etag(it.Version))
+	return c.JSON(http.StatusOK, resp)
+}
+
+func etag(version int) string {
+	return fmt.Sprintf("%q", strconv.Itoa(version))
+}
+
+// parseIfMatch accepts `"3"`, `W/"3"` or a bare `3`. Empty means no precondition.
+func parseIfMatch(v string) (*int, error) {
+	v = strings.TrimSpace(v)
+	if v == "" || v == "*" {
+		return nil, nil
+	}
+	v = strings.Trim(strings.TrimPrefix(v, "W/"), `"`)
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid If-Match; expected an item version")
+	}
+	return &n, nil
+}
+
+func commandError(c echo.Context, err error) error {
+	code := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, commands.ErrInvalidCommand):
+		code = http.StatusBadRequest
+	case errors.Is(err, ports.ErrNotFound):
+		code = http.StatusNotFound
+	case errors.Is(err, ports.ErrVersionConflict):
+		code = http.StatusConflict
+	case errors.Is(err, commands.ErrPreconditionFailed):
+		code = http.StatusPreconditionFailed
+	}
+	return c.JSON(code, map[string]any{"error": err.Error()})
+}
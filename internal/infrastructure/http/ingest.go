@@ -0,0 +1,115 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"inbox-service/internal/application/ingest"
+
+	"github.com/labstack/echo/v4"
+)
+
+// IngestEvent ingests one envelope,
+// {"id":"...","type":"TaskAssignedToUser","tenant_id":"...","payload":{...}}.
+func (h *Handlers) IngestEvent(c echo.Context) error {
+	var env ingest.Envelope
+	h.limitBody(c)
+	if err := c.Bind(&env); bodyTooLarge(err) {
+		return c.JSON(http.StatusRequestEntityTooLarge, map[string]any{"error": "body too large"})
+	} else if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]any{"error": "invalid json"})
+	}
+	res, err := h.Ingest.Ingest(c.Request().Context(), env)
+	if err != nil {
+		return ingestError(c, err)
+	}
+	return c.JSON(http.StatusOK, ingestResultJSON(env.ID, res))
+}
+
+// IngestBatch takes {"events":[<envelope>, ...]} and answers with one result
+// per event, in order: created, duplicate, invalid (with the error) or
+// failed. Only failed events are worth sending again; every event stays
+// idempotent on its id.
+func (h *Handlers) IngestBatch(c echo.Context) error {
+	var body struct {
+		Events []ingest.Envelope `json:"events"`
+	}
+	h.limitBody(c)
+	if err := c.Bind(&body); bodyTooLarge(err) {
+		return c.JSON(http.StatusRequestEntityTooLarge, map[string]any{"error": "body too large"})
+	} else if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]any{"error": "invalid json"})
+	}
+	if len(body.Events) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]any{"error": "events required"})
+	}
+	events := make([]ingest.BatchEvent, len(body.Events))
+	for i, env := range body.Events {
+		events[i] = ingest.BatchEvent{Envelope: env}
+	}
+	results, err := h.Ingest.IngestBatch(c.Request().Context(), events)
+	if err != nil {
+		return batchError(c, err)
+	}
+	return c.JSON(http.StatusOK, batchResultsJSON(results))
+}
+
+func batchResultsJSON(results []ingest.BatchResult) map[string]any {
+	out := make([]map[string]any, len(results))
+	counts := map[string]int{}
+	for i, r := range results {
+		entry := map[string]any{"index": i, "status": r.Status}
+		if r.ID != "" {
+			entry["id"] = r.ID
+		}
+		if r.Status == ingest.BatchCreated {
+			entry["inbox_item_ids"] = append([]string{}, r.ItemIDs...)
+		}
+		if r.Err != nil {
+			entry["error"] = r.Err.Error()
+		}
+		out[i] = entry
+		counts[r.Status]++
+	}
+	return map[string]any{"results": out, "counts": counts}
+}
+
+func ingestResultJSON(id string, res ingest.Result) map[string]any {
+	status := ingest.BatchCreated
+	if res.Duplicate {
+		status = ingest.BatchDuplicate
+	}
+	return map[string]any{
+		"id":             id,
+		"status":         status,
+		"inbox_item_ids": append([]string{}, res.ItemIDs...),
+	}
+}
+
+// defaultMaxBodyBytes is the ingest body limit when Handlers.MaxBodyBytes is
+// unset; a full batch of typical events is well under it.
+const defaultMaxBodyBytes = 10 << 20
+
+// limitBody makes reads past the body limit fail (see bodyTooLarge) instead
+// of buffering whatever the client sends.
+func (h *Handlers) limitBody(c echo.Context) {
+	n := h.MaxBodyBytes
+	if n <= 0 {
+		n = defaultMaxBodyBytes
+	}
+	req := c.Request()
+	req.Body = http.MaxBytesReader(c.Response(), req.Body, n)
+}
+
+func bodyTooLarge(err error) bool {
+	var tooLarge *http.MaxBytesError
+	return errors.As(err, &tooLarge)
+}
+
+func batchError(c echo.Context, err error) error {
+	code := http.StatusInternalServerError
+	if errors.Is(err, ingest.ErrBatchTooLarge) {
+		code = http.StatusRequestEntityTooLarge
+	}
+	return c.JSON(code, map[string]any{"error": err.Error()})
+}
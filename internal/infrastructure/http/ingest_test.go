@@ -0,0 +1,89 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"inbox-service/internal/application/ingest"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestIngestCloudEvents_RejectsOversizedBody(t *testing.T) {
+	h := &Handlers{MaxBodyBytes: 64}
+	e := echo.New()
+	e.POST("/v1/events", h.IngestCloudEvents)
+
+	body := `{"specversion":"1.0","id":"e-1","type":"TaskAssignedToUser","source":"test","data":"` + strings.Repeat("x", 128) + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/events", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, "application/cloudevents+json")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413, got %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestIngestBatch_RejectsOversizedBody(t *testing.T) {
+	h := &Handlers{MaxBodyBytes: 64}
+	e := echo.New()
+	e.POST("/v1/ingest:batch", h.IngestBatch)
+
+	body := `{"events":[{"id":"e-1","type":"TaskAssignedToUser","payload":{"task_title":"` + strings.Repeat("x", 128) + `"}}]}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/ingest:batch", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413, got %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestIngestEvent_RejectsOversizedBody(t *testing.T) {
+	h := &Handlers{MaxBodyBytes: 64}
+	e := echo.New()
+	e.POST("/v1/ingest", h.IngestEvent)
+
+	body := `{"id":"e-1","type":"TaskAssignedToUser","payload":{"task_title":"` + strings.Repeat("x", 128) + `"}}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/ingest", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413, got %d: %s", rec.Code, rec.Body)
+	}
+}
+
+type sqlStateError string
+
+func (e sqlStateError) Error() string    { return "pg error " + string(e) }
+func (e sqlStateError) SQLState() string { return string(e) }
+
+func TestIngestError_Codes(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want int
+	}{
+		{fmt.Errorf("%w: tenant", ingest.ErrOutOfScope), http.StatusForbidden},
+		{fmt.Errorf("%w: missing id", ingest.ErrInvalidEvent), http.StatusBadRequest},
+		// A malformed UUID rejected by the database, reported as invalid in a batch too.
+		{fmt.Errorf("insert item: %w", sqlStateError("22P02")), http.StatusBadRequest},
+		{errors.New("connection reset"), http.StatusInternalServerError},
+	} {
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(httptest.NewRequest(http.MethodPost, "/v1/ingest", nil), rec)
+		if err := ingestError(c, tc.err); err != nil {
+			t.Fatalf("ingestError: %v", err)
+		}
+		if rec.Code != tc.want {
+			t.Errorf("%v: expected %d, got %d", tc.err, tc.want, rec.Code)
+		}
+	}
+}
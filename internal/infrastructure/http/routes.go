@@ -4,10 +4,71 @@ import (
 	"github.com/labstack/echo/v4"
 )
 
-func RegisterRoutes(e *echo.Echo, h *Handlers) {
-	v1 := e.Group("/v1")
-	v1.GET("/inbox/feed", h.GetFeed)
+func RegisterRoutes(e *echo.Echo, h *Handlers, authCfg AuthConfig) {
+	v1 := e.Group("/v1", TraceContext())
 
-	// dev-only for now
-	v1.POST("/dev/ingest/task-assigned", h.DevIngestTaskAssigned)
+	inbox := v1.Group("/inbox", Authenticate(authCfg, false))
+	inbox.GET("/feed", h.GetFeed)
+	inbox.GET("/unread-count", h.GetUnreadCount)
+	inbox.PATCH("/items/:id", h.PatchItem)
+	inbox.POST("/items\\:markAllRead", h.MarkAllRead)
+	inbox.POST("/items/:id/snooze", h.SnoozeItem)
+	inbox.DELETE("/items/:id/snooze", h.UnsnoozeItem)
+
+	// EventSource cannot send headers, so the stream also takes ?access_token=.
+	v1.GET("/inbox/stream", h.StreamInbox, Authenticate(authCfg, true))
+
+	adminRole := authCfg.AdminRole
+	if adminRole == "" {
+		adminRole = "admin"
+	}
+	operatorRole := authCfg.OperatorRole
+	if operatorRole == "" {
+		operatorRole = "operator"
+	}
+	admin := v1.Group("/admin", Authenticate(authCfg, false))
+	// The outbox holds every tenant's events, so it is for platform
+	// operators, not tenant admins.
+	operator := RequireRole(operatorRole)
+	admin.GET("/outbox", h.ListOutbox, operator)
+	admin.GET("/outbox/blocked-keys", h.ListBlockedOutboxKeys, operator)
+	admin.GET("/outbox/:id", h.GetOutbox, operator)
+	admin.POST("/outbox\\:requeue", h.RequeueOutbox, operator)
+	admin.POST("/outbox\\:skip", h.SkipOutbox, operator)
+	admin.POST("/outbox\\:purge", h.PurgeOutbox, operator)
+	tenantAdmin := RequireRole(adminRole)
+	admin.GET("/api-keys", h.ListAPIKeys, tenantAdmin)
+	admin.POST("/api-keys", h.CreateAPIKey, tenantAdmin)
+	admin.DELETE("/api-keys/:id", h.RevokeAPIKey, tenantAdmin)
+
+	webhookRole := authCfg.WebhookRole
+	if webhookRole == "" {
+		webhookRole = "webhook-admin"
+	}
+	hooks := v1.Group("/webhooks", Authenticate(authCfg, false), RequireRole(webhookRole))
+	hooks.GET("/subscriptions", h.ListWebhookSubscriptions)
+	hooks.POST("/subscriptions", h.CreateWebhookSubscription)
+	hooks.GET("/subscriptions/:id", h.GetWebhookSubscription)
+	hooks.PATCH("/subscriptions/:id", h.PatchWebhookSubscription)
+	hooks.DELETE("/subscriptions/:id", h.DeleteWebhookSubscription)
+	hooks.POST("/subscriptions/:id/rotate-secret", h.RotateWebhookSecret)
+	hooks.GET("/subscriptions/:id/deliveries", h.ListWebhookDeliveries)
+	hooks.GET("/deliveries/:id", h.GetWebhookDelivery)
+	hooks.POST("/deliveries/:id/replay", h.ReplayWebhookDelivery)
+
+	ingestRole := authCfg.IngestRole
+	if ingestRole == "" {
+		ingestRole = "ingest"
+	}
+	v1.POST("/ingest", h.IngestEvent, AuthenticateIngest(authCfg, ingestRole))
+	v1.POST("/events", h.IngestCloudEvents, AuthenticateIngest(authCfg, ingestRole))
+	v1.POST("/ingest\\:batch", h.IngestBatch, AuthenticateIngest(authCfg, ingestRole))
+}
+
+// RegisterDevRoutes adds the unauthenticated ingest shortcuts. Only call it in
+// local development.
+func RegisterDevRoutes(e *echo.Echo, h *Handlers) {
+	dev := e.Group("/v1/dev", TraceContext())
+	dev.POST("/ingest", h.DevIngest)
+	dev.POST("/ingest/task-assigned", h.DevIngestTaskAssigned)
 }
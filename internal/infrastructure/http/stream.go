@@ -0,0 +1,76 @@
+package http
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"inbox-service/internal/application/stream"
+
+	"github.com/labstack/echo/v4"
+)
+
+// StreamInbox is a Server-Sent Events stream of the user's inbox changes.
+// Reconnecting clients send Last-Event-ID (browsers do this automatically) or
+// ?last_event_id= to resume without gaps.
+func (h *Handlers) StreamInbox(c echo.Context) error {
+	p := principal(c)
+	if p.TenantID == "" || p.UserID == "" {
+		return c.JSON(http.StatusBadRequest, map[string]any{"error": "tenant_id and user_id are required"})
+	}
+
+	var lastEventID *int64
+	raw := c.Request().Header.Get("Last-Event-ID")
+	if raw == "" {
+		raw = c.QueryParam("last_event_id")
+	}
+	if raw != "" {
+		v, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || v < 0 {
+			return c.JSON(http.StatusBadRequest, map[string]any{"error": "invalid Last-Event-ID"})
+		}
+		lastEventID = &v
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	res.Header().Set("X-Accel-Buffering", "no") // nginx
+	res.WriteHeader(http.StatusOK)
+	res.Flush()
+
+	err := h.Stream.Run(c.Request().Context(), p.TenantID, p.UserID, lastEventID, func(f stream.Frame) error {
+		if _, err := res.Write(formatFrame(f)); err != nil {
+			return err
+		}
+		res.Flush()
+		return nil
+	})
+	if err != nil && c.Request().Context().Err() == nil {
+		// Headers are already out; all we can do is end the stream and let
+		// the client reconnect.
+		c.Logger().Errorf("inbox stream: %v", err)
+	}
+	return nil
+}
+
+func formatFrame(f stream.Frame) []byte {
+	var b bytes.Buffer
+	if f.Comment != "" {
+		fmt.Fprintf(&b, ": %s\n\n", f.Comment)
+		return b.Bytes()
+	}
+	if f.ID != "" {
+		fmt.Fprintf(&b, "id: %s\n", f.ID)
+	}
+	if f.Event != "" {
+		fmt.Fprintf(&b, "event: %s\n", f.Event)
+	}
+	for _, line := range bytes.Split(f.Data, []byte("\n")) {
+		fmt.Fprintf(&b, "data: %s\n", line)
+	}
+	b.WriteString("\n")
+	return b.Bytes()
+}
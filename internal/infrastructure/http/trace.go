@@ -0,0 +1,38 @@
+package http
+
+import (
+	"inbox-service/internal/application/ports"
+
+	"github.com/labstack/echo/v4"
+)
+
+// HTTP names of the tracing headers; traceparent is already an HTTP header.
+const (
+	headerCorrelationID = "X-Correlation-Id"
+	headerCausationID   = "X-Causation-Id"
+)
+
+// TraceContext puts the request's correlation id, causation id and
+// traceparent in the request context as a ports.MessageMeta, so outbox events
+// written while handling it carry them. The correlation id is echoed back.
+func TraceContext() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			meta := ports.MessageMetaFromHeaders(func(name string) string {
+				switch name {
+				case ports.HeaderCorrelationID:
+					return req.Header.Get(headerCorrelationID)
+				case ports.HeaderCausationID:
+					return req.Header.Get(headerCausationID)
+				}
+				return req.Header.Get(name)
+			})
+			if meta.CorrelationID != "" {
+				c.Response().Header().Set(headerCorrelationID, meta.CorrelationID)
+			}
+			c.SetRequest(req.WithContext(ports.WithMessageMeta(req.Context(), meta)))
+			return next(c)
+		}
+	}
+}
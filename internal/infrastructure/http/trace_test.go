@@ -0,0 +1,43 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"inbox-service/internal/application/ports"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestTraceContext(t *testing.T) {
+	var got ports.MessageMeta
+	e := echo.New()
+	e.GET("/", func(c echo.Context) error {
+		got = ports.MessageMetaFrom(c.Request().Context())
+		return c.NoContent(http.StatusNoContent)
+	}, TraceContext())
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Correlation-Id", "corr-1")
+	req.Header.Set("X-Causation-Id", "evt-0")
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	want := ports.MessageMeta{CorrelationID: "corr-1", CausationID: "evt-0", Traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}
+	if got != want {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+	if rec.Header().Get("X-Correlation-Id") != "corr-1" {
+		t.Fatalf("expected the correlation id echoed back, got %q", rec.Header().Get("X-Correlation-Id"))
+	}
+
+	// A malformed traceparent is dropped rather than passed on.
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("traceparent", "00-00000000000000000000000000000000-00f067aa0ba902b7-01")
+	e.ServeHTTP(httptest.NewRecorder(), req)
+	if got != (ports.MessageMeta{}) {
+		t.Fatalf("expected no meta, got %+v", got)
+	}
+}
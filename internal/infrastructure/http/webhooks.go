@@ -0,0 +1,199 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"inbox-service/internal/application/ports"
+	"inbox-service/internal/application/webhooks"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ListWebhookSubscriptions lists the caller's tenant's subscriptions. Secrets
+// are only returned on create and rotate.
+func (h *Handlers) ListWebhookSubscriptions(c echo.Context) error {
+	subs, err := h.Webhooks.List(c.Request().Context(), principal(c).TenantID)
+	if err != nil {
+		return webhookError(c, err)
+	}
+	out := make([]map[string]any, 0, len(subs))
+	for _, s := range subs {
+		out = append(out, subscriptionJSON(s, false))
+	}
+	return c.JSON(http.StatusOK, map[string]any{"subscriptions": out})
+}
+
+// CreateWebhookSubscription registers an endpoint, e.g.
+// {"url":"https://example.com/hooks","event_types":["InboxItemCreated"]}.
+func (h *Handlers) CreateWebhookSubscription(c echo.Context) error {
+	var body struct {
+		URL        string   `json:"url"`
+		EventTypes []string `json:"event_types"`
+		Enabled    *bool    `json:"enabled"`
+	}
+	if err := c.Bind(&body); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]any{"error": "invalid json"})
+	}
+	s, err := h.Webhooks.Create(c.Request().Context(), principal(c).TenantID, webhooks.SubscriptionInput{
+		URL:        body.URL,
+		EventTypes: body.EventTypes,
+		Enabled:    body.Enabled,
+	})
+	if err != nil {
+		return webhookError(c, err)
+	}
+	return c.JSON(http.StatusCreated, subscriptionJSON(s, true))
+}
+
+func (h *Handlers) GetWebhookSubscription(c echo.Context) error {
+	s, err := h.Webhooks.Get(c.Request().Context(), principal(c).TenantID, c.Param("id"))
+	if err != nil {
+		return webhookError(c, err)
+	}
+	return c.JSON(http.StatusOK, subscriptionJSON(s, false))
+}
+
+// PatchWebhookSubscription changes url, event_types and/or enabled.
+func (h *Handlers) PatchWebhookSubscription(c echo.Context) error {
+	var body struct {
+		URL        *string   `json:"url"`
+		EventTypes *[]string `json:"event_types"`
+		Enabled    *bool     `json:"enabled"`
+	}
+	if err := c.Bind(&body); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]any{"error": "invalid json"})
+	}
+	s, err := h.Webhooks.Update(c.Request().Context(), principal(c).TenantID, c.Param("id"), webhooks.SubscriptionPatch{
+		URL:        body.URL,
+		EventTypes: body.EventTypes,
+		Enabled:    body.Enabled,
+	})
+	if err != nil {
+		return webhookError(c, err)
+	}
+	return c.JSON(http.StatusOK, subscriptionJSON(s, false))
+}
+
+func (h *Handlers) DeleteWebhookSubscription(c echo.Context) error {
+	if err := h.Webhooks.Delete(c.Request().Context(), principal(c).TenantID, c.Param("id")); err != nil {
+		return webhookError(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// RotateWebhookSecret returns the new secret. The previous one keeps signing
+// alongside it until previous_secret_expires_at.
+func (h *Handlers) RotateWebhookSecret(c echo.Context) error {
+	s, err := h.Webhooks.RotateSecret(c.Request().Context(), principal(c).TenantID, c.Param("id"))
+	if err != nil {
+		return webhookError(c, err)
+	}
+	return c.JSON(http.StatusOK, subscriptionJSON(s, true))
+}
+
+// ListWebhookDeliveries lists a subscription's most recent deliveries.
+func (h *Handlers) ListWebhookDeliveries(c echo.Context) error {
+	limit := 0
+	if v := c.QueryParam("limit"); v != "" {
+		var err error
+		if limit, err = strconv.Atoi(v); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]any{"error": "invalid limit"})
+		}
+	}
+	ds, err := h.Webhooks.ListDeliveries(c.Request().Context(), principal(c).TenantID, c.Param("id"), limit)
+	if err != nil {
+		return webhookError(c, err)
+	}
+	out := make([]map[string]any, 0, len(ds))
+	for _, d := range ds {
+		out = append(out, deliveryJSON(d))
+	}
+	return c.JSON(http.StatusOK, map[string]any{"deliveries": out})
+}
+
+// GetWebhookDelivery returns a delivery with its attempts, oldest first.
+func (h *Handlers) GetWebhookDelivery(c echo.Context) error {
+	d, attempts, err := h.Webhooks.GetDelivery(c.Request().Context(), principal(c).TenantID, c.Param("id"))
+	if err != nil {
+		return webhookError(c, err)
+	}
+	resp := deliveryJSON(d)
+	list := make([]map[string]any, 0, len(attempts))
+	for _, a := range attempts {
+		entry := map[string]any{
+			"attempt":     a.Attempt,
+			"duration_ms": a.Duration.Milliseconds(),
+			"created_at":  a.CreatedAt.Format(time.RFC3339Nano),
+		}
+		if a.StatusCode != 0 {
+			entry["status_code"] = a.StatusCode
+		}
+		if a.Error != "" {
+			entry["error"] = a.Error
+		}
+		list = append(list, entry)
+	}
+	resp["history"] = list
+	return c.JSON(http.StatusOK, resp)
+}
+
+// ReplayWebhookDelivery queues a delivery to be sent again.
+func (h *Handlers) ReplayWebhookDelivery(c echo.Context) error {
+	if err := h.Webhooks.Replay(c.Request().Context(), principal(c).TenantID, c.Param("id")); err != nil {
+		return webhookError(c, err)
+	}
+	return c.JSON(http.StatusAccepted, map[string]any{"status": ports.DeliveryPending})
+}
+
+func subscriptionJSON(s ports.WebhookSubscription, withSecret bool) map[string]any {
+	resp := map[string]any{
+		"id":          s.ID,
+		"url":         s.URL,
+		"event_types": append([]string{}, s.EventTypes...),
+		"enabled":     s.Enabled,
+		"created_at":  s.CreatedAt.Format(time.RFC3339Nano),
+		"updated_at":  s.UpdatedAt.Format(time.RFC3339Nano),
+	}
+	if withSecret {
+		resp["secret"] = s.Secret
+	}
+	if s.PreviousSecretExpiresAt != nil {
+		resp["previous_secret_expires_at"] = s.PreviousSecretExpiresAt.Format(time.RFC3339Nano)
+	}
+	return resp
+}
+
+func deliveryJSON(d ports.WebhookDelivery) map[string]any {
+	resp := map[string]any{
+		"id":              d.ID,
+		"subscription_id": d.SubscriptionID,
+		"event_id":        d.OutboxID,
+		"event_type":      d.EventType,
+		"status":          d.Status,
+		"attempts":        d.Attempts,
+		"next_run_at":     d.NextRunAt.Format(time.RFC3339Nano),
+		"created_at":      d.CreatedAt.Format(time.RFC3339Nano),
+		"updated_at":      d.UpdatedAt.Format(time.RFC3339Nano),
+	}
+	if d.LastError != "" {
+		resp["last_error"] = d.LastError
+	}
+	if d.LastStatusCode != 0 {
+		resp["last_status_code"] = d.LastStatusCode
+	}
+	return resp
+}
+
+func webhookError(c echo.Context, err error) error {
+	code := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, webhooks.ErrInvalidRequest):
+		code = http.StatusBadRequest
+	case errors.Is(err, ports.ErrNotFound):
+		code = http.StatusNotFound
+	}
+	return c.JSON(code, map[string]any{"error": err.Error()})
+}
@@ -0,0 +1,199 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"inbox-service/internal/application/ingest"
+	"inbox-service/internal/application/outbox"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// Headers added to dead-lettered records.
+const (
+	HeaderDLQError     = "dlq-error"
+	HeaderDLQTopic     = "dlq-source-topic"
+	HeaderDLQPartition = "dlq-source-partition"
+	HeaderDLQOffset    = "dlq-source-offset"
+)
+
+// Ingester is the part of ingest.Handler the consumer needs.
+type Ingester interface {
+	Ingest(ctx context.Context, env ingest.Envelope) (ingest.Result, error)
+}
+
+type ConsumerConfig struct {
+	Brokers []string
+	Group   string
+	Topics  []string
+	// TopicTypes maps a topic to the event type of bare payloads on it.
+	TopicTypes map[string]string
+	// DLQTopic receives messages that can never be ingested. Empty means
+	// they are logged and skipped.
+	DLQTopic string
+	// Transient failures (e.g. the database is down) are retried in place,
+	// which holds up the partition to keep ordering.
+	RetryBackoff    time.Duration
+	MaxRetryBackoff time.Duration
+}
+
+// Consumer reads topics as a consumer group and feeds the ingest pipeline.
+// Partitions are processed concurrently but each one strictly in order, and
+// offsets are committed only after the ingest transaction has committed.
+// Delivery is at-least-once; the pipeline's idempotency absorbs redeliveries.
+type Consumer struct {
+	client   *kgo.Client
+	ingester Ingester
+	cfg      ConsumerConfig
+}
+
+func NewConsumer(cfg ConsumerConfig, ingester Ingester, opts ...kgo.Opt) (*Consumer, error) {
+	if len(cfg.Brokers) == 0 || cfg.Group == "" || len(cfg.Topics) == 0 {
+		return nil, fmt.Errorf("kafka: brokers, group and topics are required")
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = 500 * time.Millisecond
+	}
+	if cfg.MaxRetryBackoff <= 0 {
+		cfg.MaxRetryBackoff = 30 * time.Second
+	}
+
+	client, err := kgo.NewClient(append([]kgo.Opt{
+		kgo.SeedBrokers(cfg.Brokers...),
+		kgo.ConsumerGroup(cfg.Group),
+		kgo.ConsumeTopics(cfg.Topics...),
+		kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()),
+		kgo.DisableAutoCommit(),
+		// Keep partitions assigned while a poll is being processed, so a
+		// rebalance can't hand them to another member before we commit.
+		kgo.BlockRebalanceOnPoll(),
+	}, opts...)...)
+	if err != nil {
+		return nil, fmt.Errorf("kafka client: %w", err)
+	}
+	return &Consumer{client: client, ingester: ingester, cfg: cfg}, nil
+}
+
+func (c *Consumer) Close() {
+	// A poll may still be holding off a rebalance; leaving the group waits for it.
+	c.client.AllowRebalance()
+	c.client.Close()
+}
+
+// Run blocks until ctx is cancelled.
+func (c *Consumer) Run(ctx context.Context) error {
+	for {
+		fetches := c.client.PollFetches(ctx)
+		if fetches.IsClientClosed() || ctx.Err() != nil {
+			c.client.AllowRebalance()
+			return ctx.Err()
+		}
+		fetches.EachError(func(topic string, partition int32, err error) {
+			log.Printf("kafka fetch %s/%d: %v", topic, partition, err)
+		})
+
+		var (
+			wg   sync.WaitGroup
+			mu   sync.Mutex
+			done []*kgo.Record
+		)
+		fetches.EachPartition(func(p kgo.FetchTopicPartition) {
+			if len(p.Records) == 0 {
+				return
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for _, r := range p.Records {
+					if err := c.handle(ctx, r); err != nil {
+						// Only cancellation gets here; the rest of the
+						// partition is redelivered after restart.
+						return
+					}
+					mu.Lock()
+					done = append(done, r)
+					mu.Unlock()
+				}
+			}()
+		})
+		wg.Wait()
+
+		if len(done) > 0 {
+			// Commit even when shutting down, so finished work isn't replayed.
+			commitCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			if err := c.client.CommitRecords(commitCtx, done...); err != nil {
+				log.Printf("kafka commit: %v", err)
+			}
+			cancel()
+		}
+		c.client.AllowRebalance()
+	}
+}
+
+// handle ingests one record, dead-letters it if it is poison, and retries
+// anything else until it succeeds or ctx ends.
+func (c *Consumer) handle(ctx context.Context, r *kgo.Record) error {
+	headers := make(map[string]string, len(r.Headers))
+	for _, h := range r.Headers {
+		headers[h.Key] = string(h.Value)
+	}
+	ctx = ingest.MessageContext(ctx, headers)
+
+	for attempt := 1; ; attempt++ {
+		env, err := ingest.DecodeMessage(r.Value, headers, c.cfg.TopicTypes[r.Topic])
+		if err == nil {
+			_, err = c.ingester.Ingest(ctx, env)
+		}
+		if err == nil {
+			return nil
+		}
+		if ingest.IsPermanent(err) {
+			return c.deadLetter(ctx, r, err)
+		}
+
+		log.Printf("kafka %s/%d@%d: attempt %d: %v", r.Topic, r.Partition, r.Offset, attempt, err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(outbox.Backoff(attempt, c.cfg.RetryBackoff, c.cfg.MaxRetryBackoff)):
+		}
+	}
+}
+
+func (c *Consumer) deadLetter(ctx context.Context, r *kgo.Record, cause error) error {
+	if c.cfg.DLQTopic == "" {
+		log.Printf("kafka %s/%d@%d: dropping poison message: %v", r.Topic, r.Partition, r.Offset, cause)
+		return nil
+	}
+
+	dlq := &kgo.Record{
+		Topic: c.cfg.DLQTopic,
+		Key:   r.Key,
+		Value: r.Value,
+		Headers: append(append([]kgo.RecordHeader{}, r.Headers...),
+			kgo.RecordHeader{Key: HeaderDLQError, Value: []byte(cause.Error())},
+			kgo.RecordHeader{Key: HeaderDLQTopic, Value: []byte(r.Topic)},
+			kgo.RecordHeader{Key: HeaderDLQPartition, Value: []byte(strconv.Itoa(int(r.Partition)))},
+			kgo.RecordHeader{Key: HeaderDLQOffset, Value: []byte(strconv.FormatInt(r.Offset, 10))},
+		),
+	}
+	// The source offset must not be committed before the DLQ write lands.
+	for attempt := 1; ; attempt++ {
+		err := c.client.ProduceSync(ctx, dlq).FirstErr()
+		if err == nil {
+			log.Printf("kafka %s/%d@%d: dead-lettered to %s: %v", r.Topic, r.Partition, r.Offset, c.cfg.DLQTopic, cause)
+			return nil
+		}
+		log.Printf("kafka dlq produce: attempt %d: %v", attempt, err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(outbox.Backoff(attempt, c.cfg.RetryBackoff, c.cfg.MaxRetryBackoff)):
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"inbox-service/internal/application/ingest"
+	"inbox-service/internal/application/ports"
+
+	"github.com/twmb/franz-go/pkg/kfake"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// --- fakes ---
+
+type fakeIngester struct {
+	mu       sync.Mutex
+	seen     []string // envelope ids, in order
+	failOnce map[string]bool
+	corr     map[string]string // envelope id -> correlation id from the context
+}
+
+func (f *fakeIngester) Ingest(ctx context.Context, env ingest.Envelope) (ingest.Result, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if env.Type == "Poison" {
+		return ingest.Result{}, fmt.Errorf("%w: poison", ingest.ErrInvalidEvent)
+	}
+	if f.failOnce[env.ID] {
+		delete(f.failOnce, env.ID)
+		return ingest.Result{}, errors.New("database unavailable")
+	}
+	f.seen = append(f.seen, env.ID)
+	if f.corr == nil {
+		f.corr = map[string]string{}
+	}
+	f.corr[env.ID] = ports.MessageMetaFrom(ctx).CorrelationID
+	return ingest.Result{}, nil
+}
+
+func (f *fakeIngester) ids() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.seen...)
+}
+
+func envelope(id, typ string) []byte {
+	return []byte(fmt.Sprintf(`{"id":%q,"type":%q,"tenant_id":"t","payload":{}}`, id, typ))
+}
+
+func produce(t *testing.T, brokers []string, recs ...*kgo.Record) {
+	t.Helper()
+	cl, err := kgo.NewClient(kgo.SeedBrokers(brokers...))
+	if err != nil {
+		t.Fatalf("producer: %v", err)
+	}
+	defer cl.Close()
+	if err := cl.ProduceSync(context.Background(), recs...).FirstErr(); err != nil {
+		t.Fatalf("produce: %v", err)
+	}
+}
+
+// consume runs a consumer until want ids were ingested, then stops it.
+func consume(t *testing.T, brokers []string, ing *fakeIngester, want int) {
+	t.Helper()
+	c, err := NewConsumer(ConsumerConfig{
+		Brokers:      brokers,
+		Group:        "inbox-test",
+		Topics:       []string{"events"},
+		DLQTopic:     "events.dlq",
+		RetryBackoff: time.Millisecond,
+	}, ing)
+	if err != nil {
+		t.Fatalf("NewConsumer: %v", err)
+	}
+	defer c.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- c.Run(ctx) }()
+
+	deadline := time.After(10 * time.Second)
+	for len(ing.ids()) < want {
+		select {
+		case <-deadline:
+			cancel()
+			t.Fatalf("timed out; ingested %v", ing.ids())
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	cancel()
+	<-done
+}
+
+// --- tests ---
+
+func TestConsumer_OrderedIngestDLQAndCommit(t *testing.T) {
+	cluster, err := kfake.NewCluster(kfake.NumBrokers(1), kfake.SeedTopics(1, "events", "events.dlq"))
+	if err != nil {
+		t.Fatalf("kfake: %v", err)
+	}
+	defer cluster.Close()
+	brokers := cluster.ListenAddrs()
+
+	produce(t, brokers,
+		&kgo.Record{Topic: "events", Value: envelope("e1", "TaskAssignedToUser"), Headers: []kgo.RecordHeader{{Key: ports.HeaderCorrelationID, Value: []byte("corr-1")}}},
+		&kgo.Record{Topic: "events", Value: envelope("bad", "Poison")},
+		&kgo.Record{Topic: "events", Value: envelope("e2", "TaskAssignedToUser")},
+		&kgo.Record{Topic: "events", Value: envelope("e3", "TaskAssignedToUser")},
+	)
+
+	// e2 fails once (transient): it must be retried in place, not skipped.
+	ing := &fakeIngester{failOnce: map[string]bool{"e2": true}}
+	consume(t, brokers, ing, 3)
+	if got := ing.ids(); fmt.Sprint(got) != "[e1 e2 e3]" {
+		t.Fatalf("expected in-order ingest [e1 e2 e3], got %v", got)
+	}
+	if ing.corr["e1"] != "corr-1" || ing.corr["e2"] != "" {
+		t.Fatalf("expected the correlation header in the ingest context, got %v", ing.corr)
+	}
+
+	// The poison message landed on the DLQ with its origin.
+	dlq, err := kgo.NewClient(kgo.SeedBrokers(brokers...), kgo.ConsumeTopics("events.dlq"), kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()))
+	if err != nil {
+		t.Fatalf("dlq client: %v", err)
+	}
+	defer dlq.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	fetches := dlq.PollRecords(ctx, 1)
+	recs := fetches.Records()
+	if len(recs) != 1 || string(recs[0].Value) != string(envelope("bad", "Poison")) {
+		t.Fatalf("expected the poison message on the DLQ, got %d records (%v)", len(recs), fetches.Err())
+	}
+	headers := map[string]string{}
+	for _, h := range recs[0].Headers {
+		headers[h.Key] = string(h.Value)
+	}
+	if headers[HeaderDLQTopic] != "events" || headers[HeaderDLQOffset] != "1" || headers[HeaderDLQError] == "" {
+		t.Fatalf("unexpected DLQ headers %v", headers)
+	}
+
+	// Offsets were committed: a restarted member only sees new messages.
+	produce(t, brokers, &kgo.Record{Topic: "events", Value: envelope("e4", "TaskAssignedToUser")})
+	again := &fakeIngester{}
+	consume(t, brokers, again, 1)
+	if got := again.ids(); fmt.Sprint(got) != "[e4]" {
+		t.Fatalf("expected only [e4] after restart, got %v", got)
+	}
+}
@@ -0,0 +1,70 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+
+	"inbox-service/internal/application/ports"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// HeaderMessageID carries the outbox id, stable across relay retries, for
+// consumer-side dedupe.
+const HeaderMessageID = "message-id"
+
+// Publisher produces outbox events to one topic, keyed by the message key
+// (the outbox partition key), so each key lands on one partition and keeps
+// its order. The producer is idempotent, so broker-side retries neither
+// duplicate nor reorder records within a partition.
+type Publisher struct {
+	client *kgo.Client
+	topic  string
+}
+
+func NewPublisher(brokers []string, topic string, opts ...kgo.Opt) (*Publisher, error) {
+	if len(brokers) == 0 || topic == "" {
+		return nil, fmt.Errorf("kafka: brokers and topic are required")
+	}
+	client, err := kgo.NewClient(append([]kgo.Opt{
+		kgo.SeedBrokers(brokers...),
+		kgo.DefaultProduceTopic(topic),
+		kgo.RequiredAcks(kgo.AllISRAcks()),
+		// Hash the key so the same key always maps to the same partition.
+		kgo.RecordPartitioner(kgo.StickyKeyPartitioner(nil)),
+	}, opts...)...)
+	if err != nil {
+		return nil, fmt.Errorf("kafka client: %w", err)
+	}
+	return &Publisher{client: client, topic: topic}, nil
+}
+
+func (p *Publisher) Close() {
+	p.client.Close()
+}
+
+// Publish waits for the broker's ack, so a nil error means the record is stored.
+func (p *Publisher) Publish(ctx context.Context, m ports.Message) error {
+	return p.PublishBatch(ctx, []ports.Message{m})
+}
+
+// PublishBatch produces the records in order and waits for all of them.
+func (p *Publisher) PublishBatch(ctx context.Context, ms []ports.Message) error {
+	recs := make([]*kgo.Record, len(ms))
+	for i, m := range ms {
+		recs[i] = record(m)
+	}
+	if err := p.client.ProduceSync(ctx, recs...).FirstErr(); err != nil {
+		return fmt.Errorf("produce to %s: %w", p.topic, err)
+	}
+	return nil
+}
+
+func record(m ports.Message) *kgo.Record {
+	headers := make([]kgo.RecordHeader, 0, len(m.Headers)+1)
+	headers = append(headers, kgo.RecordHeader{Key: HeaderMessageID, Value: []byte(m.ID)})
+	for k, v := range m.Headers {
+		headers = append(headers, kgo.RecordHeader{Key: k, Value: []byte(v)})
+	}
+	return &kgo.Record{Key: []byte(m.Key), Value: m.Payload, Headers: headers}
+}
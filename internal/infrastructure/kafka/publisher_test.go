@@ -0,0 +1,85 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"inbox-service/internal/application/ports"
+
+	"github.com/twmb/franz-go/pkg/kfake"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func TestPublisher_KeysKeepTheirPartitionAndOrder(t *testing.T) {
+	cluster, err := kfake.NewCluster(kfake.NumBrokers(1), kfake.SeedTopics(4, "inbox.events"))
+	if err != nil {
+		t.Fatalf("kfake: %v", err)
+	}
+	defer cluster.Close()
+	brokers := cluster.ListenAddrs()
+
+	pub, err := NewPublisher(brokers, "inbox.events")
+	if err != nil {
+		t.Fatalf("NewPublisher: %v", err)
+	}
+	defer pub.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var sent []ports.Message
+	for i := 1; i <= 3; i++ {
+		for _, key := range []string{"t/alice", "t/bob"} {
+			sent = append(sent, ports.Message{
+				ID:        fmt.Sprintf("%s-%d", key, i),
+				EventType: "InboxItemCreated",
+				Key:       key,
+				Headers:   map[string]string{ports.HeaderSequence: fmt.Sprint(i)},
+				Payload:   []byte(`{}`),
+			})
+		}
+	}
+	if err := pub.Publish(ctx, sent[0]); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if err := pub.PublishBatch(ctx, sent[1:]); err != nil {
+		t.Fatalf("PublishBatch: %v", err)
+	}
+
+	cl, err := kgo.NewClient(kgo.SeedBrokers(brokers...), kgo.ConsumeTopics("inbox.events"), kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()))
+	if err != nil {
+		t.Fatalf("consumer: %v", err)
+	}
+	defer cl.Close()
+
+	partition := map[string]int32{}
+	seqs := map[string][]string{}
+	for got := 0; got < len(sent); {
+		fetches := cl.PollFetches(ctx)
+		if err := ctx.Err(); err != nil {
+			t.Fatalf("got %d of %d records: %v", got, len(sent), err)
+		}
+		fetches.EachRecord(func(r *kgo.Record) {
+			got++
+			key := string(r.Key)
+			if p, ok := partition[key]; ok && p != r.Partition {
+				t.Errorf("%s landed on partitions %d and %d", key, p, r.Partition)
+			}
+			partition[key] = r.Partition
+			headers := map[string]string{}
+			for _, h := range r.Headers {
+				headers[h.Key] = string(h.Value)
+			}
+			if headers[HeaderMessageID] != fmt.Sprintf("%s-%s", key, headers[ports.HeaderSequence]) {
+				t.Errorf("unexpected headers %v on %s", headers, key)
+			}
+			seqs[key] = append(seqs[key], headers[ports.HeaderSequence])
+		})
+	}
+	for _, key := range []string{"t/alice", "t/bob"} {
+		if fmt.Sprint(seqs[key]) != "[1 2 3]" {
+			t.Fatalf("%s: expected sequence [1 2 3], got %v", key, seqs[key])
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package natsjs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"inbox-service/internal/application/ingest"
+	"inbox-service/internal/application/outbox"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// AdvisoryRejected is the type of the advisory published when a message is
+// terminated because it can never be ingested.
+const AdvisoryRejected = "inbox.ingest.rejected"
+
+// Ingester is the part of ingest.Handler the consumer needs.
+type Ingester interface {
+	Ingest(ctx context.Context, env ingest.Envelope) (ingest.Result, error)
+}
+
+type ConsumerConfig struct {
+	// Stream must already exist; the durable consumer is created or updated.
+	Stream  string
+	Durable string
+	// Subjects filters the stream; empty means all of it.
+	Subjects []string
+	// SubjectTypes maps a subject to the event type of bare payloads on it.
+	SubjectTypes map[string]string
+	// AdvisorySubject receives a JSON advisory for every terminated message.
+	// Empty means they are only logged.
+	AdvisorySubject string
+	AckWait         time.Duration
+	// MaxDeliver caps redeliveries of transient failures; 0 means unlimited.
+	MaxDeliver int
+	BatchSize  int
+	// Transient failures are nak'ed with a delay that grows per delivery.
+	RetryBackoff    time.Duration
+	MaxRetryBackoff time.Duration
+}
+
+// Advisory describes a terminated message. The message itself stays in the
+// stream (subject to its retention) and can be fetched by StreamSeq.
+type Advisory struct {
+	Type      string    `json:"type"`
+	Stream    string    `json:"stream"`
+	Consumer  string    `json:"consumer"`
+	StreamSeq uint64    `json:"stream_seq"`
+	Subject   string    `json:"subject"`
+	Error     string    `json:"error"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Consumer feeds a JetStream durable pull consumer into the ingest pipeline.
+// A message is acked only after the ingest transaction has committed; delivery
+// is at-least-once and the pipeline's idempotency absorbs redeliveries.
+type Consumer struct {
+	nc       *nats.Conn
+	cons     jetstream.Consumer
+	ingester Ingester
+	cfg      ConsumerConfig
+}
+
+func NewConsumer(ctx context.Context, nc *nats.Conn, cfg ConsumerConfig, ingester Ingester) (*Consumer, error) {
+	if cfg.Stream == "" || cfg.Durable == "" {
+		return nil, fmt.Errorf("nats: stream and durable are required")
+	}
+	if cfg.AckWait <= 0 {
+		cfg.AckWait = 30 * time.Second
+	}
+	if cfg.MaxDeliver <= 0 {
+		cfg.MaxDeliver = -1
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = time.Second
+	}
+	if cfg.MaxRetryBackoff <= 0 {
+		cfg.MaxRetryBackoff = 5 * time.Minute
+	}
+
+	js, err := jetstream.New(nc)
+	if err != nil {
+		return nil, fmt.Errorf("jetstream: %w", err)
+	}
+	cons, err := js.CreateOrUpdateConsumer(ctx, cfg.Stream, jetstream.ConsumerConfig{
+		Durable:        cfg.Durable,
+		FilterSubjects: cfg.Subjects,
+		AckPolicy:      jetstream.AckExplicitPolicy,
+		AckWait:        cfg.AckWait,
+		MaxDeliver:     cfg.MaxDeliver,
+		DeliverPolicy:  jetstream.DeliverAllPolicy,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("jetstream consumer %s/%s: %w", cfg.Stream, cfg.Durable, err)
+	}
+	return &Consumer{nc: nc, cons: cons, ingester: ingester, cfg: cfg}, nil
+}
+
+// Run blocks until ctx is cancelled.
+func (c *Consumer) Run(ctx context.Context) error {
+	it, err := c.cons.Messages(jetstream.PullMaxMessages(c.cfg.BatchSize))
+	if err != nil {
+		return fmt.Errorf("jetstream messages: %w", err)
+	}
+	defer it.Stop()
+
+	for {
+		msg, err := it.Next(jetstream.NextContext(ctx))
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if errors.Is(err, jetstream.ErrMsgIteratorClosed) {
+			return err
+		}
+		if err != nil {
+			log.Printf("jetstream next: %v", err)
+			continue
+		}
+		c.handle(ctx, msg)
+	}
+}
+
+// handle ingests one message and settles it: ack on success, term (plus an
+// advisory) when it can never succeed, and nak with a delay otherwise.
+func (c *Consumer) handle(ctx context.Context, msg jetstream.Msg) {
+	md, err := msg.Metadata()
+	if err != nil {
+		log.Printf("jetstream metadata: %v", err)
+		return
+	}
+
+	headers := make(map[string]string, len(msg.Headers()))
+	for k, vs := range msg.Headers() {
+		if len(vs) > 0 {
+			headers[k] = vs[0]
+		}
+	}
+	env, err := ingest.DecodeMessage(msg.Data(), headers, c.cfg.SubjectTypes[msg.Subject()])
+	if err == nil {
+		_, err = c.ingester.Ingest(ingest.MessageContext(ctx, headers), env)
+	}
+
+	switch {
+	case err == nil:
+		if err := msg.Ack(); err != nil {
+			log.Printf("jetstream ack %s#%d: %v", md.Stream, md.Sequence.Stream, err)
+		}
+	case ingest.IsPermanent(err):
+		c.advise(md, msg.Subject(), err)
+		if err := msg.TermWithReason(err.Error()); err != nil {
+			log.Printf("jetstream term %s#%d: %v", md.Stream, md.Sequence.Stream, err)
+		}
+	case ctx.Err() != nil:
+		// Shutting down: hand the message back right away.
+		_ = msg.Nak()
+	default:
+		delay := outbox.Backoff(int(md.NumDelivered), c.cfg.RetryBackoff, c.cfg.MaxRetryBackoff)
+		log.Printf("jetstream %s#%d: delivery %d: %v (retry in %s)", md.Stream, md.Sequence.Stream, md.NumDelivered, err, delay)
+		if err := msg.NakWithDelay(delay); err != nil {
+			log.Printf("jetstream nak %s#%d: %v", md.Stream, md.Sequence.Stream, err)
+		}
+	}
+}
+
+func (c *Consumer) advise(md *jetstream.MsgMetadata, subject string, cause error) {
+	log.Printf("jetstream %s#%d: terminating poison message: %v", md.Stream, md.Sequence.Stream, cause)
+	if c.cfg.AdvisorySubject == "" {
+		return
+	}
+	body, err := json.Marshal(Advisory{
+		Type:      AdvisoryRejected,
+		Stream:    md.Stream,
+		Consumer:  md.Consumer,
+		StreamSeq: md.Sequence.Stream,
+		Subject:   subject,
+		Error:     cause.Error(),
+		Timestamp: time.Now().UTC(),
+	})
+	if err != nil {
+		return
+	}
+	// Advisories are best effort, like the server's own.
+	if err := c.nc.Publish(c.cfg.AdvisorySubject, body); err != nil {
+		log.Printf("jetstream advisory: %v", err)
+	}
+}
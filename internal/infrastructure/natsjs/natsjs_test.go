@@ -0,0 +1,223 @@
+package natsjs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"inbox-service/internal/application/ingest"
+	"inbox-service/internal/application/ports"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// --- helpers ---
+
+func runServer(t *testing.T) *nats.Conn {
+	t.Helper()
+	srv, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      -1,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	if err != nil {
+		t.Fatalf("nats server: %v", err)
+	}
+	go srv.Start()
+	if !srv.ReadyForConnections(5 * time.Second) {
+		t.Fatalf("nats server not ready")
+	}
+	t.Cleanup(srv.Shutdown)
+
+	nc, err := nats.Connect(srv.ClientURL())
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(nc.Close)
+	return nc
+}
+
+func createStream(t *testing.T, nc *nats.Conn) jetstream.Stream {
+	t.Helper()
+	js, err := jetstream.New(nc)
+	if err != nil {
+		t.Fatalf("jetstream: %v", err)
+	}
+	s, err := js.CreateStream(context.Background(), jetstream.StreamConfig{
+		Name:       "INBOX",
+		Subjects:   []string{"inbox.events.>"},
+		Duplicates: time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("create stream: %v", err)
+	}
+	return s
+}
+
+type fakeIngester struct {
+	mu       sync.Mutex
+	seen     []string
+	failOnce map[string]bool
+}
+
+func (f *fakeIngester) Ingest(ctx context.Context, env ingest.Envelope) (ingest.Result, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if env.Type == "Poison" {
+		return ingest.Result{}, fmt.Errorf("%w: poison", ingest.ErrInvalidEvent)
+	}
+	if f.failOnce[env.ID] {
+		delete(f.failOnce, env.ID)
+		return ingest.Result{}, errors.New("database unavailable")
+	}
+	id := env.ID
+	if id == "" {
+		id = string(env.Payload)
+	}
+	f.seen = append(f.seen, env.Type+":"+id)
+	return ingest.Result{}, nil
+}
+
+func (f *fakeIngester) ids() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := append([]string(nil), f.seen...)
+	sort.Strings(out)
+	return out
+}
+
+// --- tests ---
+
+func TestPublisher_DedupesByOutboxID(t *testing.T) {
+	nc := runServer(t)
+	stream := createStream(t, nc)
+	p, err := NewPublisher(nc, "inbox.events")
+	if err != nil {
+		t.Fatalf("NewPublisher: %v", err)
+	}
+
+	m := ports.Message{
+		ID:        "outbox-1",
+		EventType: "InboxItemCreated",
+		Headers:   map[string]string{ports.HeaderEventType: "InboxItemCreated", ports.HeaderTenantID: "t"},
+		Payload:   []byte(`{"inbox_item_id":"i1"}`),
+	}
+	// A relay retry after a lost ack publishes the same outbox row again.
+	if err := p.PublishBatch(context.Background(), []ports.Message{m, m}); err != nil {
+		t.Fatalf("PublishBatch: %v", err)
+	}
+
+	info, err := stream.Info(context.Background())
+	if err != nil {
+		t.Fatalf("stream info: %v", err)
+	}
+	if info.State.Msgs != 1 {
+		t.Fatalf("expected 1 stored message, got %d", info.State.Msgs)
+	}
+	raw, err := stream.GetMsg(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("get msg: %v", err)
+	}
+	if raw.Subject != "inbox.events.InboxItemCreated" ||
+		raw.Header.Get(jetstream.MsgIDHeader) != "outbox-1" ||
+		raw.Header.Get(ports.HeaderTenantID) != "t" {
+		t.Fatalf("unexpected stored message: %s %v", raw.Subject, raw.Header)
+	}
+}
+
+func TestConsumer_AcksRetriesAndTerminates(t *testing.T) {
+	nc := runServer(t)
+	createStream(t, nc)
+	js, _ := jetstream.New(nc)
+	ctx := context.Background()
+
+	advisories := make(chan *nats.Msg, 4)
+	sub, err := nc.ChanSubscribe("inbox.advisories", advisories)
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	for _, body := range []string{
+		`{"id":"e1","type":"TaskAssignedToUser","tenant_id":"t","payload":{}}`,
+		`{"id":"bad","type":"Poison","tenant_id":"t","payload":{}}`,
+		`{"id":"e2","type":"TaskAssignedToUser","tenant_id":"t","payload":{}}`,
+	} {
+		if _, err := js.Publish(ctx, "inbox.events.envelopes", []byte(body)); err != nil {
+			t.Fatalf("publish: %v", err)
+		}
+	}
+	// A bare payload, typed by the subject mapping.
+	if _, err := js.Publish(ctx, "inbox.events.mentions", []byte(`{"mention_id":"m1"}`)); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+
+	ing := &fakeIngester{failOnce: map[string]bool{"e1": true}}
+	c, err := NewConsumer(ctx, nc, ConsumerConfig{
+		Stream:          "INBOX",
+		Durable:         "inbox-test",
+		SubjectTypes:    map[string]string{"inbox.events.mentions": "CommentMentioned"},
+		AdvisorySubject: "inbox.advisories",
+		RetryBackoff:    time.Millisecond,
+		MaxRetryBackoff: 10 * time.Millisecond,
+	}, ing)
+	if err != nil {
+		t.Fatalf("NewConsumer: %v", err)
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	done := make(chan error, 1)
+	go func() { done <- c.Run(runCtx) }()
+
+	select {
+	case m := <-advisories:
+		var a Advisory
+		if err := json.Unmarshal(m.Data, &a); err != nil {
+			t.Fatalf("advisory: %v", err)
+		}
+		if a.Type != AdvisoryRejected || a.StreamSeq != 2 || a.Consumer != "inbox-test" {
+			t.Fatalf("unexpected advisory %+v", a)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("no advisory for the poison message")
+	}
+
+	want := []string{`CommentMentioned:{"mention_id":"m1"}`, "TaskAssignedToUser:e1", "TaskAssignedToUser:e2"}
+	deadline := time.Now().Add(10 * time.Second)
+	for fmt.Sprint(ing.ids()) != fmt.Sprint(want) {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %v, got %v", want, ing.ids())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Everything is settled: nothing pending and nothing awaiting an ack.
+	for {
+		info, err := c.cons.Info(ctx)
+		if err != nil {
+			t.Fatalf("consumer info: %v", err)
+		}
+		if info.NumPending == 0 && info.NumAckPending == 0 && info.NumRedelivered == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("consumer not settled: %+v", info)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run: %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+package natsjs
+
+import (
+	"context"
+	"fmt"
+
+	"inbox-service/internal/application/ports"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// Publisher publishes outbox events to JetStream on "<prefix>.<event type>",
+// e.g. inbox.events.InboxItemCreated. The outbox id is sent as Nats-Msg-Id so
+// the stream drops retries of an already stored message (within its
+// duplicate window).
+type Publisher struct {
+	js     jetstream.JetStream
+	prefix string
+}
+
+func NewPublisher(nc *nats.Conn, subjectPrefix string) (*Publisher, error) {
+	if subjectPrefix == "" {
+		return nil, fmt.Errorf("nats: subject prefix is required")
+	}
+	js, err := jetstream.New(nc)
+	if err != nil {
+		return nil, fmt.Errorf("jetstream: %w", err)
+	}
+	return &Publisher{js: js, prefix: subjectPrefix}, nil
+}
+
+func (p *Publisher) Subject(eventType string) string {
+	return p.prefix + "." + eventType
+}
+
+// Publish waits for the stream's ack, so a nil error means the event is stored.
+func (p *Publisher) Publish(ctx context.Context, m ports.Message) error {
+	msg := nats.NewMsg(p.Subject(m.EventType))
+	msg.Data = m.Payload
+	for k, v := range m.Headers {
+		msg.Header.Set(k, v)
+	}
+	if _, err := p.js.PublishMsg(ctx, msg, jetstream.WithMsgID(m.ID)); err != nil {
+		return fmt.Errorf("publish %s to %s: %w", m.ID, msg.Subject, err)
+	}
+	return nil
+}
+
+func (p *Publisher) PublishBatch(ctx context.Context, ms []ports.Message) error {
+	for _, m := range ms {
+		if err := p.Publish(ctx, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
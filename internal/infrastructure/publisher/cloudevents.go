@@ -0,0 +1,90 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+
+	"inbox-service/internal/application/ports"
+)
+
+// CloudEventsContentType is the content type of structured-mode CloudEvents.
+const CloudEventsContentType = "application/cloudevents+json"
+
+// CloudEventsPublisher wraps each message's payload in a structured-mode
+// CloudEvents 1.0 event before handing it to next. The id is the outbox id,
+// time the payload's occurred_at, and the tenant, partition key, sequence and
+// tracing headers become the tenantid, partitionkey, sequence, correlationid,
+// causationid and traceparent extensions. Headers are passed on unchanged,
+// plus a content-type.
+type CloudEventsPublisher struct {
+	next   ports.EventPublisher
+	source string
+}
+
+func NewCloudEventsPublisher(next ports.EventPublisher, source string) *CloudEventsPublisher {
+	return &CloudEventsPublisher{next: next, source: source}
+}
+
+func (p *CloudEventsPublisher) Publish(ctx context.Context, m ports.Message) error {
+	return p.PublishBatch(ctx, []ports.Message{m})
+}
+
+func (p *CloudEventsPublisher) PublishBatch(ctx context.Context, ms []ports.Message) error {
+	out := make([]ports.Message, len(ms))
+	for i, m := range ms {
+		ce, err := p.wrap(m)
+		if err != nil {
+			return err
+		}
+		out[i] = ce
+	}
+	return p.next.PublishBatch(ctx, out)
+}
+
+func (p *CloudEventsPublisher) wrap(m ports.Message) (ports.Message, error) {
+	var fields struct {
+		OccurredAt string `json:"occurred_at"`
+	}
+	// Payloads are JSON objects; one that isn't just goes without a time.
+	_ = json.Unmarshal(m.Payload, &fields)
+
+	ce := map[string]any{
+		"specversion":     "1.0",
+		"id":              m.ID,
+		"source":          p.source,
+		"type":            m.EventType,
+		"datacontenttype": "application/json",
+		"data":            json.RawMessage(m.Payload),
+		"tenantid":        m.TenantID,
+	}
+	if fields.OccurredAt != "" {
+		ce["time"] = fields.OccurredAt
+	}
+	if m.Key != "" {
+		ce["partitionkey"] = m.Key
+	}
+	for ext, header := range map[string]string{
+		"sequence":      ports.HeaderSequence,
+		"correlationid": ports.HeaderCorrelationID,
+		"causationid":   ports.HeaderCausationID,
+		"traceparent":   ports.HeaderTraceparent,
+	} {
+		if v := m.Headers[header]; v != "" {
+			ce[ext] = v
+		}
+	}
+	payload, err := json.Marshal(ce)
+	if err != nil {
+		return ports.Message{}, fmt.Errorf("marshal cloudevent %s: %w", m.ID, err)
+	}
+
+	headers := maps.Clone(m.Headers)
+	if headers == nil {
+		headers = map[string]string{}
+	}
+	headers[ports.HeaderContentType] = CloudEventsContentType
+	m.Headers, m.Payload = headers, payload
+	return m, nil
+}
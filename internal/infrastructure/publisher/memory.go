@@ -0,0 +1,53 @@
+package publisher
+
+import (
+	"context"
+	"sync"
+
+	"inbox-service/internal/application/ports"
+)
+
+// MemoryPublisher keeps published messages in memory. Intended for tests.
+type MemoryPublisher struct {
+	mu   sync.Mutex
+	msgs []ports.Message
+	err  error
+}
+
+func NewMemoryPublisher() *MemoryPublisher {
+	return &MemoryPublisher{}
+}
+
+func (p *MemoryPublisher) Publish(ctx context.Context, m ports.Message) error {
+	return p.PublishBatch(ctx, []ports.Message{m})
+}
+
+func (p *MemoryPublisher) PublishBatch(ctx context.Context, ms []ports.Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return p.err
+	}
+	p.msgs = append(p.msgs, ms...)
+	return nil
+}
+
+// FailWith makes subsequent publishes return err (nil restores success).
+func (p *MemoryPublisher) FailWith(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.err = err
+}
+
+// Messages returns a copy of everything published so far.
+func (p *MemoryPublisher) Messages() []ports.Message {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]ports.Message(nil), p.msgs...)
+}
+
+func (p *MemoryPublisher) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.msgs = nil
+}
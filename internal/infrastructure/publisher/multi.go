@@ -0,0 +1,32 @@
+package publisher
+
+import (
+	"context"
+
+	"inbox-service/internal/application/ports"
+)
+
+// MultiPublisher sends every message to several transports in turn. A failure
+// in any of them fails the publish, so the dispatcher retries and the
+// transports that already succeeded see the message again; they are expected
+// to dedupe on the message id.
+type MultiPublisher struct {
+	pubs []ports.EventPublisher
+}
+
+func NewMultiPublisher(pubs ...ports.EventPublisher) *MultiPublisher {
+	return &MultiPublisher{pubs: pubs}
+}
+
+func (p *MultiPublisher) Publish(ctx context.Context, m ports.Message) error {
+	return p.PublishBatch(ctx, []ports.Message{m})
+}
+
+func (p *MultiPublisher) PublishBatch(ctx context.Context, ms []ports.Message) error {
+	for _, pub := range p.pubs {
+		if err := pub.PublishBatch(ctx, ms); err != nil {
+			return err
+		}
+	}
+	return nil
+}
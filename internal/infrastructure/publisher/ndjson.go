@@ -0,0 +1,92 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"inbox-service/internal/application/ports"
+)
+
+// NDJSONPublisher writes one JSON object per message to w. Useful for local
+// development (stdout) and for handing events to file-based tooling.
+type NDJSONPublisher struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+}
+
+type ndjsonLine struct {
+	ID          string            `json:"id"`
+	TenantID    string            `json:"tenant_id"`
+	EventType   string            `json:"event_type"`
+	Key         string            `json:"key"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Payload     json.RawMessage   `json:"payload"`
+	PublishedAt time.Time         `json:"published_at"`
+}
+
+func NewNDJSONPublisher(w io.Writer) *NDJSONPublisher {
+	return &NDJSONPublisher{w: w}
+}
+
+func NewStdoutPublisher() *NDJSONPublisher {
+	return NewNDJSONPublisher(os.Stdout)
+}
+
+// NewFilePublisher appends to path, creating it if needed.
+func NewFilePublisher(path string) (*NDJSONPublisher, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open publisher file: %w", err)
+	}
+	return &NDJSONPublisher{w: f, closer: f}, nil
+}
+
+func (p *NDJSONPublisher) Publish(ctx context.Context, m ports.Message) error {
+	return p.PublishBatch(ctx, []ports.Message{m})
+}
+
+func (p *NDJSONPublisher) PublishBatch(ctx context.Context, ms []ports.Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// Encode everything first so a batch is written with a single Write.
+	var buf []byte
+	now := time.Now().UTC()
+	for _, m := range ms {
+		line, err := json.Marshal(ndjsonLine{
+			ID:          m.ID,
+			TenantID:    m.TenantID,
+			EventType:   m.EventType,
+			Key:         m.Key,
+			Headers:     m.Headers,
+			Payload:     json.RawMessage(m.Payload),
+			PublishedAt: now,
+		})
+		if err != nil {
+			return fmt.Errorf("encode message %s: %w", m.ID, err)
+		}
+		buf = append(buf, line...)
+		buf = append(buf, '\n')
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, err := p.w.Write(buf); err != nil {
+		return fmt.Errorf("write messages: %w", err)
+	}
+	return nil
+}
+
+func (p *NDJSONPublisher) Close() error {
+	if p.closer == nil {
+		return nil
+	}
+	return p.closer.Close()
+}
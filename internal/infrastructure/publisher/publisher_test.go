@@ -0,0 +1,121 @@
+package publisher
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"inbox-service/internal/application/ports"
+)
+
+func TestNDJSONPublisher_WritesOneLinePerMessage(t *testing.T) {
+	var buf bytes.Buffer
+	p := NewNDJSONPublisher(&buf)
+
+	err := p.PublishBatch(context.Background(), []ports.Message{
+		{ID: "1", TenantID: "t", EventType: "InboxItemCreated", Key: "t", Payload: []byte(`{"a":1}`)},
+		{ID: "2", TenantID: "t", EventType: "InboxItemCreated", Key: "t", Payload: []byte(`{"a":2}`)},
+	})
+	if err != nil {
+		t.Fatalf("PublishBatch: %v", err)
+	}
+
+	sc := bufio.NewScanner(&buf)
+	var lines []ndjsonLine
+	for sc.Scan() {
+		var l ndjsonLine
+		if err := json.Unmarshal(sc.Bytes(), &l); err != nil {
+			t.Fatalf("line is not valid json: %v", err)
+		}
+		lines = append(lines, l)
+	}
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(lines))
+	}
+	if lines[1].ID != "2" || string(lines[1].Payload) != `{"a":2}` {
+		t.Fatalf("unexpected second line: %+v", lines[1])
+	}
+}
+
+func TestMemoryPublisher_RecordsAndFails(t *testing.T) {
+	p := NewMemoryPublisher()
+	ctx := context.Background()
+
+	if err := p.Publish(ctx, ports.Message{ID: "1"}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	p.FailWith(errors.New("down"))
+	if err := p.Publish(ctx, ports.Message{ID: "2"}); err == nil {
+		t.Fatalf("expected injected error")
+	}
+
+	if got := p.Messages(); len(got) != 1 || got[0].ID != "1" {
+		t.Fatalf("unexpected messages: %+v", got)
+	}
+}
+
+func TestMultiPublisher_StopsAtFirstFailure(t *testing.T) {
+	a, b, c := NewMemoryPublisher(), NewMemoryPublisher(), NewMemoryPublisher()
+	p := NewMultiPublisher(a, b, c)
+	ctx := context.Background()
+
+	if err := p.Publish(ctx, ports.Message{ID: "1"}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	b.FailWith(errors.New("down"))
+	if err := p.Publish(ctx, ports.Message{ID: "2"}); err == nil {
+		t.Fatalf("expected the second transport's error")
+	}
+
+	if len(a.Messages()) != 2 || len(b.Messages()) != 1 || len(c.Messages()) != 1 {
+		t.Fatalf("expected 2/1/1 messages, got %d/%d/%d", len(a.Messages()), len(b.Messages()), len(c.Messages()))
+	}
+}
+
+func TestCloudEventsPublisher_WrapsPayload(t *testing.T) {
+	mem := NewMemoryPublisher()
+	p := NewCloudEventsPublisher(mem, "/inbox-service")
+
+	err := p.Publish(context.Background(), ports.Message{
+		ID:        "1",
+		TenantID:  "t",
+		EventType: "InboxItemCreated",
+		Key:       "t/u",
+		Headers:   map[string]string{ports.HeaderSequence: "3", ports.HeaderCorrelationID: "corr-1"},
+		Payload:   []byte(`{"occurred_at":"2026-01-02T03:04:05Z","a":1}`),
+	})
+	if err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	got := mem.Messages()[0]
+	if got.ID != "1" || got.Headers[ports.HeaderContentType] != CloudEventsContentType || got.Headers[ports.HeaderSequence] != "3" {
+		t.Fatalf("unexpected message %+v", got)
+	}
+	var ce map[string]any
+	if err := json.Unmarshal(got.Payload, &ce); err != nil {
+		t.Fatalf("payload is not JSON: %v", err)
+	}
+	for k, want := range map[string]any{
+		"specversion":   "1.0",
+		"id":            "1",
+		"source":        "/inbox-service",
+		"type":          "InboxItemCreated",
+		"time":          "2026-01-02T03:04:05Z",
+		"tenantid":      "t",
+		"partitionkey":  "t/u",
+		"sequence":      "3",
+		"correlationid": "corr-1",
+	} {
+		if ce[k] != want {
+			t.Errorf("%s: expected %v, got %v", k, want, ce[k])
+		}
+	}
+	if data, _ := ce["data"].(map[string]any); data["a"] != float64(1) {
+		t.Fatalf("expected the original payload as data, got %v", ce["data"])
+	}
+}
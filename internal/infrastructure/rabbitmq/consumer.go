@@ -0,0 +1,284 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"inbox-service/internal/application/ingest"
+	"inbox-service/internal/application/outbox"
+	"inbox-service/internal/application/ports"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Headers set on retried and parked messages.
+const (
+	HeaderRetryCount = "x-retry-count"
+	HeaderError      = "x-error"
+	HeaderSourceKey  = "x-source-routing-key"
+)
+
+// Ingester is the part of ingest.Handler the consumer needs.
+type Ingester interface {
+	Ingest(ctx context.Context, env ingest.Envelope) (ingest.Result, error)
+}
+
+type ConsumerConfig struct {
+	URL string
+	// Queue is the work queue. If Exchange is set the queue is bound to it
+	// with RoutingKeys ("#" when empty).
+	Queue       string
+	Exchange    string
+	RoutingKeys []string
+	// DefaultType is used for bare payloads without an event-type header or
+	// AMQP type property (e.g. TaskAssignedToUser from the legacy system).
+	DefaultType string
+	Prefetch    int
+	// RetryDelays are the delayed-retry tiers; a message's n-th retry waits
+	// RetryDelays[n-1], and the last tier repeats.
+	RetryDelays []time.Duration
+	// MaxRetries parks a message after that many transient failures; 0 means
+	// retry forever.
+	MaxRetries int
+}
+
+// Queue names derived from the work queue.
+func (c ConsumerConfig) retryQueue(d time.Duration) string {
+	return c.Queue + ".retry." + d.String()
+}
+
+func (c ConsumerConfig) parkingLot() string {
+	return c.Queue + ".parking-lot"
+}
+
+// Consumer feeds an AMQP queue into the ingest pipeline.
+//
+// Topology (declared on connect):
+//
+//	<queue>               work queue, optionally bound to Exchange
+//	<queue>.retry.<delay> one per tier; x-message-ttl=<delay> and dead-lettered
+//	                      back to <queue> through the default exchange
+//	<queue>.parking-lot   messages that can never be ingested
+//
+// A delivery is acked only after the ingest transaction has committed, or
+// after its copy has been confirmed by the broker on a retry queue or the
+// parking lot. Delivery is at-least-once; the pipeline's idempotency absorbs
+// redeliveries.
+type Consumer struct {
+	cfg      ConsumerConfig
+	ingester Ingester
+
+	// publish sends to a queue through the default exchange and waits for
+	// the broker's confirm. Replaced in tests.
+	publish func(ctx context.Context, queue string, msg amqp.Publishing) error
+}
+
+func NewConsumer(cfg ConsumerConfig, ingester Ingester) (*Consumer, error) {
+	if cfg.URL == "" || cfg.Queue == "" {
+		return nil, fmt.Errorf("rabbitmq: url and queue are required")
+	}
+	if cfg.Prefetch <= 0 {
+		cfg.Prefetch = 20
+	}
+	if len(cfg.RetryDelays) == 0 {
+		cfg.RetryDelays = []time.Duration{time.Second, 10 * time.Second, time.Minute, 10 * time.Minute}
+	}
+	return &Consumer{cfg: cfg, ingester: ingester}, nil
+}
+
+// Close is a no-op: Run closes its connection when it returns.
+func (c *Consumer) Close() {}
+
+// Run consumes until ctx is cancelled, reconnecting when the connection or
+// channel is lost.
+func (c *Consumer) Run(ctx context.Context) error {
+	for attempt := 1; ; attempt++ {
+		err := c.session(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		log.Printf("rabbitmq: session ended: %v", err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(outbox.Backoff(attempt, time.Second, 30*time.Second)):
+		}
+	}
+}
+
+// session runs one connection until it breaks or ctx ends.
+func (c *Consumer) session(ctx context.Context) error {
+	conn, err := amqp.Dial(c.cfg.URL)
+	if err != nil {
+		return fmt.Errorf("dial: %w", err)
+	}
+	defer conn.Close()
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return fmt.Errorf("channel: %w", err)
+	}
+	if err := c.declare(ch); err != nil {
+		return err
+	}
+	if err := ch.Qos(c.cfg.Prefetch, 0, false); err != nil {
+		return fmt.Errorf("qos: %w", err)
+	}
+	if err := ch.Confirm(false); err != nil {
+		return fmt.Errorf("confirm mode: %w", err)
+	}
+	c.publish = func(ctx context.Context, queue string, msg amqp.Publishing) error {
+		dc, err := ch.PublishWithDeferredConfirmWithContext(ctx, "", queue, false, false, msg)
+		if err != nil {
+			return err
+		}
+		ok, err := dc.WaitContext(ctx)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("broker nacked publish to %s", queue)
+		}
+		return nil
+	}
+
+	deliveries, err := ch.ConsumeWithContext(ctx, c.cfg.Queue, "", false, false, false, false, nil)
+	if err != nil {
+		return fmt.Errorf("consume: %w", err)
+	}
+	log.Printf("rabbitmq: consuming %s (prefetch %d)", c.cfg.Queue, c.cfg.Prefetch)
+	for d := range deliveries {
+		c.handle(ctx, d)
+	}
+	return errors.New("delivery channel closed")
+}
+
+func (c *Consumer) declare(ch *amqp.Channel) error {
+	if _, err := ch.QueueDeclare(c.cfg.Queue, true, false, false, false, nil); err != nil {
+		return fmt.Errorf("declare %s: %w", c.cfg.Queue, err)
+	}
+	if c.cfg.Exchange != "" {
+		keys := c.cfg.RoutingKeys
+		if len(keys) == 0 {
+			keys = []string{"#"}
+		}
+		for _, k := range keys {
+			if err := ch.QueueBind(c.cfg.Queue, k, c.cfg.Exchange, false, nil); err != nil {
+				return fmt.Errorf("bind %s to %s/%s: %w", c.cfg.Queue, c.cfg.Exchange, k, err)
+			}
+		}
+	}
+	for _, d := range c.cfg.RetryDelays {
+		q := c.cfg.retryQueue(d)
+		if _, err := ch.QueueDeclare(q, true, false, false, false, amqp.Table{
+			"x-message-ttl":             d.Milliseconds(),
+			"x-dead-letter-exchange":    "",
+			"x-dead-letter-routing-key": c.cfg.Queue,
+		}); err != nil {
+			return fmt.Errorf("declare %s: %w", q, err)
+		}
+	}
+	if _, err := ch.QueueDeclare(c.cfg.parkingLot(), true, false, false, false, nil); err != nil {
+		return fmt.Errorf("declare %s: %w", c.cfg.parkingLot(), err)
+	}
+	return nil
+}
+
+// handle ingests one delivery and settles it.
+func (c *Consumer) handle(ctx context.Context, d amqp.Delivery) {
+	headers := map[string]string{}
+	for k, v := range d.Headers {
+		if s, ok := v.(string); ok {
+			headers[k] = s
+		}
+	}
+	if headers[ports.HeaderEventType] == "" && d.Type != "" {
+		headers[ports.HeaderEventType] = d.Type
+	}
+	if headers[ports.HeaderCorrelationID] == "" && d.CorrelationId != "" {
+		headers[ports.HeaderCorrelationID] = d.CorrelationId
+	}
+
+	env, err := ingest.DecodeMessage(d.Body, headers, c.cfg.DefaultType)
+	if err == nil && env.ID == "" {
+		env.ID = d.MessageId
+	}
+	if err == nil {
+		_, err = c.ingester.Ingest(ingest.MessageContext(ctx, headers), env)
+	}
+	if err == nil {
+		if err := d.Ack(false); err != nil {
+			log.Printf("rabbitmq ack: %v", err)
+		}
+		return
+	}
+	if ctx.Err() != nil {
+		_ = d.Nack(false, true)
+		return
+	}
+
+	retries := retryCount(d.Headers)
+	target := c.cfg.parkingLot()
+	if !ingest.IsPermanent(err) && (c.cfg.MaxRetries == 0 || retries < c.cfg.MaxRetries) {
+		tier := retries
+		if tier >= len(c.cfg.RetryDelays) {
+			tier = len(c.cfg.RetryDelays) - 1
+		}
+		target = c.cfg.retryQueue(c.cfg.RetryDelays[tier])
+		retries++
+	}
+	log.Printf("rabbitmq %s: %v -> %s", d.MessageId, err, target)
+
+	if err := c.publish(ctx, target, republish(d, retries, err)); err != nil {
+		// Not safely stored elsewhere: hand it back to the broker.
+		log.Printf("rabbitmq publish to %s: %v", target, err)
+		_ = d.Nack(false, true)
+		return
+	}
+	if err := d.Ack(false); err != nil {
+		log.Printf("rabbitmq ack: %v", err)
+	}
+}
+
+// republish copies a delivery for a retry queue or the parking lot.
+func republish(d amqp.Delivery, retries int, cause error) amqp.Publishing {
+	headers := amqp.Table{}
+	for k, v := range d.Headers {
+		headers[k] = v
+	}
+	headers[HeaderRetryCount] = int32(retries)
+	headers[HeaderError] = cause.Error()
+	if _, ok := headers[HeaderSourceKey]; !ok {
+		headers[HeaderSourceKey] = d.RoutingKey
+	}
+	return amqp.Publishing{
+		Headers:       headers,
+		ContentType:   d.ContentType,
+		DeliveryMode:  amqp.Persistent,
+		CorrelationId: d.CorrelationId,
+		MessageId:     d.MessageId,
+		Timestamp:     d.Timestamp,
+		Type:          d.Type,
+		AppId:         d.AppId,
+		Body:          d.Body,
+	}
+}
+
+func retryCount(h amqp.Table) int {
+	switch v := h[HeaderRetryCount].(type) {
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case int:
+		return v
+	case string:
+		n, _ := strconv.Atoi(v)
+		return n
+	}
+	return 0
+}
@@ -0,0 +1,154 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"inbox-service/internal/application/ingest"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// --- fakes ---
+
+type fakeIngester struct {
+	err  error
+	envs []ingest.Envelope
+}
+
+func (f *fakeIngester) Ingest(ctx context.Context, env ingest.Envelope) (ingest.Result, error) {
+	f.envs = append(f.envs, env)
+	return ingest.Result{}, f.err
+}
+
+// fakeAck records how a delivery was settled.
+type fakeAck struct {
+	acked, nacked, requeued bool
+}
+
+func (a *fakeAck) Ack(tag uint64, multiple bool) error { a.acked = true; return nil }
+func (a *fakeAck) Nack(tag uint64, multiple, requeue bool) error {
+	a.nacked, a.requeued = true, requeue
+	return nil
+}
+func (a *fakeAck) Reject(tag uint64, requeue bool) error { return a.Nack(tag, false, requeue) }
+
+type published struct {
+	queue string
+	msg   amqp.Publishing
+}
+
+func newTestConsumer(t *testing.T, ing *fakeIngester, maxRetries int, pubErr error) (*Consumer, *[]published) {
+	t.Helper()
+	c, err := NewConsumer(ConsumerConfig{
+		URL:         "amqp://unused",
+		Queue:       "inbox.tasks",
+		DefaultType: "TaskAssignedToUser",
+		RetryDelays: []time.Duration{time.Second, time.Minute},
+		MaxRetries:  maxRetries,
+	}, ing)
+	if err != nil {
+		t.Fatalf("NewConsumer: %v", err)
+	}
+	var out []published
+	c.publish = func(ctx context.Context, queue string, msg amqp.Publishing) error {
+		if pubErr != nil {
+			return pubErr
+		}
+		out = append(out, published{queue, msg})
+		return nil
+	}
+	return c, &out
+}
+
+func delivery(ack *fakeAck, retries int) amqp.Delivery {
+	h := amqp.Table{"tenant-id": "t"}
+	if retries > 0 {
+		h[HeaderRetryCount] = int32(retries)
+	}
+	return amqp.Delivery{
+		Acknowledger: ack,
+		MessageId:    "m1",
+		RoutingKey:   "task.assigned",
+		Headers:      h,
+		Body:         []byte(`{"task_id":"42"}`),
+	}
+}
+
+// --- tests ---
+
+func TestHandle_AcksAfterIngest(t *testing.T) {
+	ing := &fakeIngester{}
+	c, pub := newTestConsumer(t, ing, 0, nil)
+	ack := &fakeAck{}
+
+	c.handle(context.Background(), delivery(ack, 0))
+
+	if !ack.acked || len(*pub) != 0 {
+		t.Fatalf("expected plain ack, got %+v and %d publishes", ack, len(*pub))
+	}
+	env := ing.envs[0]
+	if env.Type != "TaskAssignedToUser" || env.TenantID != "t" || env.ID != "m1" {
+		t.Fatalf("unexpected envelope %+v", env)
+	}
+}
+
+func TestHandle_TransientGoesToRetryTier(t *testing.T) {
+	for retries, want := range map[int]string{
+		0: "inbox.tasks.retry.1s",
+		1: "inbox.tasks.retry.1m0s",
+		5: "inbox.tasks.retry.1m0s", // the last tier repeats
+	} {
+		c, pub := newTestConsumer(t, &fakeIngester{err: errors.New("db down")}, 0, nil)
+		ack := &fakeAck{}
+
+		c.handle(context.Background(), delivery(ack, retries))
+
+		if !ack.acked || len(*pub) != 1 || (*pub)[0].queue != want {
+			t.Fatalf("retries=%d: expected ack and publish to %s, got %+v %+v", retries, want, ack, *pub)
+		}
+		if got := retryCount((*pub)[0].msg.Headers); got != retries+1 {
+			t.Fatalf("retries=%d: expected retry count %d, got %d", retries, retries+1, got)
+		}
+	}
+}
+
+func TestHandle_ValidationErrorIsParked(t *testing.T) {
+	c, pub := newTestConsumer(t, &fakeIngester{err: fmt.Errorf("%w: task_url required", ingest.ErrInvalidEvent)}, 0, nil)
+	ack := &fakeAck{}
+
+	c.handle(context.Background(), delivery(ack, 0))
+
+	if !ack.acked || len(*pub) != 1 || (*pub)[0].queue != "inbox.tasks.parking-lot" {
+		t.Fatalf("expected ack and publish to the parking lot, got %+v %+v", ack, *pub)
+	}
+	h := (*pub)[0].msg.Headers
+	if h[HeaderError] != "invalid event: task_url required" || h[HeaderSourceKey] != "task.assigned" || h["tenant-id"] != "t" {
+		t.Fatalf("unexpected parking-lot headers %v", h)
+	}
+}
+
+func TestHandle_MaxRetriesParks(t *testing.T) {
+	c, pub := newTestConsumer(t, &fakeIngester{err: errors.New("db down")}, 3, nil)
+	ack := &fakeAck{}
+
+	c.handle(context.Background(), delivery(ack, 3))
+
+	if len(*pub) != 1 || (*pub)[0].queue != "inbox.tasks.parking-lot" {
+		t.Fatalf("expected parking lot after max retries, got %+v", *pub)
+	}
+}
+
+func TestHandle_RequeuesWhenRepublishFails(t *testing.T) {
+	c, _ := newTestConsumer(t, &fakeIngester{err: errors.New("db down")}, 0, errors.New("channel closed"))
+	ack := &fakeAck{}
+
+	c.handle(context.Background(), delivery(ack, 0))
+
+	if ack.acked || !ack.nacked || !ack.requeued {
+		t.Fatalf("expected nack with requeue, got %+v", ack)
+	}
+}
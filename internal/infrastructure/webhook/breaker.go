@@ -0,0 +1,63 @@
+package webhook
+
+import (
+	"sync"
+	"time"
+)
+
+// breakers keeps a circuit breaker per endpoint key. threshold consecutive
+// failures open a breaker for openFor; after that one call is let through to
+// probe the endpoint, and its outcome closes or reopens the breaker.
+type breakers struct {
+	threshold int
+	openFor   time.Duration
+	now       func() time.Time
+
+	mu sync.Mutex
+	m  map[string]*breaker
+}
+
+type breaker struct {
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+func newBreakers(threshold int, openFor time.Duration, now func() time.Time) *breakers {
+	return &breakers{threshold: threshold, openFor: openFor, now: now, m: map[string]*breaker{}}
+}
+
+// allow reports whether the endpoint may be called.
+func (bs *breakers) allow(key string) bool {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	b := bs.m[key]
+	if b == nil || b.failures < bs.threshold {
+		return true
+	}
+	if b.probing || bs.now().Before(b.openUntil) {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+func (bs *breakers) record(key string, err error) {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	if err == nil {
+		// Healthy endpoints don't need an entry.
+		delete(bs.m, key)
+		return
+	}
+	b := bs.m[key]
+	if b == nil {
+		b = &breaker{}
+		bs.m[key] = b
+	}
+	b.probing = false
+	b.failures++
+	if b.failures >= bs.threshold {
+		b.openUntil = bs.now().Add(bs.openFor)
+	}
+}
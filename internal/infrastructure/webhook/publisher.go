@@ -0,0 +1,260 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"inbox-service/internal/application/ports"
+)
+
+// Request headers. The signature is "sha256=<hex>" of HMAC-SHA256 over
+// "<timestamp>.<body>" with the endpoint's secret; receivers should reject
+// timestamps outside a few minutes of their clock and dedupe on the
+// idempotency key, which is the outbox id and stays the same across retries.
+const (
+	HeaderSignature      = "X-Inbox-Signature"
+	HeaderTimestamp      = "X-Inbox-Timestamp"
+	HeaderIdempotencyKey = "Idempotency-Key"
+)
+
+// ErrCircuitOpen is returned for messages whose only remaining endpoints have
+// an open breaker; the dispatcher postpones them without using up an attempt.
+var ErrCircuitOpen = ports.ErrWebhookCircuitOpen
+
+// Endpoint is a receiver of outbox events.
+type Endpoint struct {
+	URL    string   `json:"url"`
+	Secret string   `json:"secret"`
+	Events []string `json:"events,omitempty"` // event types; empty means all
+}
+
+func (e Endpoint) wants(eventType string) bool {
+	return len(e.Events) == 0 || slices.Contains(e.Events, eventType)
+}
+
+type Config struct {
+	Client  *http.Client
+	Timeout time.Duration // per request
+	// FailureThreshold consecutive failures open an endpoint's breaker for
+	// OpenFor; after that one request is let through to probe it.
+	FailureThreshold int
+	OpenFor          time.Duration
+	// AllowPrivate lets the Sender reach loopback and private networks,
+	// which it refuses by default; only for local development.
+	AllowPrivate bool
+}
+
+func (c Config) withDefaults() Config {
+	if c.Client == nil {
+		c.Client = &http.Client{}
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = 10 * time.Second
+	}
+	if c.FailureThreshold <= 0 {
+		c.FailureThreshold = 5
+	}
+	if c.OpenFor <= 0 {
+		c.OpenFor = 30 * time.Second
+	}
+	return c
+}
+
+// maxDelivered bounds the partial fan-out bookkeeping. Past it the state is
+// dropped, which only means some endpoints see a retry twice.
+const maxDelivered = 10_000
+
+// Publisher POSTs each outbox event to every endpoint that wants its type.
+// Each endpoint has its own breaker, and endpoints that already accepted a
+// message are not called again when the dispatcher retries it for another
+// endpoint.
+type Publisher struct {
+	endpoints []Endpoint
+	cfg       Config
+	now       func() time.Time
+
+	breakers *breakers
+
+	mu        sync.Mutex
+	delivered map[string]map[int]bool // message id -> endpoints that accepted it
+}
+
+func NewPublisher(endpoints []Endpoint, cfg Config) (*Publisher, error) {
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("webhook: at least one endpoint is required")
+	}
+	for _, e := range endpoints {
+		if e.URL == "" || e.Secret == "" {
+			return nil, fmt.Errorf("webhook: endpoint needs a url and a secret")
+		}
+	}
+	p := &Publisher{
+		endpoints: endpoints,
+		cfg:       cfg.withDefaults(),
+		now:       time.Now,
+		delivered: map[string]map[int]bool{},
+	}
+	p.breakers = newBreakers(p.cfg.FailureThreshold, p.cfg.OpenFor, func() time.Time { return p.now() })
+	return p, nil
+}
+
+// Publish delivers m to all its endpoints and fails if any of them did not
+// accept it. Endpoints with an open breaker are skipped without blocking the
+// others; the error only matches ErrCircuitOpen if no endpoint that was
+// called failed, so a real failure still counts as an attempt.
+func (p *Publisher) Publish(ctx context.Context, m ports.Message) error {
+	var failed, open []error
+	for i, e := range p.endpoints {
+		if !e.wants(m.EventType) || p.wasDelivered(m.ID, i) {
+			continue
+		}
+		if !p.breakers.allow(e.URL) {
+			open = append(open, fmt.Errorf("%s: %w", e.URL, ErrCircuitOpen))
+			continue
+		}
+		_, err := post(ctx, p.cfg, e.URL, []string{e.Secret}, m, p.now())
+		p.breakers.record(e.URL, err)
+		if err != nil {
+			failed = append(failed, err)
+			continue
+		}
+		p.markDelivered(m.ID, i)
+	}
+	if len(failed) > 0 {
+		return errors.Join(failed...)
+	}
+	if len(open) > 0 {
+		return errors.Join(open...)
+	}
+	p.forget(m.ID)
+	return nil
+}
+
+// PublishBatch publishes messages in order and stops at the first failure.
+func (p *Publisher) PublishBatch(ctx context.Context, ms []ports.Message) error {
+	for _, m := range ms {
+		if err := p.Publish(ctx, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// post makes one signed call and returns the response status code.
+func post(ctx context.Context, cfg Config, url string, secrets []string, m ports.Message, now time.Time) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(m.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("webhook request %s: %w", url, err)
+	}
+	for k, v := range m.Headers {
+		req.Header.Set(headerName(k), v)
+	}
+	ts := strconv.FormatInt(now.Unix(), 10)
+	sigs := make([]string, len(secrets))
+	for i, secret := range secrets {
+		sigs[i] = Sign(secret, ts, m.Payload)
+	}
+	if req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set(HeaderIdempotencyKey, m.ID)
+	req.Header.Set(HeaderTimestamp, ts)
+	req.Header.Set(HeaderSignature, strings.Join(sigs, ","))
+
+	resp, err := cfg.Client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("webhook %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook %s: status %d", url, resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// headerName maps message headers to HTTP: the content type and the tracing
+// headers keep the names the API accepts, the rest get an X-Inbox- prefix.
+func headerName(k string) string {
+	switch k {
+	case ports.HeaderContentType:
+		return "Content-Type"
+	case ports.HeaderTraceparent:
+		return "traceparent"
+	case ports.HeaderCorrelationID:
+		return "X-Correlation-Id"
+	case ports.HeaderCausationID:
+		return "X-Causation-Id"
+	}
+	return "X-Inbox-" + k
+}
+
+func (p *Publisher) markDelivered(id string, i int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.delivered) >= maxDelivered {
+		p.delivered = map[string]map[int]bool{}
+	}
+	if p.delivered[id] == nil {
+		p.delivered[id] = map[int]bool{}
+	}
+	p.delivered[id][i] = true
+}
+
+func (p *Publisher) wasDelivered(id string, i int) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.delivered[id][i]
+}
+
+func (p *Publisher) forget(id string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.delivered, id)
+}
+
+// Sign returns the signature header value for body sent at timestamp ts
+// (unix seconds).
+func Sign(secret, ts string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a received signature header and rejects timestamps more than
+// tolerance away from now. It is what receivers are expected to do. After a
+// secret rotation the header carries one comma-separated signature per
+// secret still in use; any of them matching is enough.
+func Verify(secret, ts, signature string, body []byte, now time.Time, tolerance time.Duration) error {
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return fmt.Errorf("webhook: bad timestamp %q", ts)
+	}
+	if d := now.Sub(time.Unix(sec, 0)); d > tolerance || d < -tolerance {
+		return fmt.Errorf("webhook: timestamp outside tolerance")
+	}
+	want := []byte(Sign(secret, ts, body))
+	for _, sig := range strings.Split(signature, ",") {
+		if hmac.Equal([]byte(strings.TrimSpace(sig)), want) {
+			return nil
+		}
+	}
+	return fmt.Errorf("webhook: signature mismatch")
+}
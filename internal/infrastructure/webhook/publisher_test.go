@@ -0,0 +1,192 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"inbox-service/internal/application/ports"
+)
+
+// receiver records verified deliveries and answers with status.
+type receiver struct {
+	mu     sync.Mutex
+	secret string
+	status int
+	calls  int
+	got    []*http.Request
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls++
+	if err := Verify(r.secret, req.Header.Get(HeaderTimestamp), req.Header.Get(HeaderSignature), body, time.Now(), 5*time.Minute); err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	r.got = append(r.got, req)
+	w.WriteHeader(r.status)
+}
+
+func (r *receiver) setStatus(code int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status = code
+}
+
+func (r *receiver) counts() (calls, ok int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.calls, len(r.got)
+}
+
+func message(id string) ports.Message {
+	return ports.Message{
+		ID:        id,
+		TenantID:  "t",
+		EventType: "InboxItemCreated",
+		Headers: map[string]string{
+			ports.HeaderEventType:     "InboxItemCreated",
+			ports.HeaderCorrelationID: "corr-1",
+		},
+		Payload: []byte(`{"event_id":"` + id + `"}`),
+	}
+}
+
+func TestPublisher_SignsAndSendsHeaders(t *testing.T) {
+	rcv := &receiver{secret: "s3cret", status: http.StatusNoContent}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+
+	p, err := NewPublisher([]Endpoint{{URL: srv.URL, Secret: "s3cret"}}, Config{})
+	if err != nil {
+		t.Fatalf("NewPublisher: %v", err)
+	}
+	if err := p.Publish(context.Background(), message("m1")); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	if _, ok := rcv.counts(); ok != 1 {
+		t.Fatalf("expected 1 verified delivery, got %d", ok)
+	}
+	h := rcv.got[0].Header
+	if h.Get(HeaderIdempotencyKey) != "m1" || h.Get("X-Inbox-Event-Type") != "InboxItemCreated" || h.Get("X-Correlation-Id") != "corr-1" {
+		t.Fatalf("unexpected headers %v", h)
+	}
+
+	// A wrong secret is caught by the receiver.
+	bad, _ := NewPublisher([]Endpoint{{URL: srv.URL, Secret: "other"}}, Config{})
+	if err := bad.Publish(context.Background(), message("m2")); err == nil {
+		t.Fatalf("expected a rejected signature to fail the publish")
+	}
+}
+
+func TestVerify_RejectsStaleTimestamps(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	ts := "1699999000" // 1000s earlier
+	sig := Sign("k", ts, []byte(`{}`))
+	if err := Verify("k", ts, sig, []byte(`{}`), now, 5*time.Minute); err == nil {
+		t.Fatalf("expected a stale timestamp to be rejected")
+	}
+	if err := Verify("k", ts, sig, []byte(`{}`), now, time.Hour); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+}
+
+func TestPublisher_DeadEndpointDoesNotHoldBackOthers(t *testing.T) {
+	good := &receiver{secret: "a", status: http.StatusOK}
+	dead := &receiver{secret: "b", status: http.StatusServiceUnavailable}
+	goodSrv, deadSrv := httptest.NewServer(good), httptest.NewServer(dead)
+	defer goodSrv.Close()
+	defer deadSrv.Close()
+
+	now := time.Now()
+	p, err := NewPublisher([]Endpoint{
+		{URL: goodSrv.URL, Secret: "a"},
+		{URL: deadSrv.URL, Secret: "b"},
+	}, Config{FailureThreshold: 2, OpenFor: time.Minute})
+	if err != nil {
+		t.Fatalf("NewPublisher: %v", err)
+	}
+	p.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	// Two failures open the dead endpoint's breaker.
+	for _, id := range []string{"m1", "m2"} {
+		if err := p.Publish(ctx, message(id)); err == nil {
+			t.Fatalf("%s: expected the dead endpoint to fail the publish", id)
+		}
+	}
+	// While open it is not called at all, and the good endpoint still is.
+	err = p.Publish(ctx, message("m3"))
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+	if calls, _ := dead.counts(); calls != 2 {
+		t.Fatalf("expected 2 calls to the dead endpoint, got %d", calls)
+	}
+	if _, ok := good.counts(); ok != 3 {
+		t.Fatalf("expected 3 deliveries to the good endpoint, got %d", ok)
+	}
+
+	// A retry of m3 skips the endpoint that already accepted it.
+	_ = p.Publish(ctx, message("m3"))
+	if _, ok := good.counts(); ok != 3 {
+		t.Fatalf("expected no redelivery to the good endpoint, got %d", ok)
+	}
+
+	// After OpenFor one probe goes through; success closes the breaker.
+	dead.setStatus(http.StatusOK)
+	now = now.Add(time.Minute)
+	if err := p.Publish(ctx, message("m3")); err != nil {
+		t.Fatalf("expected the probe to succeed, got %v", err)
+	}
+	if err := p.Publish(ctx, message("m4")); err != nil {
+		t.Fatalf("Publish after recovery: %v", err)
+	}
+}
+
+func TestPublisher_FiltersByEventType(t *testing.T) {
+	rcv := &receiver{secret: "s", status: http.StatusOK}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+
+	p, _ := NewPublisher([]Endpoint{{URL: srv.URL, Secret: "s", Events: []string{"InboxItemRead"}}}, Config{})
+	if err := p.Publish(context.Background(), message("m1")); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if calls, _ := rcv.counts(); calls != 0 {
+		t.Fatalf("expected the endpoint to be skipped, got %d calls", calls)
+	}
+}
+
+func TestPublisher_RealFailureIsNotReportedAsCircuitOpen(t *testing.T) {
+	dead := &receiver{secret: "a", status: http.StatusServiceUnavailable}
+	flaky := &receiver{secret: "b", status: http.StatusOK}
+	deadSrv, flakySrv := httptest.NewServer(dead), httptest.NewServer(flaky)
+	defer deadSrv.Close()
+	defer flakySrv.Close()
+
+	p, _ := NewPublisher([]Endpoint{
+		{URL: deadSrv.URL, Secret: "a"},
+		{URL: flakySrv.URL, Secret: "b"},
+	}, Config{FailureThreshold: 1, OpenFor: time.Minute})
+	ctx := context.Background()
+
+	// The first failure opens the dead endpoint's breaker.
+	_ = p.Publish(ctx, message("m1"))
+	flaky.setStatus(http.StatusInternalServerError)
+
+	// The dead endpoint is skipped, but the other one was called and failed,
+	// so the message has used up an attempt.
+	if err := p.Publish(ctx, message("m2")); err == nil || errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected a plain failure, got %v", err)
+	}
+}
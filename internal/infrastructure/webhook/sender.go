@@ -0,0 +1,73 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+
+	"inbox-service/internal/application/ports"
+	"inbox-service/internal/application/webhooks"
+)
+
+// ErrBlockedAddress is returned for calls to an address tenant webhooks may
+// not reach (see webhooks.PublicAddr).
+var ErrBlockedAddress = errors.New("webhook: address not allowed")
+
+// Sender makes the signed calls for tenant subscriptions. It signs with every
+// secret of the target (the previous one too, during a rotation) and keeps a
+// breaker per subscription, so a dead endpoint is not called for every one of
+// its deliveries. Unless cfg.AllowPrivate is set or cfg.Client is given, it
+// only connects to public addresses, checked on every dial (redirects and
+// DNS changes included).
+type Sender struct {
+	cfg      Config
+	now      func() time.Time
+	breakers *breakers
+}
+
+func NewSender(cfg Config) *Sender {
+	if cfg.Client == nil && !cfg.AllowPrivate {
+		cfg.Client = publicOnlyClient()
+	}
+	s := &Sender{cfg: cfg.withDefaults(), now: time.Now}
+	s.breakers = newBreakers(s.cfg.FailureThreshold, s.cfg.OpenFor, func() time.Time { return s.now() })
+	return s
+}
+
+func (s *Sender) Send(ctx context.Context, t ports.WebhookTarget) (int, error) {
+	key := t.Delivery.SubscriptionID
+	if !s.breakers.allow(key) {
+		return 0, fmt.Errorf("%s: %w", t.URL, ports.ErrWebhookCircuitOpen)
+	}
+	code, err := post(ctx, s.cfg, t.URL, t.Secrets, t.Message, s.now())
+	s.breakers.record(key, err)
+	return code, err
+}
+
+// publicOnlyClient connects directly, so the address check sees the
+// receiver's address rather than a proxy's.
+func publicOnlyClient() *http.Client {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: checkDialAddr}
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.Proxy = nil
+	tr.DialContext = dialer.DialContext
+	return &http.Client{Transport: tr}
+}
+
+// checkDialAddr runs after DNS resolution, right before each connect.
+func checkDialAddr(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, address)
+	}
+	a, err := netip.ParseAddr(host)
+	if err != nil || !webhooks.PublicAddr(a) {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, address)
+	}
+	return nil
+}
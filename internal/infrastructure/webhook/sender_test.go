@@ -0,0 +1,81 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"inbox-service/internal/application/ports"
+)
+
+func target(subscriptionID, url string, secrets ...string) ports.WebhookTarget {
+	return ports.WebhookTarget{
+		Delivery: ports.WebhookDelivery{ID: "d-" + subscriptionID, SubscriptionID: subscriptionID},
+		URL:      url,
+		Secrets:  secrets,
+		Message:  message("m1"),
+	}
+}
+
+func TestSender_SignsWithEverySecretDuringRotation(t *testing.T) {
+	oldRcv := &receiver{secret: "old", status: http.StatusOK}
+	newRcv := &receiver{secret: "new", status: http.StatusOK}
+	oldSrv, newSrv := httptest.NewServer(oldRcv), httptest.NewServer(newRcv)
+	defer oldSrv.Close()
+	defer newSrv.Close()
+
+	s := NewSender(Config{AllowPrivate: true})
+	// A receiver still on the old secret and one already on the new secret
+	// both accept the same signed request.
+	for _, url := range []string{oldSrv.URL, newSrv.URL} {
+		if code, err := s.Send(context.Background(), target("sub", url, "new", "old")); err != nil || code != http.StatusOK {
+			t.Fatalf("Send to %s: %d, %v", url, code, err)
+		}
+	}
+	if _, ok := oldRcv.counts(); ok != 1 {
+		t.Fatalf("expected the old-secret receiver to verify, got %d", ok)
+	}
+	if _, ok := newRcv.counts(); ok != 1 {
+		t.Fatalf("expected the new-secret receiver to verify, got %d", ok)
+	}
+}
+
+func TestSender_BreakerIsPerSubscription(t *testing.T) {
+	dead := &receiver{secret: "s", status: http.StatusInternalServerError}
+	srv := httptest.NewServer(dead)
+	defer srv.Close()
+
+	s := NewSender(Config{FailureThreshold: 1, OpenFor: time.Minute, AllowPrivate: true})
+	ctx := context.Background()
+
+	if code, err := s.Send(ctx, target("a", srv.URL, "s")); err == nil || code != http.StatusInternalServerError {
+		t.Fatalf("expected a 500 failure, got %d, %v", code, err)
+	}
+	if _, err := s.Send(ctx, target("a", srv.URL, "s")); !errors.Is(err, ports.ErrWebhookCircuitOpen) {
+		t.Fatalf("expected subscription a's circuit to be open, got %v", err)
+	}
+	// Another subscription on the same URL still gets called.
+	if _, err := s.Send(ctx, target("b", srv.URL, "s")); errors.Is(err, ports.ErrWebhookCircuitOpen) {
+		t.Fatalf("expected subscription b to be called")
+	}
+	if calls, _ := dead.counts(); calls != 2 {
+		t.Fatalf("expected 2 calls, got %d", calls)
+	}
+}
+
+func TestSender_RefusesPrivateAddresses(t *testing.T) {
+	rcv := &receiver{secret: "s", status: http.StatusOK}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+
+	s := NewSender(Config{})
+	if _, err := s.Send(context.Background(), target("a", srv.URL, "s")); !errors.Is(err, ErrBlockedAddress) {
+		t.Fatalf("expected ErrBlockedAddress for a loopback receiver, got %v", err)
+	}
+	if calls, _ := rcv.counts(); calls != 0 {
+		t.Fatalf("expected no call to reach the receiver, got %d", calls)
+	}
+}